@@ -0,0 +1,648 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// aclCategories lists every ACL category in the order ACL CAT reports them.
+var aclCategories = []string{
+	"keyspace", "read", "write", "set", "sortedset", "list", "hash", "string",
+	"bitmap", "hyperloglog", "geo", "stream", "pubsub", "admin", "fast", "slow",
+	"blocking", "dangerous", "connection", "transaction", "scripting",
+}
+
+var (
+	errACLSyntax          = errors.New("Syntax error")
+	errACLUnknownCommand  = errors.New("Unknown command or category name in ACL")
+	errACLBadPasswordHash = errors.New("The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+	errACLNoSuchPassword  = errors.New("No such password in ACL")
+
+	errNoPermissionKey     = ErrorReply("NOPERM No permissions to access a key")
+	errNoPermissionChannel = ErrorReply("NOPERM No permissions to access a channel")
+)
+
+// aclKeyPattern is one key pattern of a user. ~pattern grants both read and
+// write access, %R~ and %W~ only one of them.
+type aclKeyPattern struct {
+	pattern string
+	read    bool
+	write   bool
+}
+
+// ACLUser is one user of the ACL subsystem: whether it may log in, with which
+// passwords, and which commands, keys and channels it may use.
+type ACLUser struct {
+	Name    string
+	Enabled bool
+	NoPass  bool
+	// passwordHashes holds the SHA-256 hex digests of the user's passwords.
+	passwordHashes []string
+
+	// baseAllCommands is set by +@all and cleared by -@all; allowedCommands
+	// overrides it per command for the rules applied since. commandRules
+	// keeps those rules to describe the user.
+	baseAllCommands bool
+	commandRules    []string
+	allowedCommands map[string]bool
+	// allowedSubcommands lists subcommands allowed with +command|sub while
+	// the command as a whole is not.
+	allowedSubcommands map[string]map[string]bool
+
+	keyPatterns     []aclKeyPattern
+	channelPatterns []string
+}
+
+var (
+	aclMutex sync.RWMutex
+	aclUsers = make(map[string]*ACLUser)
+)
+
+func init() {
+	resetACL()
+}
+
+func ResetACLForTest() {
+	resetACL()
+}
+
+// resetACL leaves only the default user, which may do anything without a
+// password, and clears the ACL log.
+func resetACL() {
+	aclMutex.Lock()
+	defer aclMutex.Unlock()
+
+	aclUsers = map[string]*ACLUser{defaultUserName: newDefaultACLUser()}
+	resetACLLogLocked()
+}
+
+func newACLUser(name string) *ACLUser {
+	return &ACLUser{
+		Name:               name,
+		allowedCommands:    make(map[string]bool),
+		allowedSubcommands: make(map[string]map[string]bool),
+	}
+}
+
+func newDefaultACLUser() *ACLUser {
+	user := newACLUser(defaultUserName)
+	for _, rule := range []string{"on", "nopass", "allkeys", "allchannels", "+@all"} {
+		user.applyRule(rule)
+	}
+	return user
+}
+
+func (user *ACLUser) clone() *ACLUser {
+	copied := *user
+	copied.passwordHashes = append([]string(nil), user.passwordHashes...)
+	copied.commandRules = append([]string(nil), user.commandRules...)
+	copied.keyPatterns = append([]aclKeyPattern(nil), user.keyPatterns...)
+	copied.channelPatterns = append([]string(nil), user.channelPatterns...)
+	copied.allowedCommands = make(map[string]bool, len(user.allowedCommands))
+	for name, allowed := range user.allowedCommands {
+		copied.allowedCommands[name] = allowed
+	}
+	copied.allowedSubcommands = make(map[string]map[string]bool, len(user.allowedSubcommands))
+	for name, subcommands := range user.allowedSubcommands {
+		copied.allowedSubcommands[name] = make(map[string]bool, len(subcommands))
+		for subcommand := range subcommands {
+			copied.allowedSubcommands[name][subcommand] = true
+		}
+	}
+	return &copied
+}
+
+func hashACLPassword(password string) string {
+	digest := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(digest[:])
+}
+
+func isValidACLPasswordHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	for _, character := range hash {
+		if !(character >= '0' && character <= '9') && !(character >= 'a' && character <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRule applies one ACL SETUSER rule to the user.
+func (user *ACLUser) applyRule(rule string) error {
+	lowered := strings.ToLower(rule)
+	switch {
+	case lowered == "on":
+		user.Enabled = true
+	case lowered == "off":
+		user.Enabled = false
+	case lowered == "nopass":
+		user.NoPass = true
+		user.passwordHashes = nil
+	case lowered == "resetpass":
+		user.NoPass = false
+		user.passwordHashes = nil
+	case lowered == "allkeys":
+		user.keyPatterns = []aclKeyPattern{{pattern: "*", read: true, write: true}}
+	case lowered == "resetkeys":
+		user.keyPatterns = nil
+	case lowered == "allchannels":
+		user.channelPatterns = []string{"*"}
+	case lowered == "resetchannels":
+		user.channelPatterns = nil
+	case lowered == "allcommands":
+		user.setAllCommands(true)
+	case lowered == "nocommands":
+		user.setAllCommands(false)
+	case lowered == "reset":
+		for _, resetRule := range []string{"resetpass", "resetkeys", "resetchannels", "off", "-@all"} {
+			user.applyRule(resetRule)
+		}
+	case strings.HasPrefix(rule, ">"):
+		user.addPasswordHash(hashACLPassword(rule[1:]))
+	case strings.HasPrefix(rule, "#"):
+		if !isValidACLPasswordHash(rule[1:]) {
+			return errACLBadPasswordHash
+		}
+		user.addPasswordHash(rule[1:])
+	case strings.HasPrefix(rule, "<"):
+		return user.removePasswordHash(hashACLPassword(rule[1:]))
+	case strings.HasPrefix(rule, "!"):
+		if !isValidACLPasswordHash(rule[1:]) {
+			return errACLBadPasswordHash
+		}
+		return user.removePasswordHash(rule[1:])
+	case strings.HasPrefix(rule, "~"):
+		user.addKeyPattern(aclKeyPattern{pattern: rule[1:], read: true, write: true})
+	case strings.HasPrefix(lowered, "%"):
+		return user.applyKeyPermissionRule(rule)
+	case strings.HasPrefix(rule, "&"):
+		user.addChannelPattern(rule[1:])
+	case strings.HasPrefix(rule, "+") || strings.HasPrefix(rule, "-"):
+		return user.applyCommandRule(lowered)
+	default:
+		return errACLSyntax
+	}
+
+	return nil
+}
+
+func (user *ACLUser) addPasswordHash(hash string) {
+	user.NoPass = false
+	for _, existing := range user.passwordHashes {
+		if existing == hash {
+			return
+		}
+	}
+	user.passwordHashes = append(user.passwordHashes, hash)
+}
+
+func (user *ACLUser) removePasswordHash(hash string) error {
+	for index, existing := range user.passwordHashes {
+		if existing == hash {
+			user.passwordHashes = append(user.passwordHashes[:index], user.passwordHashes[index+1:]...)
+			return nil
+		}
+	}
+	return errACLNoSuchPassword
+}
+
+func (user *ACLUser) addKeyPattern(pattern aclKeyPattern) {
+	if len(user.keyPatterns) == 1 && user.keyPatterns[0] == (aclKeyPattern{pattern: "*", read: true, write: true}) {
+		return
+	}
+	if pattern == (aclKeyPattern{pattern: "*", read: true, write: true}) {
+		user.keyPatterns = nil
+	}
+	user.keyPatterns = append(user.keyPatterns, pattern)
+}
+
+// applyKeyPermissionRule handles %R~pattern, %W~pattern and %RW~pattern.
+func (user *ACLUser) applyKeyPermissionRule(rule string) error {
+	separator := strings.Index(rule, "~")
+	if separator < 2 {
+		return errACLSyntax
+	}
+
+	pattern := aclKeyPattern{pattern: rule[separator+1:]}
+	for _, permission := range strings.ToUpper(rule[1:separator]) {
+		switch permission {
+		case 'R':
+			pattern.read = true
+		case 'W':
+			pattern.write = true
+		default:
+			return errACLSyntax
+		}
+	}
+
+	user.addKeyPattern(pattern)
+	return nil
+}
+
+func (user *ACLUser) addChannelPattern(pattern string) {
+	if len(user.channelPatterns) == 1 && user.channelPatterns[0] == "*" {
+		return
+	}
+	if pattern == "*" {
+		user.channelPatterns = nil
+	}
+	user.channelPatterns = append(user.channelPatterns, pattern)
+}
+
+func (user *ACLUser) setAllCommands(allowed bool) {
+	user.baseAllCommands = allowed
+	user.commandRules = nil
+	user.allowedSubcommands = make(map[string]map[string]bool)
+	user.allowedCommands = make(map[string]bool)
+}
+
+// applyCommandRule handles +command, -command, +command|subcommand,
+// +@category and -@category.
+func (user *ACLUser) applyCommandRule(rule string) error {
+	allow := rule[0] == '+'
+	target := rule[1:]
+
+	if target == "@all" {
+		user.setAllCommands(allow)
+		return nil
+	}
+
+	if strings.HasPrefix(target, "@") {
+		specs, known := commandsInACLCategory(target[1:])
+		if !known {
+			return errACLUnknownCommand
+		}
+		for _, spec := range specs {
+			user.allowedCommands[spec.Name] = allow
+			delete(user.allowedSubcommands, spec.Name)
+		}
+		user.commandRules = append(user.commandRules, rule)
+		return nil
+	}
+
+	name, subcommand, hasSubcommand := strings.Cut(target, "|")
+	if LookupCommandSpecByName(name) == nil {
+		return errACLUnknownCommand
+	}
+
+	if hasSubcommand {
+		if !allow || subcommand == "" {
+			return errACLSyntax
+		}
+		if user.allowedSubcommands[name] == nil {
+			user.allowedSubcommands[name] = make(map[string]bool)
+		}
+		user.allowedSubcommands[name][subcommand] = true
+	} else {
+		user.allowedCommands[name] = allow
+		delete(user.allowedSubcommands, name)
+	}
+
+	user.commandRules = append(user.commandRules, rule)
+	return nil
+}
+
+// commandsInACLCategory returns the commands in a category, and whether the
+// category exists at all.
+func commandsInACLCategory(category string) ([]*CommandSpec, bool) {
+	category = strings.ToLower(category)
+	known := false
+	for _, name := range aclCategories {
+		if name == category {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, false
+	}
+
+	var specs []*CommandSpec
+	for _, spec := range CommandSpecs() {
+		for _, specCategory := range spec.Categories() {
+			if specCategory == "@"+category {
+				specs = append(specs, spec)
+				break
+			}
+		}
+	}
+	return specs, true
+}
+
+// canRunCommand reports whether the user may run the command with the given
+// arguments.
+func (user *ACLUser) canRunCommand(spec *CommandSpec, args []string) bool {
+	allowed, overridden := user.allowedCommands[spec.Name]
+	if !overridden {
+		allowed = user.baseAllCommands
+	}
+	if allowed {
+		return true
+	}
+
+	subcommands := user.allowedSubcommands[spec.Name]
+	return len(args) > 0 && subcommands[strings.ToLower(args[0])]
+}
+
+// canAccessKey reports whether one of the user's key patterns grants every
+// access the command needs on the key.
+func (user *ACLUser) canAccessKey(key string, access KeyAccess) bool {
+	for _, pattern := range user.keyPatterns {
+		if access&KeyAccessRead != 0 && !pattern.read || access&KeyAccessWrite != 0 && !pattern.write {
+			continue
+		}
+		if globMatch(pattern.pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (user *ACLUser) canAccessChannel(channel string) bool {
+	for _, pattern := range user.channelPatterns {
+		if globMatch(pattern, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (user *ACLUser) checkPassword(password string) bool {
+	if user.NoPass {
+		return true
+	}
+
+	hash := hashACLPassword(password)
+	for _, existing := range user.passwordHashes {
+		if existing == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// describeCommands renders the user's command permissions as rules.
+func (user *ACLUser) describeCommands() string {
+	base := "-@all"
+	if user.baseAllCommands {
+		base = "+@all"
+	}
+	return strings.Join(append([]string{base}, user.commandRules...), " ")
+}
+
+func (user *ACLUser) describeKeys() string {
+	parts := make([]string, 0, len(user.keyPatterns))
+	for _, pattern := range user.keyPatterns {
+		switch {
+		case pattern.read && pattern.write:
+			parts = append(parts, "~"+pattern.pattern)
+		case pattern.read:
+			parts = append(parts, "%R~"+pattern.pattern)
+		default:
+			parts = append(parts, "%W~"+pattern.pattern)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (user *ACLUser) describeChannels() string {
+	if len(user.channelPatterns) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(user.channelPatterns))
+	for _, pattern := range user.channelPatterns {
+		parts = append(parts, "&"+pattern)
+	}
+	return strings.Join(parts, " ")
+}
+
+// describe renders the user as an ACL LIST line, which is also the aclfile
+// format.
+func (user *ACLUser) describe() string {
+	parts := []string{"user", user.Name}
+	if user.Enabled {
+		parts = append(parts, "on")
+	} else {
+		parts = append(parts, "off")
+	}
+	if user.NoPass {
+		parts = append(parts, "nopass")
+	}
+	for _, hash := range user.passwordHashes {
+		parts = append(parts, "#"+hash)
+	}
+	if keys := user.describeKeys(); keys != "" {
+		parts = append(parts, keys)
+	}
+	if channels := user.describeChannels(); channels != "" {
+		parts = append(parts, channels)
+	} else {
+		parts = append(parts, "resetchannels")
+	}
+	parts = append(parts, user.describeCommands())
+
+	return strings.Join(parts, " ")
+}
+
+// SetACLUser creates or modifies a user. The rules are applied to a copy, so
+// the user is left untouched when any rule is invalid.
+func SetACLUser(name string, rules []string) error {
+	aclMutex.Lock()
+	defer aclMutex.Unlock()
+
+	user, err := buildACLUser(aclUsers[name], name, rules)
+	if err != nil {
+		return err
+	}
+
+	aclUsers[name] = user
+	return nil
+}
+
+func buildACLUser(existing *ACLUser, name string, rules []string) (*ACLUser, error) {
+	var user *ACLUser
+	if existing != nil {
+		user = existing.clone()
+	} else {
+		user = newACLUser(name)
+	}
+
+	for _, rule := range rules {
+		if err := user.applyRule(rule); err != nil {
+			return nil, fmt.Errorf("Error in ACL SETUSER modifier '%s': %w", rule, err)
+		}
+	}
+
+	return user, nil
+}
+
+// LookupACLUser returns a copy of the named user.
+func LookupACLUser(name string) (*ACLUser, bool) {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	user, exists := aclUsers[name]
+	if !exists {
+		return nil, false
+	}
+	return user.clone(), true
+}
+
+// DeleteACLUsers removes the named users and returns how many existed.
+func DeleteACLUsers(names []string) int {
+	aclMutex.Lock()
+	defer aclMutex.Unlock()
+
+	deleted := 0
+	for _, name := range names {
+		if _, exists := aclUsers[name]; exists {
+			delete(aclUsers, name)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// ACLUserNames returns every user name, sorted.
+func ACLUserNames() []string {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	names := make([]string, 0, len(aclUsers))
+	for name := range aclUsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ACLListLines returns the ACL LIST description of every user.
+func ACLListLines() []string {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	names := make([]string, 0, len(aclUsers))
+	for name := range aclUsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, aclUsers[name].describe())
+	}
+	return lines
+}
+
+// ApplyRequirePass makes requirepass the default user's only password, or
+// lets the default user in without one when it is empty.
+func ApplyRequirePass(password string) {
+	rules := []string{"nopass"}
+	if password != "" {
+		rules = []string{"resetpass", ">" + password}
+	}
+
+	if err := SetACLUser(defaultUserName, rules); err != nil {
+		LogWarning("Failed to apply requirepass: %s", err.Error())
+	}
+}
+
+// isDefaultUserPasswordless reports whether new connections are logged in
+// as the default user without having to authenticate.
+func isDefaultUserPasswordless() bool {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	user, exists := aclUsers[defaultUserName]
+	return exists && user.Enabled && user.NoPass
+}
+
+// checkACLCredentials reports whether the user exists, is enabled and
+// accepts the password.
+func checkACLCredentials(username string, password string) bool {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	user, exists := aclUsers[username]
+	return exists && user.Enabled && user.checkPassword(password)
+}
+
+// aclDenial describes why a command was refused.
+type aclDenial struct {
+	reason string
+	object string
+}
+
+// checkACLPermissions checks a command against the user's command, key and
+// channel permissions.
+func checkACLPermissions(username string, spec *CommandSpec, args []string) (aclDenial, bool) {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	user, exists := aclUsers[username]
+	if !exists || !user.canRunCommand(spec, args) {
+		return aclDenial{reason: aclDeniedCommand, object: spec.Name}, false
+	}
+
+	argv := append([]string{spec.Name}, args...)
+	for _, position := range spec.KeyPositions(argv) {
+		if !user.canAccessKey(argv[position], spec.KeyAccess) {
+			return aclDenial{reason: aclDeniedKey, object: argv[position]}, false
+		}
+	}
+
+	for _, channel := range commandChannels(spec, args) {
+		if !user.canAccessChannel(channel) {
+			return aclDenial{reason: aclDeniedChannel, object: channel}, false
+		}
+	}
+
+	return aclDenial{}, true
+}
+
+// commandChannels returns the channels a command publishes or subscribes to.
+func commandChannels(spec *CommandSpec, args []string) []string {
+	switch spec.Type {
+	case CmdPUBLISH:
+		if len(args) > 0 {
+			return args[:1]
+		}
+	case CmdSUBSCRIBE:
+		return args
+	}
+	return nil
+}
+
+// checkCommandPermission returns a NOPERM error, and logs the denial, when
+// the connection's user may not run the command. Commands that need no
+// authentication and commands from the master are always allowed.
+func checkCommandPermission(connection net.Conn, spec *CommandSpec, command *RedisCommand) Reply {
+	if spec.HasFlag(FlagNoAuth) || IsMasterLinkConnection(connection) {
+		return Reply{}
+	}
+
+	username := snapshotClient(connection).User
+	denial, allowed := checkACLPermissions(username, spec, command.Args)
+	if allowed {
+		return Reply{}
+	}
+
+	recordACLDenial(connection, denial.reason, denial.object, username)
+	switch denial.reason {
+	case aclDeniedKey:
+		return errNoPermissionKey
+	case aclDeniedChannel:
+		return errNoPermissionChannel
+	default:
+		return ErrorReply(fmt.Sprintf("NOPERM User %s has no permissions to run the '%s' command", username, spec.Name))
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var errACLDeleteDefaultUser = ErrorReply("ERR The 'default' user cannot be removed")
+
+func aclWrongNumberOfArguments(subcommand string) Reply {
+	return WrongNumberOfArgumentsReply("acl|" + strings.ToLower(subcommand))
+}
+
+// HandleACL dispatches the ACL subcommands.
+func HandleACL(connection net.Conn, command *RedisCommand) Reply {
+	if len(command.Args) == 0 {
+		return WrongNumberOfArgumentsReply("acl")
+	}
+
+	subcommand := strings.ToUpper(command.Args[0])
+	args := command.Args[1:]
+
+	switch subcommand {
+	case "SETUSER":
+		return handleACLSetUser(args)
+	case "GETUSER":
+		return handleACLGetUser(args)
+	case "LIST":
+		if len(args) != 0 {
+			return aclWrongNumberOfArguments(subcommand)
+		}
+		return BulkStringArrayReply(ACLListLines())
+	case "USERS":
+		if len(args) != 0 {
+			return aclWrongNumberOfArguments(subcommand)
+		}
+		return BulkStringArrayReply(ACLUserNames())
+	case "DELUSER":
+		return handleACLDelUser(args)
+	case "WHOAMI":
+		if len(args) != 0 {
+			return aclWrongNumberOfArguments(subcommand)
+		}
+		return BulkStringReply(snapshotClient(connection).User)
+	case "CAT":
+		return handleACLCat(args)
+	case "LOG":
+		return handleACLLog(args)
+	case "LOAD", "SAVE":
+		return handleACLFile(subcommand, args)
+	default:
+		return ErrorReply(fmt.Sprintf("ERR unknown subcommand '%s'. Try ACL HELP.", command.Args[0]))
+	}
+}
+
+func handleACLSetUser(args []string) Reply {
+	if len(args) < 1 {
+		return aclWrongNumberOfArguments("SETUSER")
+	}
+
+	if err := SetACLUser(args[0], args[1:]); err != nil {
+		return ErrorReply("ERR " + err.Error())
+	}
+
+	return OKReply()
+}
+
+func handleACLGetUser(args []string) Reply {
+	if len(args) != 1 {
+		return aclWrongNumberOfArguments("GETUSER")
+	}
+
+	user, exists := LookupACLUser(args[0])
+	if !exists {
+		return NullReply()
+	}
+
+	flags := []string{"off"}
+	if user.Enabled {
+		flags[0] = "on"
+	}
+	if user.NoPass {
+		flags = append(flags, "nopass")
+	}
+
+	return MapReply(
+		BulkStringReply("flags"), BulkStringArrayReply(flags),
+		BulkStringReply("passwords"), BulkStringArrayReply(user.passwordHashes),
+		BulkStringReply("commands"), BulkStringReply(user.describeCommands()),
+		BulkStringReply("keys"), BulkStringReply(user.describeKeys()),
+		BulkStringReply("channels"), BulkStringReply(user.describeChannels()),
+		BulkStringReply("selectors"), ArrayReply(),
+	)
+}
+
+func handleACLDelUser(args []string) Reply {
+	if len(args) < 1 {
+		return aclWrongNumberOfArguments("DELUSER")
+	}
+
+	for _, name := range args {
+		if name == defaultUserName {
+			return errACLDeleteDefaultUser
+		}
+	}
+
+	deleted := DeleteACLUsers(args)
+	disconnectClientsOfMissingUsers()
+
+	return IntegerReply(int64(deleted))
+}
+
+func handleACLCat(args []string) Reply {
+	switch len(args) {
+	case 0:
+		return BulkStringArrayReply(aclCategories)
+	case 1:
+		specs, known := commandsInACLCategory(args[0])
+		if !known {
+			return ErrorReply(fmt.Sprintf("ERR Unknown category '%s'", args[0]))
+		}
+		names := make([]string, 0, len(specs))
+		for _, spec := range specs {
+			names = append(names, spec.Name)
+		}
+		return BulkStringArrayReply(names)
+	default:
+		return aclWrongNumberOfArguments("CAT")
+	}
+}
+
+func handleACLLog(args []string) Reply {
+	count := 10
+	switch len(args) {
+	case 0:
+	case 1:
+		if strings.EqualFold(args[0], "RESET") {
+			aclMutex.Lock()
+			resetACLLogLocked()
+			aclMutex.Unlock()
+			return OKReply()
+		}
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 0 {
+			return ErrorReply("ERR value is out of range, must be positive")
+		}
+		count = parsed
+	default:
+		return aclWrongNumberOfArguments("LOG")
+	}
+
+	return aclLogReply(count)
+}
+
+func handleACLFile(subcommand string, args []string) Reply {
+	if len(args) != 0 {
+		return aclWrongNumberOfArguments(subcommand)
+	}
+
+	path := GetConfig().AclFile
+	if path == "" {
+		return ErrorReply("ERR " + errNoACLFile.Error())
+	}
+
+	var err error
+	if subcommand == "LOAD" {
+		err = LoadACLFile(path)
+	} else {
+		err = SaveACLFile(path)
+	}
+	if err != nil {
+		return ErrorReply("ERR " + err.Error())
+	}
+
+	return OKReply()
+}
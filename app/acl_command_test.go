@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetACLTestState(t *testing.T) {
+	t.Helper()
+	resetHelloTestState(t)
+	ResetACLForTest()
+	t.Cleanup(ResetACLForTest)
+}
+
+func aclCommand(connection net.Conn, args ...string) Reply {
+	return HandleConnectionCommand(connection, &RedisCommand{Type: CmdACL, Args: args})
+}
+
+func authenticateAs(t *testing.T, username string, password string) net.Conn {
+	t.Helper()
+	connection := testConnection(t)
+	RegisterClient(connection)
+	result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdAUTH, Args: []string{username, password}}).String()
+	if result != "+OK\r\n" {
+		t.Fatalf("AUTH %s = %q, expected +OK", username, result)
+	}
+	return connection
+}
+
+func TestHandleACLUserManagement(t *testing.T) {
+	resetACLTestState(t)
+	connection := testConnection(t)
+	RegisterClient(connection)
+
+	if result := aclCommand(connection, "SETUSER", "alice", "on", ">pw", "+get").String(); result != "+OK\r\n" {
+		t.Fatalf("ACL SETUSER = %q, expected +OK", result)
+	}
+	if result := aclCommand(connection, "USERS").String(); result != "*2\r\n$5\r\nalice\r\n$7\r\ndefault\r\n" {
+		t.Errorf("ACL USERS = %q", result)
+	}
+	if result := aclCommand(connection, "GETUSER", "nobody").String(); result != "$-1\r\n" {
+		t.Errorf("ACL GETUSER nobody = %q, expected null", result)
+	}
+	if result := aclCommand(connection, "WHOAMI").String(); result != "$7\r\ndefault\r\n" {
+		t.Errorf("ACL WHOAMI = %q, expected default", result)
+	}
+	if result := aclCommand(connection, "DELUSER", "default").String(); result != errACLDeleteDefaultUser.String() {
+		t.Errorf("ACL DELUSER default = %q, expected %q", result, errACLDeleteDefaultUser.String())
+	}
+	if result := aclCommand(connection, "DELUSER", "alice", "nobody").String(); result != ":1\r\n" {
+		t.Errorf("ACL DELUSER = %q, expected :1", result)
+	}
+}
+
+func TestACLDeniesCommandsKeysAndChannels(t *testing.T) {
+	resetACLTestState(t)
+	SetACLUser("app", []string{"on", ">pw", "%R~shared:*", "&news", "+@all", "-keys"})
+	connection := authenticateAs(t, "app", "pw")
+
+	if result := aclCommand(connection, "WHOAMI").String(); result != "$3\r\napp\r\n" {
+		t.Errorf("ACL WHOAMI = %q, expected app", result)
+	}
+
+	tests := []struct {
+		command  *RedisCommand
+		expected string
+	}{
+		{&RedisCommand{Type: CmdKEYS, Args: []string{"*"}}, "-NOPERM User app has no permissions to run the 'keys' command\r\n"},
+		{&RedisCommand{Type: CmdSET, Args: []string{"shared:1", "v"}}, errNoPermissionKey.String()},
+		{&RedisCommand{Type: CmdGET, Args: []string{"shared:1"}}, "$-1\r\n"},
+		{&RedisCommand{Type: CmdPUBLISH, Args: []string{"sports", "hi"}}, errNoPermissionChannel.String()},
+	}
+	for _, test := range tests {
+		if result := HandleConnectionCommand(connection, test.command).String(); result != test.expected {
+			t.Errorf("%s %v = %q, expected %q", test.command.Type, test.command.Args, result, test.expected)
+		}
+	}
+
+	log := aclCommand(connection, "LOG").String()
+	for _, field := range []string{"$7\r\ncommand\r\n", "$3\r\nkey\r\n", "$7\r\nchannel\r\n", "$8\r\nshared:1\r\n"} {
+		if !strings.Contains(log, field) {
+			t.Errorf("ACL LOG = %q, expected it to contain %q", log, field)
+		}
+	}
+	if result := aclCommand(connection, "LOG", "RESET").String(); result != "+OK\r\n" {
+		t.Fatalf("ACL LOG RESET = %q", result)
+	}
+	if result := aclCommand(connection, "LOG").String(); result != "*0\r\n" {
+		t.Errorf("ACL LOG after RESET = %q, expected empty", result)
+	}
+}
+
+func TestACLDenialInsideMultiAbortsExec(t *testing.T) {
+	resetACLTestState(t)
+	SetACLUser("reader", []string{"on", ">pw", "allkeys", "+@read", "+multi", "+exec"})
+	connection := authenticateAs(t, "reader", "pw")
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdMULTI})
+	queued := HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"k", "v"}}).String()
+	if !strings.HasPrefix(queued, "-NOPERM") {
+		t.Errorf("queued SET = %q, expected NOPERM", queued)
+	}
+	if result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdEXEC}).String(); result != errExecAbort.String() {
+		t.Errorf("EXEC = %q, expected %q", result, errExecAbort.String())
+	}
+}
+
+func TestACLSaveAndLoadRoundTrip(t *testing.T) {
+	resetACLTestState(t)
+	originalConfig := serverConfig
+	t.Cleanup(func() { serverConfig = originalConfig })
+	connection := testConnection(t)
+	RegisterClient(connection)
+
+	if result := aclCommand(connection, "SAVE").String(); !strings.HasPrefix(result, "-ERR This Redis instance is not configured to use an ACL file") {
+		t.Errorf("ACL SAVE without aclfile = %q", result)
+	}
+
+	serverConfig.AclFile = filepath.Join(t.TempDir(), "users.acl")
+	SetACLUser("alice", []string{"on", ">pw", "~cache:*", "+@read"})
+	before := aclCommand(connection, "LIST").String()
+
+	if result := aclCommand(connection, "SAVE").String(); result != "+OK\r\n" {
+		t.Fatalf("ACL SAVE = %q", result)
+	}
+	DeleteACLUsers([]string{"alice"})
+	if result := aclCommand(connection, "LOAD").String(); result != "+OK\r\n" {
+		t.Fatalf("ACL LOAD = %q", result)
+	}
+	if after := aclCommand(connection, "LIST").String(); after != before {
+		t.Errorf("ACL LIST after LOAD = %q, expected %q", after, before)
+	}
+}
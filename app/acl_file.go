@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errNoACLFile = errors.New("This Redis instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE (assuming you have a Redis configuration file set) in order to store users in the Redis configuration.")
+
+// LoadACLFile replaces every user with those defined in path. Each line is
+// "user <name> <rules...>" as written by ACL SAVE; blank lines and lines
+// starting with # are ignored. Nothing changes unless the whole file is
+// valid. A file that does not define the default user gets a fresh one.
+func LoadACLFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Error loading ACLs, opening file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	users := make(map[string]*ACLUser)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] != "user" || len(fields) < 2 {
+			return fmt.Errorf("%s:%d: line should start with user keyword", path, lineNumber)
+		}
+		name := fields[1]
+		if _, duplicate := users[name]; duplicate {
+			return fmt.Errorf("%s:%d: Duplicate user '%s' found", path, lineNumber, name)
+		}
+
+		user, err := buildACLUser(nil, name, fields[2:])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		users[name] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Error loading ACLs from '%s': %w", path, err)
+	}
+
+	if _, exists := users[defaultUserName]; !exists {
+		users[defaultUserName] = newDefaultACLUser()
+	}
+
+	aclMutex.Lock()
+	aclUsers = users
+	aclMutex.Unlock()
+
+	disconnectClientsOfMissingUsers()
+	return nil
+}
+
+// SaveACLFile writes every user to path in ACL LIST form, replacing the
+// file only once it is completely written.
+func SaveACLFile(path string) error {
+	contents := strings.Join(ACLListLines(), "\n") + "\n"
+
+	err := replaceFile(path, "temp-acl-*", 0644, func(file *os.File) error {
+		_, err := file.WriteString(contents)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Error saving ACLs to '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// disconnectClientsOfMissingUsers closes the connections of clients whose
+// user no longer exists.
+func disconnectClientsOfMissingUsers() {
+	for _, client := range ClientsSnapshot() {
+		if _, exists := LookupACLUser(client.User); !exists {
+			LogVerbose("Closing client %d whose user '%s' was removed", client.ID, client.User)
+			client.Connection.Close()
+		}
+	}
+}
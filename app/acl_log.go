@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net"
+	"time"
+)
+
+// aclLogMaxLength is the number of entries ACL LOG keeps, like Redis'
+// default acllog-max-len.
+const aclLogMaxLength = 128
+
+// aclLogGroupingWindow is how long a repeated denial bumps the count of the
+// existing entry instead of adding a new one.
+const aclLogGroupingWindow = 60 * time.Second
+
+// Reasons recorded in the ACL log.
+const (
+	aclDeniedAuth    = "auth"
+	aclDeniedCommand = "command"
+	aclDeniedKey     = "key"
+	aclDeniedChannel = "channel"
+)
+
+// aclLogEntry records a denied command or failed authentication.
+type aclLogEntry struct {
+	count       int64
+	reason      string
+	context     string
+	object      string
+	username    string
+	clientInfo  string
+	entryID     int64
+	createdAt   time.Time
+	lastUpdated time.Time
+}
+
+var (
+	// aclLogEntries is ordered newest first and guarded by aclMutex.
+	aclLogEntries   []*aclLogEntry
+	nextACLLogEntry int64
+)
+
+func resetACLLogLocked() {
+	aclLogEntries = nil
+}
+
+// recordACLDenial adds an entry to the ACL log, or bumps the count of a
+// recent identical one.
+func recordACLDenial(connection net.Conn, reason string, object string, username string) {
+	context := "toplevel"
+	if isConnectionInTransaction(connection) {
+		context = "multi"
+	}
+	clientInfo := formatClientInfo(snapshotClient(connection), time.Now())
+	now := time.Now()
+
+	aclMutex.Lock()
+	defer aclMutex.Unlock()
+
+	for _, entry := range aclLogEntries {
+		if entry.reason == reason && entry.context == context && entry.object == object &&
+			entry.username == username && now.Sub(entry.lastUpdated) < aclLogGroupingWindow {
+			entry.count++
+			entry.lastUpdated = now
+			entry.clientInfo = clientInfo
+			return
+		}
+	}
+
+	entry := &aclLogEntry{
+		count:       1,
+		reason:      reason,
+		context:     context,
+		object:      object,
+		username:    username,
+		clientInfo:  clientInfo,
+		entryID:     nextACLLogEntry,
+		createdAt:   now,
+		lastUpdated: now,
+	}
+	nextACLLogEntry++
+
+	aclLogEntries = append([]*aclLogEntry{entry}, aclLogEntries...)
+	if len(aclLogEntries) > aclLogMaxLength {
+		aclLogEntries = aclLogEntries[:aclLogMaxLength]
+	}
+}
+
+// aclLogReply renders up to count entries, newest first, as ACL LOG does.
+func aclLogReply(count int) Reply {
+	aclMutex.RLock()
+	defer aclMutex.RUnlock()
+
+	now := time.Now()
+	entries := make([]Reply, 0, min(count, len(aclLogEntries)))
+	for _, entry := range aclLogEntries {
+		if len(entries) == count {
+			break
+		}
+		entries = append(entries, MapReply(
+			BulkStringReply("count"), IntegerReply(entry.count),
+			BulkStringReply("reason"), BulkStringReply(entry.reason),
+			BulkStringReply("context"), BulkStringReply(entry.context),
+			BulkStringReply("object"), BulkStringReply(entry.object),
+			BulkStringReply("username"), BulkStringReply(entry.username),
+			BulkStringReply("age-seconds"), DoubleReply(now.Sub(entry.createdAt).Seconds()),
+			BulkStringReply("client-info"), BulkStringReply(entry.clientInfo),
+			BulkStringReply("entry-id"), IntegerReply(entry.entryID),
+			BulkStringReply("timestamp-created"), IntegerReply(entry.createdAt.UnixMilli()),
+			BulkStringReply("timestamp-last-updated"), IntegerReply(entry.lastUpdated.UnixMilli()),
+		))
+	}
+
+	return ArrayReply(entries...)
+}
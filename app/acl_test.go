@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetACLUserAppliesRules(t *testing.T) {
+	ResetACLForTest()
+	defer ResetACLForTest()
+
+	err := SetACLUser("alice", []string{"on", ">secret", "~cache:*", "%R~shared:*", "&news.*", "+@read", "-zrange", "+set"})
+	if err != nil {
+		t.Fatalf("SetACLUser() error = %v", err)
+	}
+
+	user, exists := LookupACLUser("alice")
+	if !exists {
+		t.Fatal("alice was not created")
+	}
+	if !user.Enabled || !user.checkPassword("secret") || user.checkPassword("wrong") {
+		t.Error("alice should be enabled and accept only her password")
+	}
+
+	expected := "user alice on #" + hashACLPassword("secret") + " ~cache:* %R~shared:* &news.* -@all +@read -zrange +set"
+	if described := user.describe(); described != expected {
+		t.Errorf("describe() = %q, expected %q", described, expected)
+	}
+}
+
+func TestSetACLUserIsAtomic(t *testing.T) {
+	ResetACLForTest()
+	defer ResetACLForTest()
+
+	SetACLUser("bob", []string{"on", "nopass"})
+	err := SetACLUser("bob", []string{"off", "+nosuchcommand"})
+	if err == nil || err.Error() != "Error in ACL SETUSER modifier '+nosuchcommand': Unknown command or category name in ACL" {
+		t.Fatalf("SetACLUser() error = %v", err)
+	}
+
+	user, _ := LookupACLUser("bob")
+	if !user.Enabled {
+		t.Error("a failed SETUSER changed the user")
+	}
+}
+
+func TestSetACLUserRejectsInvalidRules(t *testing.T) {
+	ResetACLForTest()
+	defer ResetACLForTest()
+
+	for _, rule := range []string{"bogus", "#abc", "+@nosuchcategory", "%X~key", "<unknown"} {
+		if err := SetACLUser("carol", []string{rule}); err == nil {
+			t.Errorf("SetACLUser(%q) returned no error", rule)
+		}
+	}
+}
+
+func TestCheckACLPermissions(t *testing.T) {
+	ResetACLForTest()
+	defer ResetACLForTest()
+
+	SetACLUser("app", []string{"on", "nopass", "~cache:*", "%R~shared:*", "%W~inbox:*", "&news.*", "+@all", "-zadd", "+client|id"})
+	SetACLUser("limited", []string{"on", "nopass", "allkeys", "-@all", "+client|id"})
+	SetACLUser("careful", []string{"on", "nopass", "allkeys", "+@all", "-@dangerous"})
+
+	tests := []struct {
+		name           string
+		username       string
+		command        string
+		args           []string
+		expectedReason string
+	}{
+		{"allowed write", "app", "set", []string{"cache:1", "v"}, ""},
+		{"denied command", "app", "zadd", []string{"cache:z", "1", "m"}, aclDeniedCommand},
+		{"key outside patterns", "app", "get", []string{"other"}, aclDeniedKey},
+		{"read-only key read", "app", "get", []string{"shared:1"}, ""},
+		{"read-only key write", "app", "set", []string{"shared:1", "v"}, aclDeniedKey},
+		{"write-only key push", "app", "rpush", []string{"inbox:1", "job"}, ""},
+		{"write-only key pop", "app", "lpop", []string{"inbox:1"}, aclDeniedKey},
+		{"write-only key blocking pop", "app", "blpop", []string{"inbox:1", "0"}, aclDeniedKey},
+		{"write-only key increment", "app", "incr", []string{"inbox:counter"}, aclDeniedKey},
+		{"allowed channel", "app", "publish", []string{"news.today", "hi"}, ""},
+		{"denied channel", "app", "subscribe", []string{"news.today", "sports"}, aclDeniedChannel},
+		{"allowed subcommand", "limited", "client", []string{"ID"}, ""},
+		{"denied subcommand", "limited", "client", []string{"LIST"}, aclDeniedCommand},
+		{"dangerous keys", "careful", "keys", []string{"*"}, aclDeniedCommand},
+		{"safe read", "careful", "get", []string{"cache:1"}, ""},
+		{"unknown user", "ghost", "ping", nil, aclDeniedCommand},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			denial, allowed := checkACLPermissions(test.username, LookupCommandSpecByName(test.command), test.args)
+			if allowed != (test.expectedReason == "") || denial.reason != test.expectedReason {
+				t.Errorf("checkACLPermissions() = %+v, %v; expected reason %q", denial, allowed, test.expectedReason)
+			}
+		})
+	}
+}
+
+func TestCommandTableDeclaresKeyAccess(t *testing.T) {
+	for _, spec := range CommandSpecs() {
+		if (spec.FirstKey != 0 || spec.KeysFunc != nil) && spec.KeyAccess == 0 {
+			t.Errorf("%s takes keys but declares no KeyAccess", spec.Name)
+		}
+	}
+}
+
+func TestACLCategoriesCoverCommandTable(t *testing.T) {
+	for _, spec := range CommandSpecs() {
+		for _, category := range spec.Categories() {
+			if _, known := commandsInACLCategory(strings.TrimPrefix(category, "@")); !known {
+				t.Errorf("%s is in category %s, which ACL CAT does not list", spec.Name, category)
+			}
+		}
+	}
+}
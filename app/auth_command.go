@@ -0,0 +1,62 @@
+package main
+
+import "net"
+
+const defaultUserName = "default"
+
+var (
+	errNoAuth              = ErrorReply("NOAUTH Authentication required.")
+	errAuthWithoutPassword = ErrorReply("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+	errAuthSyntax          = ErrorReply("ERR syntax error")
+)
+
+func parseAuthCommandArguments(command *RedisCommand) (username string, password string, errorResponse Reply) {
+	switch len(command.Args) {
+	case 1:
+		return defaultUserName, command.Args[0], Reply{}
+	case 2:
+		return command.Args[0], command.Args[1], Reply{}
+	case 0:
+		return "", "", WrongNumberOfArgumentsReply("auth")
+	default:
+		return "", "", errAuthSyntax
+	}
+}
+
+// authenticateUser checks a username-password pair against the ACL users.
+func authenticateUser(username string, password string) bool {
+	return checkACLCredentials(username, password)
+}
+
+// isAuthenticationRequired reports whether a connection must authenticate
+// before it may run the command. Unknown commands are left to the usual
+// unknown-command error.
+func isAuthenticationRequired(connection net.Conn, command *RedisCommand) bool {
+	spec := LookupCommandSpec(command.Type)
+	if spec == nil || spec.HasFlag(FlagNoAuth) {
+		return false
+	}
+
+	return !isClientAuthenticated(connection)
+}
+
+// HandleAuth authenticates the connection as the given user, or as the
+// default user when only a password is given.
+func HandleAuth(connection net.Conn, command *RedisCommand) Reply {
+	username, password, errorResponse := parseAuthCommandArguments(command)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	if len(command.Args) == 1 && isDefaultUserPasswordless() {
+		return errAuthWithoutPassword
+	}
+
+	if !authenticateUser(username, password) {
+		recordACLDenial(connection, aclDeniedAuth, "AUTH", username)
+		return errWrongPassword
+	}
+
+	setClientAuthenticated(connection, username)
+	return OKReply()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func requirePasswordForTest(t *testing.T, password string) {
+	t.Helper()
+	resetHelloTestState(t)
+	originalConfig := serverConfig
+	serverConfig.RequirePass = password
+	ResetACLForTest()
+	ApplyRequirePass(password)
+	t.Cleanup(func() {
+		serverConfig = originalConfig
+		ResetACLForTest()
+	})
+}
+
+func TestParseAuthCommandArguments(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedUsername string
+		expectedPassword string
+		expectedError    string
+	}{
+		{name: "password only", args: []string{"secret"}, expectedUsername: "default", expectedPassword: "secret"},
+		{name: "username and password", args: []string{"alice", "secret"}, expectedUsername: "alice", expectedPassword: "secret"},
+		{name: "no arguments", args: []string{}, expectedError: "-ERR wrong number of arguments for 'auth' command\r\n"},
+		{name: "too many arguments", args: []string{"a", "b", "c"}, expectedError: "-ERR syntax error\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			username, password, errorResponse := parseAuthCommandArguments(&RedisCommand{Type: CmdAUTH, Args: test.args})
+			if errorResponse.String() != test.expectedError {
+				t.Fatalf("error = %q, expected %q", errorResponse.String(), test.expectedError)
+			}
+			if username != test.expectedUsername || password != test.expectedPassword {
+				t.Errorf("parsed %q/%q, expected %q/%q", username, password, test.expectedUsername, test.expectedPassword)
+			}
+		})
+	}
+}
+
+func TestUnauthenticatedClientsAreRefused(t *testing.T) {
+	requirePasswordForTest(t, "secret")
+	connection := testConnection(t)
+
+	result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdPING}).String()
+	if result != "-NOAUTH Authentication required.\r\n" {
+		t.Fatalf("PING before AUTH = %q, expected NOAUTH", result)
+	}
+
+	result = HandleConnectionCommand(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"wrong"}}).String()
+	if result != errWrongPassword.String() {
+		t.Fatalf("AUTH with a wrong password = %q, expected %q", result, errWrongPassword.String())
+	}
+
+	result = HandleConnectionCommand(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"secret"}}).String()
+	if result != "+OK\r\n" {
+		t.Fatalf("AUTH with the password = %q, expected OK", result)
+	}
+
+	result = HandleConnectionCommand(connection, &RedisCommand{Type: CmdPING}).String()
+	if result != "+PONG\r\n" {
+		t.Errorf("PING after AUTH = %q, expected PONG", result)
+	}
+}
+
+func TestAuthWithUsername(t *testing.T) {
+	requirePasswordForTest(t, "secret")
+	connection := testConnection(t)
+
+	if result := HandleAuth(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"alice", "secret"}}).String(); result != errWrongPassword.String() {
+		t.Errorf("AUTH as an unknown user = %q, expected %q", result, errWrongPassword.String())
+	}
+	if result := HandleAuth(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"default", "secret"}}).String(); result != "+OK\r\n" {
+		t.Errorf("AUTH default = %q, expected OK", result)
+	}
+}
+
+func TestAuthWithoutRequirePass(t *testing.T) {
+	resetHelloTestState(t)
+	connection := testConnection(t)
+
+	result := HandleAuth(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"anything"}}).String()
+	if result != errAuthWithoutPassword.String() {
+		t.Errorf("AUTH <password> = %q, expected %q", result, errAuthWithoutPassword.String())
+	}
+
+	result = HandleAuth(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"default", "anything"}}).String()
+	if result != "+OK\r\n" {
+		t.Errorf("AUTH default <password> = %q, expected OK", result)
+	}
+}
+
+func TestHelloRequiresAuthentication(t *testing.T) {
+	requirePasswordForTest(t, "secret")
+	connection := testConnection(t)
+
+	result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdHELLO, Args: []string{"3"}}).String()
+	if !strings.HasPrefix(result, "-NOAUTH HELLO must be called with the client already authenticated") {
+		t.Fatalf("HELLO without AUTH = %q, expected NOAUTH", result)
+	}
+
+	result = HandleConnectionCommand(connection, &RedisCommand{Type: CmdHELLO, Args: []string{"3", "AUTH", "default", "secret"}}).String()
+	if !strings.HasPrefix(result, "*14\r\n") {
+		t.Fatalf("HELLO with AUTH = %q, expected the server description", result)
+	}
+	if !isClientAuthenticated(connection) {
+		t.Error("HELLO AUTH did not authenticate the client")
+	}
+}
+
+func TestResetDropsAuthentication(t *testing.T) {
+	requirePasswordForTest(t, "secret")
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdAUTH, Args: []string{"secret"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdRESET})
+
+	if result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdPING}).String(); result != errNoAuth.String() {
+		t.Errorf("PING after RESET = %q, expected NOAUTH", result)
+	}
+}
+
+func TestProtectedModeAdmitsRemoteClientsWhenPasswordIsSet(t *testing.T) {
+	requirePasswordForTest(t, "secret")
+	serverConfig.ProtectedMode = true
+
+	remote := &remoteAddressConnection{Conn: testConnection(t), remoteAddress: &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 50000}}
+	if !admitConnection(remote) {
+		t.Error("admitConnection() refused a remote client although a password is set")
+	}
+}
+
+func TestInitiateHandshakeAuthenticatesWithMaster(t *testing.T) {
+	masterListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start mock master: %v", err)
+	}
+	defer masterListener.Close()
+	host, port, _ := net.SplitHostPort(masterListener.Addr().String())
+
+	go InitiateHandshake(Config{IsReplica: true, MasterHost: host, MasterPort: port, MasterUser: "replicator", MasterAuth: "secret"})
+
+	masterConnection, err := masterListener.Accept()
+	if err != nil {
+		t.Fatalf("accept replica: %v", err)
+	}
+	defer masterConnection.Close()
+	masterConnection.SetDeadline(time.Now().Add(2 * time.Second))
+
+	reader := bufio.NewReader(masterConnection)
+	expected := "*3\r\n$4\r\nAUTH\r\n$10\r\nreplicator\r\n$6\r\nsecret\r\n"
+	received := make([]byte, len(expected))
+	if _, err := reader.Read(received); err != nil {
+		t.Fatalf("read AUTH: %v", err)
+	}
+	if string(received) != expected {
+		t.Fatalf("first command from replica = %q, expected %q", received, expected)
+	}
+
+	masterConnection.Write([]byte("+OK\r\n"))
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "*1\r\n" {
+		t.Errorf("command after AUTH = %q, %v; expected PING", line, err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultBind listens on every IPv4 interface and, where available, every
+// IPv6 interface.
+const DefaultBind = "* -::*"
+
+// bindAddress is one entry of the bind setting. An optional address, written
+// with a leading "-", is skipped when it cannot be bound, for example an IPv6
+// address on a host without IPv6.
+type bindAddress struct {
+	host     string
+	optional bool
+}
+
+// parseBindAddresses splits the bind setting into its addresses. "*" stands
+// for all IPv4 interfaces and "::*" for all IPv6 interfaces.
+func parseBindAddresses(bind string) ([]bindAddress, error) {
+	fields := strings.Fields(bind)
+	if len(fields) == 0 {
+		fields = strings.Fields(DefaultBind)
+	}
+
+	addresses := make([]bindAddress, 0, len(fields))
+	for _, field := range fields {
+		address := bindAddress{host: field}
+		if strings.HasPrefix(field, "-") {
+			address = bindAddress{host: field[1:], optional: true}
+		}
+
+		if address.host != "*" && address.host != "::*" && net.ParseIP(address.host) == nil {
+			return nil, fmt.Errorf("invalid bind address '%s'", field)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// listenNetwork returns the network and address to listen on for one bind
+// address. The wildcards are pinned to a single address family so that "*"
+// and "::*" can be bound side by side.
+func (address bindAddress) listenNetwork(port int) (string, string) {
+	switch address.host {
+	case "*":
+		return "tcp4", fmt.Sprintf("0.0.0.0:%d", port)
+	case "::*":
+		return "tcp6", fmt.Sprintf("[::]:%d", port)
+	default:
+		return "tcp", net.JoinHostPort(address.host, fmt.Sprint(port))
+	}
+}
+
+// ListenTCP opens a listener on port for every bind address, wrapping each in
+// TLS when tlsConfig is set. Failing to bind a required address closes the
+// listeners opened so far and returns the error.
+func ListenTCP(bind string, port int, tlsConfig *tls.Config) ([]net.Listener, error) {
+	addresses, err := parseBindAddresses(bind)
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []net.Listener
+	for _, address := range addresses {
+		network, listenAddress := address.listenNetwork(port)
+		listener, err := net.Listen(network, listenAddress)
+		if err != nil {
+			if address.optional {
+				LogVerbose("Skipping optional bind address %s: %s", address.host, err.Error())
+				continue
+			}
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("could not create server TCP listening socket %s: %w", listenAddress, err)
+		}
+
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("failed listening on all bind addresses")
+	}
+
+	return listeners, nil
+}
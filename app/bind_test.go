@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBindAddresses(t *testing.T) {
+	addresses, err := parseBindAddresses("127.0.0.1 -::1 *")
+	if err != nil {
+		t.Fatalf("parseBindAddresses() error = %v", err)
+	}
+
+	expected := []bindAddress{{host: "127.0.0.1"}, {host: "::1", optional: true}, {host: "*"}}
+	if len(addresses) != len(expected) {
+		t.Fatalf("parseBindAddresses() = %v, expected %v", addresses, expected)
+	}
+	for index := range expected {
+		if addresses[index] != expected[index] {
+			t.Errorf("address %d = %+v, expected %+v", index, addresses[index], expected[index])
+		}
+	}
+
+	if _, err := parseBindAddresses("127.0.0.1 localhost"); err == nil {
+		t.Error("parseBindAddresses() accepted a host name")
+	}
+}
+
+func TestListenTCPBindsEveryAddress(t *testing.T) {
+	listeners, err := ListenTCP("127.0.0.1 -::1", 0, nil)
+	if err != nil {
+		t.Fatalf("ListenTCP() error = %v", err)
+	}
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+
+	if len(listeners) == 0 || listeners[0].Addr().(*net.TCPAddr).IP.String() != "127.0.0.1" {
+		t.Fatalf("ListenTCP() listeners = %v, expected one on 127.0.0.1", listeners)
+	}
+	for _, listener := range listeners[1:] {
+		if ip := listener.Addr().(*net.TCPAddr).IP.String(); ip != "::1" {
+			t.Errorf("unexpected listener on %s", ip)
+		}
+	}
+}
+
+func TestListenTCPFailsOnRequiredAddress(t *testing.T) {
+	// 192.0.2.0/24 is reserved for documentation and never local.
+	if listeners, err := ListenTCP("127.0.0.1 192.0.2.1", 0, nil); err == nil {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		t.Fatal("ListenTCP() succeeded binding an address that is not local")
+	}
+
+	listeners, err := ListenTCP("127.0.0.1 -192.0.2.1", 0, nil)
+	if err != nil {
+		t.Fatalf("ListenTCP() with an optional unbindable address error = %v", err)
+	}
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// remoteAddressConnection reports a fixed peer address.
+type remoteAddressConnection struct {
+	net.Conn
+	remoteAddress net.Addr
+}
+
+func (connection *remoteAddressConnection) RemoteAddr() net.Addr {
+	return connection.remoteAddress
+}
+
+func TestProtectedModeRefusesRemoteClients(t *testing.T) {
+	resetClientTestState(t)
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.ProtectedMode = true
+
+	serverConnection, clientConnection := testConnectionPair(t)
+	remoteConnection := &remoteAddressConnection{Conn: serverConnection, remoteAddress: &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 50000}}
+	replies := make(chan string, 1)
+	go func() {
+		reply, _ := io.ReadAll(clientConnection)
+		replies <- string(reply)
+	}()
+
+	if admitConnection(remoteConnection) {
+		t.Fatal("admitConnection() accepted a remote client in protected mode")
+	}
+
+	select {
+	case reply := <-replies:
+		if !strings.HasPrefix(reply, "-DENIED Redis is running in protected mode") || !strings.HasSuffix(reply, "\r\n") {
+			t.Errorf("refused client received %q, expected the protected mode error", reply)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refused connection was not closed")
+	}
+}
+
+func TestProtectedModeAdmitsLoopbackClients(t *testing.T) {
+	resetClientTestState(t)
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.ProtectedMode = true
+
+	loopback := &remoteAddressConnection{Conn: testConnection(t), remoteAddress: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 50000}}
+	if !admitConnection(loopback) {
+		t.Error("admitConnection() refused a loopback client")
+	}
+
+	serverConfig.ProtectedMode = false
+	remote := &remoteAddressConnection{Conn: testConnection(t), remoteAddress: &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 50000}}
+	if !admitConnection(remote) {
+		t.Error("admitConnection() refused a remote client with protected mode off")
+	}
+}
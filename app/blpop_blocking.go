@@ -3,7 +3,7 @@ package main
 import "sync"
 
 type BlockingBlpopWaiter struct {
-	ResponseChannel chan string
+	ResponseChannel chan Reply
 	listKey         string
 }
 
@@ -33,7 +33,7 @@ func (registry *BlockingBlpopRegistry) RegisterWaiter(listKey string) *BlockingB
 	defer registry.mutex.Unlock()
 
 	waiter := &BlockingBlpopWaiter{
-		ResponseChannel: make(chan string, 1),
+		ResponseChannel: make(chan Reply, 1),
 		listKey:         listKey,
 	}
 
@@ -62,7 +62,7 @@ func (registry *BlockingBlpopRegistry) HasWaiters(listKey string) bool {
 	return len(registry.waiters[listKey]) > 0
 }
 
-func (registry *BlockingBlpopRegistry) NotifyNextWaiter(listKey string, response string) bool {
+func (registry *BlockingBlpopRegistry) NotifyNextWaiter(listKey string, response Reply) bool {
 	registry.mutex.Lock()
 	defer registry.mutex.Unlock()
 
@@ -1,36 +1,28 @@
 package main
 
 import (
-	"fmt"
 	"strconv"
-	"strings"
 	"time"
 )
 
-const blockingBlpopTimeoutResponse = "*-1\r\n"
+var blockingBlpopTimeoutResponse = NullArrayReply()
 
-func parseBlpopCommandArguments(command *RedisCommand) (listKey string, timeoutSeconds string, errorResponse string) {
+func parseBlpopCommandArguments(command *RedisCommand) (listKey string, timeoutSeconds string, errorResponse Reply) {
 	if len(command.Args) != 2 {
-		return "", "", "-ERR wrong number of arguments for 'blpop' command\r\n"
+		return "", "", WrongNumberOfArgumentsReply("blpop")
 	}
 
-	return command.Args[0], command.Args[1], ""
+	return command.Args[0], command.Args[1], Reply{}
 }
 
-func encodeBlpopResponse(listKey string, element string) string {
-	var builder strings.Builder
-
-	builder.WriteString("*2\r\n")
-	builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(listKey), listKey))
-	builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(element), element))
-
-	return builder.String()
+func encodeBlpopResponse(listKey string, element string) Reply {
+	return BulkStringArrayReply([]string{listKey, element})
 }
 
-func tryImmediateBlpop(listKey string) (string, bool) {
+func tryImmediateBlpop(listKey string) (Reply, bool) {
 	poppedElements, popped := GetInstance().PopListLeft(listKey, 1)
 	if !popped {
-		return "", false
+		return Reply{}, false
 	}
 
 	return encodeBlpopResponse(listKey, poppedElements[0]), true
@@ -54,7 +46,7 @@ func notifyBlockingBlpopWaiters(listKey string) {
 	}
 }
 
-func waitForBlockingBlpopResponse(listKey string, timeoutSeconds float64) string {
+func waitForBlockingBlpopResponse(listKey string, timeoutSeconds float64) Reply {
 	waiter := GetBlockingBlpopRegistry().RegisterWaiter(listKey)
 	defer waiter.Unregister()
 
@@ -73,15 +65,15 @@ func waitForBlockingBlpopResponse(listKey string, timeoutSeconds float64) string
 	}
 }
 
-func HandleBlpop(command *RedisCommand) string {
+func HandleBlpop(command *RedisCommand) Reply {
 	listKey, timeoutSecondsString, errorResponse := parseBlpopCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	timeoutSeconds, parseTimeoutError := strconv.ParseFloat(timeoutSecondsString, 64)
 	if parseTimeoutError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	if response, poppedImmediately := tryImmediateBlpop(listKey); poppedImmediately {
@@ -12,7 +12,7 @@ func resetBlpopTestState(t *testing.T) {
 }
 
 func formatExpectedBlpopResponse(listKey string, element string) string {
-	return encodeBlpopResponse(listKey, element)
+	return encodeBlpopResponse(listKey, element).String()
 }
 
 func TestParseBlpopCommandArguments(t *testing.T) {
@@ -59,8 +59,8 @@ func TestParseBlpopCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseBlpopCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseBlpopCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if listKey != testCase.expectedListKey {
@@ -85,7 +85,7 @@ func TestHandleBlpopReturnsImmediatelyWhenListHasElements(t *testing.T) {
 	result := HandleBlpop(&RedisCommand{
 		Type: CmdBLPOP,
 		Args: []string{"list_key", "0"},
-	})
+	}).String()
 
 	expected := formatExpectedBlpopResponse("list_key", "foo")
 	if result != expected {
@@ -101,7 +101,7 @@ func TestHandleBlpopCodecraftersStageExample(t *testing.T) {
 		resultChannel <- HandleBlpop(&RedisCommand{
 			Type: CmdBLPOP,
 			Args: []string{"list_key", "0"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -109,7 +109,7 @@ func TestHandleBlpopCodecraftersStageExample(t *testing.T) {
 	rpushResult := HandleRpush(&RedisCommand{
 		Type: CmdRPUSH,
 		Args: []string{"list_key", "foo"},
-	})
+	}).String()
 	if rpushResult != ":1\r\n" {
 		t.Fatalf("HandleRpush() = %q, expected %q", rpushResult, ":1\r\n")
 	}
@@ -135,7 +135,7 @@ func TestHandleBlpopRespondsToLongestWaitingClientFirst(t *testing.T) {
 		firstClientResultChannel <- HandleBlpop(&RedisCommand{
 			Type: CmdBLPOP,
 			Args: []string{"another_list_key", "0"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(20 * time.Millisecond)
@@ -144,7 +144,7 @@ func TestHandleBlpopRespondsToLongestWaitingClientFirst(t *testing.T) {
 		secondClientResultChannel <- HandleBlpop(&RedisCommand{
 			Type: CmdBLPOP,
 			Args: []string{"another_list_key", "0"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -182,7 +182,7 @@ func TestHandleBlpopRemovesPoppedElementFromList(t *testing.T) {
 	result := HandleBlpop(&RedisCommand{
 		Type: CmdBLPOP,
 		Args: []string{"list_key", "0"},
-	})
+	}).String()
 	if result != formatExpectedBlpopResponse("list_key", "foo") {
 		t.Fatalf("HandleBlpop() = %q, expected %q", result, formatExpectedBlpopResponse("list_key", "foo"))
 	}
@@ -190,7 +190,7 @@ func TestHandleBlpopRemovesPoppedElementFromList(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	if rangeResult != formatExpectedLrangeResponse("bar") {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, formatExpectedLrangeResponse("bar"))
 	}
@@ -202,7 +202,7 @@ func TestHandleBlpopArgumentParsing(t *testing.T) {
 	result := HandleBlpop(&RedisCommand{
 		Type: CmdBLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'blpop' command\r\n" {
 		t.Errorf("HandleBlpop() = %q, expected %q", result, "-ERR wrong number of arguments for 'blpop' command\r\n")
@@ -215,7 +215,7 @@ func TestHandleBlpopRejectsInvalidTimeout(t *testing.T) {
 	result := HandleBlpop(&RedisCommand{
 		Type: CmdBLPOP,
 		Args: []string{"list_key", "not-a-number"},
-	})
+	}).String()
 
 	if result != "-ERR value is not an integer or out of range\r\n" {
 		t.Errorf("HandleBlpop() = %q, expected %q", result, "-ERR value is not an integer or out of range\r\n")
@@ -229,11 +229,11 @@ func TestHandleBlpopReturnsNullArrayOnTimeout(t *testing.T) {
 	result := HandleBlpop(&RedisCommand{
 		Type: CmdBLPOP,
 		Args: []string{"list_key", "0.1"},
-	})
+	}).String()
 	elapsed := time.Since(startTime)
 
-	if result != blockingBlpopTimeoutResponse {
-		t.Errorf("HandleBlpop() = %q, expected %q", result, blockingBlpopTimeoutResponse)
+	if result != blockingBlpopTimeoutResponse.String() {
+		t.Errorf("HandleBlpop() = %q, expected %q", result, blockingBlpopTimeoutResponse.String())
 	}
 
 	if elapsed < 90*time.Millisecond {
@@ -249,7 +249,7 @@ func TestHandleBlpopWaitsForPushBeforeTimeout(t *testing.T) {
 		resultChannel <- HandleBlpop(&RedisCommand{
 			Type: CmdBLPOP,
 			Args: []string{"list_key", "1"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -278,13 +278,13 @@ func TestHandleBlpopUnblocksTimedOutWaiterDoesNotReceiveLaterPush(t *testing.T)
 		resultChannel <- HandleBlpop(&RedisCommand{
 			Type: CmdBLPOP,
 			Args: []string{"list_key", "0.1"},
-		})
+		}).String()
 	}()
 
 	select {
 	case result := <-resultChannel:
-		if result != blockingBlpopTimeoutResponse {
-			t.Fatalf("HandleBlpop() = %q, expected %q", result, blockingBlpopTimeoutResponse)
+		if result != blockingBlpopTimeoutResponse.String() {
+			t.Fatalf("HandleBlpop() = %q, expected %q", result, blockingBlpopTimeoutResponse.String())
 		}
 	case <-time.After(500 * time.Millisecond):
 		t.Fatal("timed out waiting for BLPOP timeout response")
@@ -298,7 +298,7 @@ func TestHandleBlpopUnblocksTimedOutWaiterDoesNotReceiveLaterPush(t *testing.T)
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	if rangeResult != formatExpectedLrangeResponse("foo") {
 		t.Errorf("HandleLrange() = %q, expected element to remain on list after timed out BLPOP", rangeResult)
 	}
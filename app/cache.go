@@ -1,10 +1,6 @@
 package main
 
-import (
-	"fmt"
-	"sync"
-	"time"
-)
+import "sync"
 
 type CacheItem struct {
 	Value      interface{}
@@ -13,7 +9,10 @@ type CacheItem struct {
 
 type Cache struct {
 	cache map[string]CacheItem
-	mutex sync.RWMutex
+	// expires holds the keys of cache that have an expiry, like Redis's
+	// expires dict, so the active expire cycle samples only those.
+	expires map[string]struct{}
+	mutex   sync.RWMutex
 }
 
 var (
@@ -35,56 +34,174 @@ func (c *Cache) Set(key string, value interface{}, options map[string]interface{
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	fmt.Println("options are: ", options)
-
 	expiration := int64(0)
-	now := time.Now()
+	now := CurrentTimeMilliseconds()
 	if options != nil {
 		if val, ok := options["EX"]; ok && val != nil {
-			expiration = now.Add(time.Duration(val.(int)) * time.Second).UnixMilli()
+			expiration = now + int64(val.(int))*1000
 		}
 
 		if val, ok := options["PX"]; ok && val != nil {
-			expiration = now.Add(time.Duration(val.(int)) * time.Millisecond).UnixMilli()
+			expiration = now + int64(val.(int))
 		}
 	}
 
-	fmt.Println("expiration", expiration)
-	c.cache[key] = CacheItem{
+	c.storeLocked(key, CacheItem{
 		Value:      value,
 		Expiration: expiration,
-	}
-	fmt.Println("cache", c.cache)
+	})
 }
 
 func (c *Cache) SetWithExpiry(key string, value interface{}, expirationMs int64) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.cache[key] = CacheItem{
+	c.storeLocked(key, CacheItem{
 		Value:      value,
 		Expiration: expirationMs,
+	})
+}
+
+// storeLocked stores item at key and keeps the expires index in step. The
+// caller holds the write lock.
+func (c *Cache) storeLocked(key string, item CacheItem) {
+	c.cache[key] = item
+	if item.Expiration == 0 {
+		delete(c.expires, key)
+		return
 	}
+
+	if c.expires == nil {
+		c.expires = make(map[string]struct{})
+	}
+	c.expires[key] = struct{}{}
+}
+
+// deleteLocked removes key from the cache and the expires index. The caller
+// holds the write lock.
+func (c *Cache) deleteLocked(key string) {
+	delete(c.cache, key)
+	delete(c.expires, key)
 }
 
+// Get returns the live value stored at key, deleting the key if it has
+// expired. Readers share the read lock, so the delete retakes the lock
+// exclusively and checks again that the key is still the expired one.
 func (c *Cache) Get(key string) interface{} {
 	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
 	item, ok := c.cache[key]
+	c.mutex.RUnlock()
 	if !ok {
 		return nil
 	}
 
-	fmt.Println("item", item)
-	now := time.Now().UnixMilli()
-	fmt.Println("now", now, "expiration", item.Expiration, "now >= exp?", now >= item.Expiration)
-	if item.Expiration > 0 && now >= item.Expiration {
-		delete(c.cache, key)
-		return nil
+	now := CurrentTimeMilliseconds()
+	if item.Expiration == 0 || now < item.Expiration {
+		return item.Value
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if current, exists := c.cache[key]; exists && current.Expiration > 0 && now >= current.Expiration {
+		c.deleteLocked(key)
+	}
+
+	return nil
+}
+
+// LookupRead returns the value stored at key for a command that reads it and
+// counts the lookup as a keyspace hit or miss, as Redis's lookupKeyRead
+// does. Commands that only modify the key use Get, which is not counted.
+func (c *Cache) LookupRead(key string) interface{} {
+	value := c.Get(key)
+	recordKeyspaceLookup(value != nil)
+
+	return value
+}
+
+// Exists reports whether key holds a value that has not expired.
+func (c *Cache) Exists(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	item, ok := c.cache[key]
+	return ok && (item.Expiration == 0 || CurrentTimeMilliseconds() < item.Expiration)
+}
+
+// DeleteExpiredSample checks up to sampleSize keys from the expires index,
+// picked by map iteration order, and deletes those that expired at now (in
+// milliseconds). It returns how many keys it checked and the deleted keys.
+func (c *Cache) DeleteExpiredSample(sampleSize int, now int64) (sampled int, expired []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key := range c.expires {
+		if sampled == sampleSize {
+			break
+		}
+		item, exists := c.cache[key]
+		if !exists || item.Expiration == 0 {
+			// The cache map was replaced behind the index's back.
+			delete(c.expires, key)
+			continue
+		}
+		sampled++
+		if now >= item.Expiration {
+			c.deleteLocked(key)
+			expired = append(expired, key)
+		}
 	}
+	return sampled, expired
+}
+
+// KeyspaceCounts returns how many live keys there are and how many of them
+// have an expiry, as INFO keyspace reports them.
+func (c *Cache) KeyspaceCounts() (keys int, expires int) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 
-	return item.Value
+	now := CurrentTimeMilliseconds()
+	for _, item := range c.cache {
+		if item.Expiration > 0 && now >= item.Expiration {
+			continue
+		}
+		keys++
+		if item.Expiration > 0 {
+			expires++
+		}
+	}
+	return keys, expires
+}
+
+// KeyCountsByType returns how many live keys hold each type of value, by
+// the names TYPE uses.
+func (c *Cache) KeyCountsByType() map[string]int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	now := CurrentTimeMilliseconds()
+	for _, item := range c.cache {
+		if item.Expiration > 0 && now >= item.Expiration {
+			continue
+		}
+		counts[valueTypeName(item.Value)]++
+	}
+	return counts
+}
+
+// valueTypeName returns the TYPE name of a stored value.
+func valueTypeName(value interface{}) string {
+	switch value.(type) {
+	case *List:
+		return "list"
+	case *SortedSet:
+		return "zset"
+	case *Stream:
+		return "stream"
+	default:
+		return "string"
+	}
 }
 
 func (c *Cache) GetAllKeys() []string {
@@ -92,7 +209,7 @@ func (c *Cache) GetAllKeys() []string {
 	defer c.mutex.RUnlock()
 
 	keys := make([]string, 0, len(c.cache))
-	now := time.Now().UnixMilli()
+	now := CurrentTimeMilliseconds()
 	for k, item := range c.cache {
 		if item.Expiration == 0 || now < item.Expiration {
 			keys = append(keys, k)
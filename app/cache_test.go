@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -82,7 +83,7 @@ func TestHandleSetWithPXIntegration(t *testing.T) {
 
 	// Call HandleSet which goes through the full flow:
 	// HandleSet -> handleOptionalArguments -> cache.Set
-	result := HandleSet(cmd)
+	result := HandleSet(cmd).String()
 
 	// Should return OK
 	if result != "+OK\r\n" {
@@ -130,7 +131,7 @@ func TestReproduceRedisTesterFailure(t *testing.T) {
 	}
 
 	// Call HandleSet
-	result := HandleSet(cmd)
+	result := HandleSet(cmd).String()
 	if result != "+OK\r\n" {
 		t.Errorf("SET should return OK, got %s", result)
 	}
@@ -141,7 +142,7 @@ func TestReproduceRedisTesterFailure(t *testing.T) {
 		Args: []string{"raspberry"},
 	}
 
-	getResult := HandleGet(getCmd)
+	getResult := HandleGet(getCmd).String()
 
 	// This should return the value, but in the buggy version it returns "$-1\r\n" (null)
 	expected := "$10\r\nstrawberry\r\n" // Bulk string: $10\r\nstrawberry\r\n
@@ -162,7 +163,7 @@ func TestSetPineappleApplePX100(t *testing.T) {
 	}
 
 	// Execute SET command
-	result := HandleSet(cmd)
+	result := HandleSet(cmd).String()
 	if result != "+OK\r\n" {
 		t.Errorf("SET should return +OK\\r\\n, got %s", result)
 	}
@@ -172,7 +173,7 @@ func TestSetPineappleApplePX100(t *testing.T) {
 		Type: CmdGET,
 		Args: []string{"pineapple"},
 	}
-	getResult := HandleGet(getCmd)
+	getResult := HandleGet(getCmd).String()
 
 	expected := "$5\r\napple\r\n" // "apple" is 5 characters
 	if getResult != expected {
@@ -194,8 +195,57 @@ func TestSetPineappleApplePX100(t *testing.T) {
 	time.Sleep(150 * time.Millisecond)
 
 	// Verify the key has expired
-	getResultAfter := HandleGet(getCmd)
+	getResultAfter := HandleGet(getCmd).String()
 	if getResultAfter != "$-1\r\n" {
 		t.Errorf("Expected null bulk string after expiration, got %q", getResultAfter)
 	}
 }
+
+func TestCacheCountsKeysWhileReadsDeleteExpiredKeys(t *testing.T) {
+	cache := &Cache{cache: make(map[string]CacheItem)}
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	for index := 0; index < 100; index++ {
+		cache.SetWithExpiry(fmt.Sprint("key-", index), "v", expired)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 100; index++ {
+			cache.Get(fmt.Sprint("key-", index))
+		}
+	}()
+
+	for index := 0; index < 100; index++ {
+		cache.KeyspaceCounts()
+	}
+	<-done
+
+	if len(cache.cache) != 0 {
+		t.Errorf("%d expired keys left after reading them", len(cache.cache))
+	}
+}
+
+func TestCacheCountsKeysByTypeWhileReadsDeleteExpiredKeys(t *testing.T) {
+	cache := &Cache{cache: make(map[string]CacheItem)}
+	expired := time.Now().Add(-time.Second).UnixMilli()
+	for index := 0; index < 100; index++ {
+		cache.SetWithExpiry(fmt.Sprint("key-", index), "v", expired)
+	}
+	cache.Set("live", "v", nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 100; index++ {
+			cache.Get(fmt.Sprint("key-", index))
+		}
+	}()
+
+	for index := 0; index < 100; index++ {
+		if counts := cache.KeyCountsByType(); counts["string"] != 1 {
+			t.Errorf("KeyCountsByType() = %v, expected one live string", counts)
+		}
+	}
+	<-done
+}
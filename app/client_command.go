@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errClientNoSuchClient = ErrorReply("ERR No such client")
+	errClientSyntax       = ErrorReply("ERR syntax error")
+)
+
+func clientWrongNumberOfArgumentsReply(subCommand string) Reply {
+	return WrongNumberOfArgumentsReply("client|" + strings.ToLower(subCommand))
+}
+
+// clientFilter selects clients for CLIENT LIST and CLIENT KILL. Zero fields
+// match everything.
+type clientFilter struct {
+	ids          map[int64]bool
+	clientType   string
+	address      string
+	localAddress string
+	user         string
+	skipMe       bool
+}
+
+func (filter clientFilter) matches(client Client, caller net.Conn) bool {
+	if filter.skipMe && client.Connection == caller {
+		return false
+	}
+	if filter.ids != nil && !filter.ids[client.ID] {
+		return false
+	}
+	if filter.clientType != "" && clientType(client) != filter.clientType {
+		return false
+	}
+	if filter.address != "" && clientAddress(client.Connection) != filter.address {
+		return false
+	}
+	if filter.localAddress != "" && clientLocalAddress(client.Connection) != filter.localAddress {
+		return false
+	}
+	if filter.user != "" && client.User != filter.user {
+		return false
+	}
+
+	return true
+}
+
+func parseClientID(argument string) (int64, Reply) {
+	id, err := strconv.ParseInt(argument, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrorReply("ERR Invalid client ID")
+	}
+
+	return id, Reply{}
+}
+
+func parseClientTypeArgument(argument string) (string, Reply) {
+	clientType, valid := parseClientType(argument)
+	if !valid {
+		return "", ErrorReply(fmt.Sprintf("ERR Unknown client type '%s'", argument))
+	}
+
+	return clientType, Reply{}
+}
+
+// parseClientListArguments parses CLIENT LIST [TYPE type] [ID id [id ...]].
+func parseClientListArguments(args []string) (filter clientFilter, errorResponse Reply) {
+	for index := 0; index < len(args); index++ {
+		switch strings.ToUpper(args[index]) {
+		case "TYPE":
+			if index+1 >= len(args) {
+				return clientFilter{}, errClientSyntax
+			}
+			index++
+			filter.clientType, errorResponse = parseClientTypeArgument(args[index])
+			if !errorResponse.IsEmpty() {
+				return clientFilter{}, errorResponse
+			}
+		case "ID":
+			if index+1 >= len(args) {
+				return clientFilter{}, errClientSyntax
+			}
+			filter.ids = make(map[int64]bool)
+			for index+1 < len(args) {
+				id, errorResponse := parseClientID(args[index+1])
+				if !errorResponse.IsEmpty() {
+					return clientFilter{}, errorResponse
+				}
+				filter.ids[id] = true
+				index++
+			}
+		default:
+			return clientFilter{}, errClientSyntax
+		}
+	}
+
+	return filter, Reply{}
+}
+
+// parseClientKillArguments parses both CLIENT KILL forms: the legacy single
+// address, and filter pairs (ID, ADDR, LADDR, USER, TYPE, SKIPME). legacy
+// reports which form was used, since they reply differently.
+func parseClientKillArguments(args []string) (filter clientFilter, legacy bool, errorResponse Reply) {
+	if len(args) == 1 {
+		return clientFilter{address: args[0]}, true, Reply{}
+	}
+
+	if len(args)%2 != 0 {
+		return clientFilter{}, false, errClientSyntax
+	}
+
+	filter.skipMe = true
+	for index := 0; index < len(args); index += 2 {
+		value := args[index+1]
+		switch strings.ToUpper(args[index]) {
+		case "ID":
+			id, errorResponse := parseClientID(value)
+			if !errorResponse.IsEmpty() {
+				return clientFilter{}, false, errorResponse
+			}
+			filter.ids = map[int64]bool{id: true}
+		case "ADDR":
+			filter.address = value
+		case "LADDR":
+			filter.localAddress = value
+		case "USER":
+			filter.user = value
+		case "TYPE":
+			filter.clientType, errorResponse = parseClientTypeArgument(value)
+			if !errorResponse.IsEmpty() {
+				return clientFilter{}, false, errorResponse
+			}
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				filter.skipMe = true
+			case "no":
+				filter.skipMe = false
+			default:
+				return clientFilter{}, false, errClientSyntax
+			}
+		default:
+			return clientFilter{}, false, errClientSyntax
+		}
+	}
+
+	return filter, false, Reply{}
+}
+
+func formatClientList(clients []Client) string {
+	now := time.Now()
+
+	var builder strings.Builder
+	for _, client := range clients {
+		builder.WriteString(formatClientInfo(client, now))
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func handleClientList(connection net.Conn, args []string) Reply {
+	filter, errorResponse := parseClientListArguments(args)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	matching := make([]Client, 0)
+	for _, client := range ClientsSnapshot() {
+		if filter.matches(client, connection) {
+			matching = append(matching, client)
+		}
+	}
+
+	return BulkStringReply(formatClientList(matching))
+}
+
+func handleClientKill(connection net.Conn, args []string) Reply {
+	filter, legacy, errorResponse := parseClientKillArguments(args)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	killed := 0
+	for _, client := range ClientsSnapshot() {
+		if !filter.matches(client, connection) {
+			continue
+		}
+		LogVerbose("Killing client %d (%s) on request", client.ID, clientAddress(client.Connection))
+		client.Connection.Close()
+		killed++
+	}
+
+	if legacy {
+		if killed == 0 {
+			return errClientNoSuchClient
+		}
+		return OKReply()
+	}
+
+	return IntegerReply(int64(killed))
+}
+
+func handleClientSetName(connection net.Conn, name string) Reply {
+	if !isValidClientName(name) {
+		return errInvalidClientName
+	}
+
+	updateClient(connection, func(client *Client) {
+		client.Name = name
+	})
+
+	return OKReply()
+}
+
+func handleClientGetName(connection net.Conn) Reply {
+	client := snapshotClient(connection)
+	if client.Name == "" {
+		return NullReply()
+	}
+
+	return BulkStringReply(client.Name)
+}
+
+// HandleClient processes the CLIENT subcommands: ID, SETNAME, GETNAME,
+// LIST, INFO and KILL.
+func HandleClient(connection net.Conn, command *RedisCommand) Reply {
+	if len(command.Args) == 0 {
+		return WrongNumberOfArgumentsReply("client")
+	}
+
+	subCommand := strings.ToUpper(command.Args[0])
+	args := command.Args[1:]
+
+	switch subCommand {
+	case "ID":
+		if len(args) != 0 {
+			return clientWrongNumberOfArgumentsReply(subCommand)
+		}
+		return IntegerReply(snapshotClient(connection).ID)
+	case "SETNAME":
+		if len(args) != 1 {
+			return clientWrongNumberOfArgumentsReply(subCommand)
+		}
+		return handleClientSetName(connection, args[0])
+	case "GETNAME":
+		if len(args) != 0 {
+			return clientWrongNumberOfArgumentsReply(subCommand)
+		}
+		return handleClientGetName(connection)
+	case "LIST":
+		return handleClientList(connection, args)
+	case "INFO":
+		if len(args) != 0 {
+			return clientWrongNumberOfArgumentsReply(subCommand)
+		}
+		return BulkStringReply(formatClientList([]Client{snapshotClient(connection)}))
+	case "KILL":
+		if len(args) == 0 {
+			return clientWrongNumberOfArgumentsReply(subCommand)
+		}
+		return handleClientKill(connection, args)
+	default:
+		return ErrorReply(fmt.Sprintf("ERR unknown subcommand '%s'. Try CLIENT HELP.", command.Args[0]))
+	}
+}
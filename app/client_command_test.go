@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetClientTestState(t *testing.T) {
+	t.Helper()
+	ResetClientRegistryForTest()
+	ResetConnectionPubSubStatesForTest()
+	ResetConnectionTransactionStatesForTest()
+	resetReplicationStateForTest()
+}
+
+func TestHandleClientIDAndNames(t *testing.T) {
+	resetClientTestState(t)
+	connection := testConnection(t)
+	clientID := RegisterClient(connection)
+
+	idResult := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"ID"}}).String()
+	if expected := fmt.Sprintf(":%d\r\n", clientID); idResult != expected {
+		t.Errorf("CLIENT ID = %q, expected %q", idResult, expected)
+	}
+
+	if result := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"GETNAME"}}).String(); result != "$-1\r\n" {
+		t.Errorf("CLIENT GETNAME = %q, expected null", result)
+	}
+
+	if result := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"SETNAME", "worker-1"}}).String(); result != "+OK\r\n" {
+		t.Fatalf("CLIENT SETNAME = %q, expected +OK", result)
+	}
+
+	if result := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"GETNAME"}}).String(); result != "$8\r\nworker-1\r\n" {
+		t.Errorf("CLIENT GETNAME = %q, expected worker-1", result)
+	}
+
+	if result := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"SETNAME", "bad name"}}).String(); result != errInvalidClientName.String() {
+		t.Errorf("CLIENT SETNAME with a space = %q, expected %q", result, errInvalidClientName.String())
+	}
+}
+
+func TestHandleClientInfoDescribesCaller(t *testing.T) {
+	resetClientTestState(t)
+	connection := testConnection(t)
+	clientID := RegisterClient(connection)
+
+	recordClientCommand(connection, &RedisCommand{Type: CmdMULTI, Name: "MULTI"})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdMULTI})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"k", "v"}})
+
+	result := HandleClient(connection, &RedisCommand{Type: CmdCLIENT, Args: []string{"INFO"}})
+	info := result.Text
+
+	for _, field := range []string{
+		fmt.Sprintf("id=%d ", clientID),
+		" flags=x ",
+		" multi=1 ",
+		" cmd=multi ",
+		" user=default ",
+		" resp=2",
+	} {
+		if !strings.Contains(info, field) {
+			t.Errorf("CLIENT INFO = %q, expected it to contain %q", info, field)
+		}
+	}
+}
+
+func TestHandleClientListFilters(t *testing.T) {
+	resetClientTestState(t)
+	normalConnection := testConnection(t)
+	subscriberConnection, subscriberPeer := testConnectionPair(t)
+	startDrainConnection(subscriberPeer)
+	normalID := RegisterClient(normalConnection)
+	subscriberID := RegisterClient(subscriberConnection)
+
+	HandleSubscribe(subscriberConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+
+	pubsubList := HandleClient(normalConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"LIST", "TYPE", "pubsub"}}).Text
+	if !strings.Contains(pubsubList, fmt.Sprintf("id=%d ", subscriberID)) || strings.Contains(pubsubList, fmt.Sprintf("id=%d ", normalID)) {
+		t.Errorf("CLIENT LIST TYPE pubsub = %q, expected only client %d", pubsubList, subscriberID)
+	}
+	if !strings.Contains(pubsubList, " flags=P ") || !strings.Contains(pubsubList, " sub=1 ") {
+		t.Errorf("CLIENT LIST TYPE pubsub = %q, expected the P flag and one subscription", pubsubList)
+	}
+
+	idList := HandleClient(normalConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"LIST", "ID", fmt.Sprint(normalID)}}).Text
+	if strings.Count(idList, "\n") != 1 || !strings.HasPrefix(idList, fmt.Sprintf("id=%d ", normalID)) {
+		t.Errorf("CLIENT LIST ID = %q, expected only client %d", idList, normalID)
+	}
+
+	invalidType := HandleClient(normalConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"LIST", "TYPE", "robots"}}).String()
+	if invalidType != "-ERR Unknown client type 'robots'\r\n" {
+		t.Errorf("CLIENT LIST TYPE robots = %q, expected an unknown type error", invalidType)
+	}
+}
+
+func TestHandleClientKill(t *testing.T) {
+	resetClientTestState(t)
+	callerConnection := testConnection(t)
+	targetConnection, targetPeer := testConnectionPair(t)
+	RegisterClient(callerConnection)
+	targetID := RegisterClient(targetConnection)
+
+	result := HandleClient(callerConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"KILL", "ID", fmt.Sprint(targetID)}}).String()
+	if result != ":1\r\n" {
+		t.Fatalf("CLIENT KILL ID = %q, expected :1", result)
+	}
+
+	targetPeer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := targetPeer.Read(make([]byte, 1)); err == nil {
+		t.Error("killed client connection is still open")
+	}
+
+	UnregisterClient(targetConnection)
+
+	skipped := HandleClient(callerConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"KILL", "TYPE", "normal", "SKIPME", "yes"}}).String()
+	if skipped != ":0\r\n" {
+		t.Errorf("CLIENT KILL TYPE normal SKIPME yes = %q, expected :0", skipped)
+	}
+
+	legacy := HandleClient(callerConnection, &RedisCommand{Type: CmdCLIENT, Args: []string{"KILL", "10.0.0.1:1"}}).String()
+	if legacy != errClientNoSuchClient.String() {
+		t.Errorf("CLIENT KILL addr = %q, expected %q", legacy, errClientNoSuchClient.String())
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+var errMaxClientsReached = ErrorReply("ERR max number of clients reached")
+
+// rejectWriteTimeout bounds how long telling a refused client why may take.
+const rejectWriteTimeout = 5 * time.Second
+
+var (
+	totalConnectionsReceived atomic.Int64
+	rejectedConnections      atomic.Int64
+)
+
+// admitConnection registers a newly accepted connection, or replies with an
+// error and closes it when maxclients clients are already connected or
+// protected mode refuses the client.
+func admitConnection(connection net.Conn) bool {
+	totalConnectionsReceived.Add(1)
+
+	if maxClients := GetConfig().MaxClients; maxClients > 0 && ClientCount() >= maxClients {
+		rejectedConnections.Add(1)
+		LogVerbose("Error registering fd event for the new client: max number of clients reached")
+		rejectConnection(connection, errMaxClientsReached)
+		return false
+	}
+
+	if isProtectedModeRefused(connection) {
+		rejectedConnections.Add(1)
+		LogVerbose("Denied connection from %s: protected mode", clientAddress(connection))
+		rejectConnection(connection, errProtectedMode)
+		return false
+	}
+
+	applyTCPKeepAlive(connection, GetConfig().TCPKeepAlive)
+	RegisterClient(connection)
+	return true
+}
+
+// rejectConnection sends a refused client its error and closes the
+// connection without holding up the accept loop: on a TLS listener the
+// write first runs the handshake, which a silent peer would never finish.
+func rejectConnection(connection net.Conn, reply Reply) {
+	go func() {
+		connection.SetDeadline(time.Now().Add(rejectWriteTimeout))
+		connection.Write([]byte(reply.Encode(protocolVersionRESP2)))
+		connection.Close()
+	}()
+}
+
+// applyTCPKeepAlive enables TCP keepalive probes every period seconds on TCP
+// connections; a period of zero disables them.
+func applyTCPKeepAlive(connection net.Conn, period int) {
+	tcpConnection, isTCP := underlyingConnection(connection).(*net.TCPConn)
+	if !isTCP {
+		return
+	}
+
+	if period <= 0 {
+		tcpConnection.SetKeepAlive(false)
+		return
+	}
+
+	tcpConnection.SetKeepAlive(true)
+	tcpConnection.SetKeepAlivePeriod(time.Duration(period) * time.Second)
+}
+
+// isClientExemptFromTimeout reports whether a client is never closed for
+// being idle: replicas, the master link, subscribers, monitors and blocked
+// clients are all legitimately quiet.
+func isClientExemptFromTimeout(client Client) bool {
+	if client.Blocked || isMonitorConnection(client.Connection) {
+		return true
+	}
+
+	return clientType(client) != clientTypeNormal
+}
+
+// reapIdleClients closes clients idle for longer than timeout and returns how
+// many were closed.
+func reapIdleClients(now time.Time, timeout time.Duration) int {
+	if timeout <= 0 {
+		return 0
+	}
+
+	closed := 0
+	for _, client := range ClientsSnapshot() {
+		if isClientExemptFromTimeout(client) || now.Sub(client.LastInteraction) <= timeout {
+			continue
+		}
+
+		LogVerbose("Closing idle client %d", client.ID)
+		client.Connection.Close()
+		closed++
+	}
+
+	return closed
+}
+
+// StartIdleClientReaper checks for idle clients once a second, honoring the
+// timeout setting as it is at each check.
+func StartIdleClientReaper() {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			reapIdleClients(now, time.Duration(GetConfig().Timeout)*time.Second)
+		}
+	}()
+}
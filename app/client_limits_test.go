@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdmitConnectionRejectsBeyondMaxClients(t *testing.T) {
+	resetClientTestState(t)
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.MaxClients = 1
+
+	if !admitConnection(testConnection(t)) {
+		t.Fatal("admitConnection() rejected the first client")
+	}
+
+	rejectedBefore := rejectedConnections.Load()
+	serverConnection, clientConnection := testConnectionPair(t)
+	replies := make(chan string, 1)
+	go func() {
+		reply, _ := io.ReadAll(clientConnection)
+		replies <- string(reply)
+	}()
+
+	if admitConnection(serverConnection) {
+		t.Fatal("admitConnection() accepted a client beyond maxclients")
+	}
+
+	select {
+	case reply := <-replies:
+		if reply != "-ERR max number of clients reached\r\n" {
+			t.Errorf("rejected client received %q, expected the max clients error", reply)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("rejected connection was not closed")
+	}
+
+	if rejectedConnections.Load() != rejectedBefore+1 {
+		t.Errorf("rejected_connections = %d, expected %d", rejectedConnections.Load(), rejectedBefore+1)
+	}
+	if ClientCount() != 1 {
+		t.Errorf("ClientCount() = %d, expected the rejected client not to be registered", ClientCount())
+	}
+}
+
+func TestReapIdleClientsClosesOnlyIdleNormalClients(t *testing.T) {
+	resetClientTestState(t)
+
+	idleConnection, idlePeer := testConnectionPair(t)
+	activeConnection := testConnection(t)
+	subscriberConnection, subscriberPeer := testConnectionPair(t)
+	startDrainConnection(subscriberPeer)
+	RegisterClient(idleConnection)
+	RegisterClient(activeConnection)
+	RegisterClient(subscriberConnection)
+	HandleSubscribe(subscriberConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+
+	now := time.Now().Add(time.Minute)
+	recordClientCommand(activeConnection, &RedisCommand{Type: CmdPING})
+	updateClient(activeConnection, func(client *Client) { client.LastInteraction = now })
+
+	if closed := reapIdleClients(now, 0); closed != 0 {
+		t.Errorf("reapIdleClients() with timeout 0 closed %d clients, expected none", closed)
+	}
+
+	if closed := reapIdleClients(now, 30*time.Second); closed != 1 {
+		t.Errorf("reapIdleClients() closed %d clients, expected 1", closed)
+	}
+
+	idlePeer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idlePeer.Read(make([]byte, 1)); err == nil {
+		t.Error("idle client connection is still open")
+	}
+}
+
+func TestReapIdleClientsSkipsMonitors(t *testing.T) {
+	resetClientTestState(t)
+	monitorConnection, monitorPeer := testConnectionPair(t)
+	startDrainConnection(monitorPeer)
+	RegisterClient(monitorConnection)
+	t.Cleanup(func() { RemoveMonitorConnection(monitorConnection) })
+	HandleMonitor(monitorConnection, &RedisCommand{Type: CmdMONITOR})
+
+	if closed := reapIdleClients(time.Now().Add(time.Minute), 30*time.Second); closed != 0 {
+		t.Errorf("reapIdleClients() closed %d clients, expected the monitor to be kept", closed)
+	}
+}
+
+func TestReapIdleClientsWhileClientsSubscribe(t *testing.T) {
+	resetClientTestState(t)
+	subscriberConnection, subscriberPeer := testConnectionPair(t)
+	startDrainConnection(subscriberPeer)
+	RegisterClient(subscriberConnection)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 200; index++ {
+			HandleSubscribe(subscriberConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{fmt.Sprint("channel-", index)}})
+		}
+	}()
+
+	// The client is never idle for an hour here; the reaper only classifies it.
+	for index := 0; index < 200; index++ {
+		reapIdleClients(time.Now(), time.Hour)
+		clientOutputBufferLimitFor(subscriberConnection)
+	}
+	<-done
+
+	if count := connectionSubscriptionCount(subscriberConnection); count != 200 {
+		t.Errorf("connectionSubscriptionCount() = %d, expected 200", count)
+	}
+}
+
+func TestInfoClientsSection(t *testing.T) {
+	resetClientTestState(t)
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.MaxClients = 42
+	serverConfig.Timeout = 7
+	serverConfig.TCPKeepAlive = 60
+
+	RegisterClient(testConnection(t))
+
+	info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"clients"}}).Text
+	for _, field := range []string{"# Clients\r\n", "connected_clients:1\r\n", "maxclients:42\r\n", "timeout:7\r\n", "tcp_keepalive:60\r\n"} {
+		if !strings.Contains(info, field) {
+			t.Errorf("INFO clients = %q, expected it to contain %q", info, field)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ClientOutputBufferLimit is one class of client-output-buffer-limit. A
+// client is disconnected as soon as its output buffer reaches HardLimit, or
+// once it has stayed at or above SoftLimit for SoftSeconds. Zero disables a
+// limit.
+type ClientOutputBufferLimit struct {
+	HardLimit   int64
+	SoftLimit   int64
+	SoftSeconds int
+}
+
+// clientOutputBufferClasses lists the limit classes in the order Redis
+// reports them.
+var clientOutputBufferClasses = []string{clientTypeNormal, clientTypeReplica, clientTypePubSub}
+
+var defaultClientOutputBufferLimits = map[string]ClientOutputBufferLimit{
+	clientTypeNormal:  {},
+	clientTypeReplica: {HardLimit: 256 * 1024 * 1024, SoftLimit: 64 * 1024 * 1024, SoftSeconds: 60},
+	clientTypePubSub:  {HardLimit: 32 * 1024 * 1024, SoftLimit: 8 * 1024 * 1024, SoftSeconds: 60},
+}
+
+var outputBufferLimitDisconnections atomic.Int64
+
+// ClientOutputBufferLimitForClass returns the configured limit for a class,
+// falling back to the Redis default.
+func ClientOutputBufferLimitForClass(class string) ClientOutputBufferLimit {
+	if limit, exists := GetConfig().ClientOutputBufferLimits[class]; exists {
+		return limit
+	}
+	return defaultClientOutputBufferLimits[class]
+}
+
+// clientOutputBufferLimitFor picks the limit class for a connection: replicas
+// first, then clients subscribed to channels, then everyone else.
+func clientOutputBufferLimitFor(connection net.Conn) ClientOutputBufferLimit {
+	switch {
+	case isReplicaConnection(connection):
+		return ClientOutputBufferLimitForClass(clientTypeReplica)
+	case connectionSubscriptionCount(connection) > 0:
+		return ClientOutputBufferLimitForClass(clientTypePubSub)
+	default:
+		return ClientOutputBufferLimitForClass(clientTypeNormal)
+	}
+}
+
+// parseClientOutputBufferLimits parses "<class> <hard> <soft> <seconds>"
+// groups, e.g. "pubsub 32mb 8mb 60 replica 256mb 64mb 60".
+func parseClientOutputBufferLimits(text string) (map[string]ClientOutputBufferLimit, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields)%4 != 0 {
+		return nil, fmt.Errorf("wrong number of arguments in client-output-buffer-limit '%s'", text)
+	}
+
+	limits := make(map[string]ClientOutputBufferLimit)
+	for index := 0; index < len(fields); index += 4 {
+		class, known := parseClientType(fields[index])
+		if !known || class == clientTypeMaster {
+			return nil, fmt.Errorf("invalid client class '%s' in client-output-buffer-limit", fields[index])
+		}
+
+		hardLimit, err := parseMemorySize(fields[index+1])
+		if err != nil {
+			return nil, err
+		}
+		softLimit, err := parseMemorySize(fields[index+2])
+		if err != nil {
+			return nil, err
+		}
+		softSeconds, err := strconv.Atoi(fields[index+3])
+		if err != nil || softSeconds < 0 {
+			return nil, fmt.Errorf("invalid soft limit seconds '%s' in client-output-buffer-limit", fields[index+3])
+		}
+
+		limits[class] = ClientOutputBufferLimit{HardLimit: hardLimit, SoftLimit: softLimit, SoftSeconds: softSeconds}
+	}
+
+	return limits, nil
+}
+
+// formatClientOutputBufferLimits renders every class, with overrides taking
+// precedence over the defaults, the way CONFIG GET reports
+// client-output-buffer-limit.
+func formatClientOutputBufferLimits(overrides map[string]ClientOutputBufferLimit) string {
+	parts := make([]string, 0, len(clientOutputBufferClasses))
+	for _, class := range clientOutputBufferClasses {
+		limit, exists := overrides[class]
+		if !exists {
+			limit = defaultClientOutputBufferLimits[class]
+		}
+		name := class
+		if class == clientTypeReplica {
+			name = "slave"
+		}
+		parts = append(parts, fmt.Sprintf("%s %d %d %d", name, limit.HardLimit, limit.SoftLimit, limit.SoftSeconds))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// clientOutputBufferLimitsValue is a flag.Value that may be given several
+// times; each occurrence overrides the classes it names.
+type clientOutputBufferLimitsValue map[string]ClientOutputBufferLimit
+
+func (value *clientOutputBufferLimitsValue) String() string {
+	if value == nil {
+		return formatClientOutputBufferLimits(nil)
+	}
+	return formatClientOutputBufferLimits(*value)
+}
+
+func (value *clientOutputBufferLimitsValue) Set(text string) error {
+	limits, err := parseClientOutputBufferLimits(text)
+	if err != nil {
+		return err
+	}
+
+	// Build a new map rather than updating the current one in place: CONFIG
+	// SET works on a copy of the configuration that shares it.
+	merged := make(clientOutputBufferLimitsValue, len(*value)+len(limits))
+	for class, limit := range *value {
+		merged[class] = limit
+	}
+	for class, limit := range limits {
+		merged[class] = limit
+	}
+	*value = merged
+	return nil
+}
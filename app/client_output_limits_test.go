@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetOutputLimitTestState(t *testing.T, limits map[string]ClientOutputBufferLimit) {
+	t.Helper()
+	resetPublishTestState(t)
+	originalConfig := serverConfig
+	serverConfig.ClientOutputBufferLimits = limits
+	outputBufferLimitDisconnections.Store(0)
+	t.Cleanup(func() {
+		serverConfig = originalConfig
+		ResetConnectionWriteMutexesForTest()
+	})
+}
+
+func TestParseClientOutputBufferLimits(t *testing.T) {
+	limits, err := parseClientOutputBufferLimits("pubsub 32mb 8mb 60 slave 0 0 0")
+	if err != nil {
+		t.Fatalf("parseClientOutputBufferLimits() error = %v", err)
+	}
+
+	expectedPubSub := ClientOutputBufferLimit{HardLimit: 32 * 1024 * 1024, SoftLimit: 8 * 1024 * 1024, SoftSeconds: 60}
+	if limits[clientTypePubSub] != expectedPubSub {
+		t.Errorf("pubsub limit = %+v, expected %+v", limits[clientTypePubSub], expectedPubSub)
+	}
+	if limits[clientTypeReplica] != (ClientOutputBufferLimit{}) {
+		t.Errorf("replica limit = %+v, expected no limit", limits[clientTypeReplica])
+	}
+
+	for _, input := range []string{"", "normal 0 0", "master 0 0 0", "pubsub x 0 0", "pubsub 0 0 -1"} {
+		if _, err := parseClientOutputBufferLimits(input); err == nil {
+			t.Errorf("parseClientOutputBufferLimits(%q) returned no error", input)
+		}
+	}
+}
+
+func TestFormatClientOutputBufferLimitsUsesDefaults(t *testing.T) {
+	resetOutputLimitTestState(t, map[string]ClientOutputBufferLimit{
+		clientTypeNormal: {HardLimit: 1024, SoftLimit: 512, SoftSeconds: 5},
+	})
+
+	expected := "normal 1024 512 5 slave 268435456 67108864 60 pubsub 33554432 8388608 60"
+	if formatted := formatClientOutputBufferLimits(GetConfig().ClientOutputBufferLimits); formatted != expected {
+		t.Errorf("formatClientOutputBufferLimits() = %q, expected %q", formatted, expected)
+	}
+}
+
+func TestSlowSubscriberDoesNotStallPublish(t *testing.T) {
+	resetOutputLimitTestState(t, nil)
+
+	// Nobody reads from the slow subscriber's side of the pipe.
+	slowServerConnection, _ := testConnectionPair(t)
+	fastServerConnection, fastClientConnection := testConnectionPair(t)
+	HandleSubscribe(slowServerConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+	HandleSubscribe(fastServerConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+
+	published := make(chan struct{})
+	go func() {
+		for index := 0; index < 10; index++ {
+			HandlePublish(&RedisCommand{Type: CmdPUBLISH, Args: []string{"news", "hello"}})
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("PUBLISH blocked on a subscriber that is not reading")
+	}
+
+	fastClientConnection.SetReadDeadline(time.Now().Add(2 * time.Second))
+	expected := formatExpectedPubSubMessageResponse("news", "hello")
+	received, err := readAvailableBytes(fastClientConnection)
+	if err != nil || !strings.HasPrefix(string(received), expected) {
+		t.Fatalf("fast subscriber received %q, %v; expected %q", received, err, expected)
+	}
+}
+
+func TestSubscriberOverHardLimitIsDisconnected(t *testing.T) {
+	resetOutputLimitTestState(t, map[string]ClientOutputBufferLimit{
+		clientTypePubSub: {HardLimit: 256},
+	})
+
+	slowServerConnection, slowClientConnection := testConnectionPair(t)
+	HandleSubscribe(slowServerConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+
+	for index := 0; index < 20; index++ {
+		HandlePublish(&RedisCommand{Type: CmdPUBLISH, Args: []string{"news", strings.Repeat("x", 32)}})
+	}
+
+	if outputBufferLimitDisconnections.Load() != 1 {
+		t.Fatalf("disconnections = %d, expected 1", outputBufferLimitDisconnections.Load())
+	}
+	if err := EnqueueToConnection(slowServerConnection, []byte("+late\r\n")); !errors.Is(err, errOutputBufferLimitReached) {
+		t.Errorf("EnqueueToConnection() after disconnect = %v, expected %v", err, errOutputBufferLimitReached)
+	}
+
+	slowClientConnection.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buffer := make([]byte, 4096)
+	for {
+		_, err := slowClientConnection.Read(buffer)
+		if os.IsTimeout(err) {
+			t.Fatal("subscriber connection was not closed")
+		}
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestSubscriberOverSoftLimitIsDisconnectedAfterGracePeriod(t *testing.T) {
+	resetOutputLimitTestState(t, nil)
+
+	serverConnection, _ := testConnectionPair(t)
+	output := getConnectionOutput(serverConnection)
+	limit := ClientOutputBufferLimit{SoftLimit: 10, SoftSeconds: 60}
+	start := time.Now()
+
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	output.pending = []byte(strings.Repeat("x", 20))
+	if err := output.enforceLimitLocked(serverConnection, limit, start); err != nil {
+		t.Fatalf("first check over the soft limit returned %v, expected a grace period", err)
+	}
+	if err := output.enforceLimitLocked(serverConnection, limit, start.Add(30*time.Second)); err != nil {
+		t.Fatalf("check within the grace period returned %v", err)
+	}
+	if err := output.enforceLimitLocked(serverConnection, limit, start.Add(61*time.Second)); !errors.Is(err, errOutputBufferLimitReached) {
+		t.Fatalf("check after the grace period returned %v, expected %v", err, errOutputBufferLimitReached)
+	}
+}
+
+func TestSoftLimitTimerResetsWhenBufferDrains(t *testing.T) {
+	resetOutputLimitTestState(t, nil)
+
+	serverConnection, _ := testConnectionPair(t)
+	output := getConnectionOutput(serverConnection)
+	limit := ClientOutputBufferLimit{SoftLimit: 10, SoftSeconds: 60}
+	start := time.Now()
+
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	output.pending = []byte(strings.Repeat("x", 20))
+	output.enforceLimitLocked(serverConnection, limit, start)
+	output.pending = output.pending[:5]
+	output.enforceLimitLocked(serverConnection, limit, start.Add(30*time.Second))
+	output.pending = []byte(strings.Repeat("x", 20))
+	if err := output.enforceLimitLocked(serverConnection, limit, start.Add(61*time.Second)); err != nil {
+		t.Fatalf("check after the buffer drained returned %v, expected a fresh grace period", err)
+	}
+}
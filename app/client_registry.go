@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is the server's view of one connection: its identity, what it
+// negotiated and when it was last active.
+type Client struct {
+	ID              int64
+	Connection      net.Conn
+	Name            string
+	User            string
+	ProtocolVersion int
+	CreatedAt       time.Time
+	LastInteraction time.Time
+	LastCommand     string
+	Blocked         bool
+	// Authenticated is set once the client has proven who it is. Clients
+	// connecting while no password is required start out authenticated.
+	Authenticated bool
+	// ReplicaListeningPort is the port a replica announced with REPLCONF
+	// listening-port.
+	ReplicaListeningPort int
+}
+
+var (
+	clientRegistryMutex sync.Mutex
+	clientsByConnection = make(map[net.Conn]*Client)
+	clientsByID         = make(map[int64]*Client)
+	nextClientID        int64
+)
+
+func ResetClientRegistryForTest() {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	clientsByConnection = make(map[net.Conn]*Client)
+	clientsByID = make(map[int64]*Client)
+}
+
+// getClient must be called with clientRegistryMutex held. Connections that
+// were never registered, such as those built by tests, get a client on
+// first use.
+func getClient(connection net.Conn) *Client {
+	client, exists := clientsByConnection[connection]
+	if !exists {
+		nextClientID++
+		now := time.Now()
+		client = &Client{
+			ID:              nextClientID,
+			Connection:      connection,
+			User:            defaultUserName,
+			ProtocolVersion: protocolVersionRESP2,
+			CreatedAt:       now,
+			LastInteraction: now,
+			Authenticated:   isDefaultUserPasswordless(),
+		}
+		clientsByConnection[connection] = client
+		clientsByID[client.ID] = client
+	}
+
+	return client
+}
+
+// isClientAuthenticated reports whether the connection may run commands
+// that require authentication. The master link is always trusted.
+func isClientAuthenticated(connection net.Conn) bool {
+	if IsMasterLinkConnection(connection) {
+		return true
+	}
+
+	return snapshotClient(connection).Authenticated
+}
+
+// setClientAuthenticated records that the connection authenticated as user.
+func setClientAuthenticated(connection net.Conn, user string) {
+	updateClient(connection, func(client *Client) {
+		client.User = user
+		client.Authenticated = true
+	})
+}
+
+// RegisterClient adds a connection to the registry and returns its ID.
+func RegisterClient(connection net.Conn) int64 {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	return getClient(connection).ID
+}
+
+// UnregisterClient removes a connection from the registry and drops every
+// piece of per-connection state kept for it.
+func UnregisterClient(connection net.Conn) {
+	clientRegistryMutex.Lock()
+	if client, exists := clientsByConnection[connection]; exists {
+		delete(clientsByID, client.ID)
+		delete(clientsByConnection, connection)
+	}
+	clientRegistryMutex.Unlock()
+
+	UnregisterReplica(connection)
+	RemoveConnectionTransactionState(connection)
+	RemoveConnectionPubSubState(connection)
+	RemoveMonitorConnection(connection)
+	RemoveConnectionWriteMutex(connection)
+}
+
+// snapshotClient returns a copy of the connection's client.
+func snapshotClient(connection net.Conn) Client {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	return *getClient(connection)
+}
+
+// updateClient applies update to the connection's client under the
+// registry lock and returns a copy of the result.
+func updateClient(connection net.Conn, update func(client *Client)) Client {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	client := getClient(connection)
+	update(client)
+
+	return *client
+}
+
+// recordClientCommand notes that the client just sent a command.
+func recordClientCommand(connection net.Conn, command *RedisCommand) {
+	commandName := strings.ToLower(command.Name)
+	if spec := LookupCommandSpec(command.Type); spec != nil {
+		commandName = spec.Name
+	}
+
+	updateClient(connection, func(client *Client) {
+		client.LastInteraction = time.Now()
+		client.LastCommand = commandName
+	})
+}
+
+// setClientBlocked marks a client as waiting in a blocking command. A client
+// that disconnected while blocked is not registered again.
+func setClientBlocked(connection net.Conn, blocked bool) {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	if client, exists := clientsByConnection[connection]; exists {
+		client.Blocked = blocked
+	}
+}
+
+// ClientsSnapshot returns copies of all registered clients ordered by ID.
+func ClientsSnapshot() []Client {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	clients := make([]Client, 0, len(clientsByConnection))
+	for _, client := range clientsByConnection {
+		clients = append(clients, *client)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+
+	return clients
+}
+
+// ClientCount returns the number of registered clients.
+func ClientCount() int {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	return len(clientsByConnection)
+}
+
+const (
+	clientTypeNormal  = "normal"
+	clientTypeMaster  = "master"
+	clientTypeReplica = "replica"
+	clientTypePubSub  = "pubsub"
+)
+
+// clientType classifies a client the way CLIENT LIST TYPE and CLIENT KILL
+// TYPE do.
+func clientType(client Client) string {
+	switch {
+	case IsMasterLinkConnection(client.Connection):
+		return clientTypeMaster
+	case isReplicaConnection(client.Connection):
+		return clientTypeReplica
+	case connectionSubscriptionCount(client.Connection) > 0:
+		return clientTypePubSub
+	default:
+		return clientTypeNormal
+	}
+}
+
+// parseClientType accepts the type names Redis accepts, including the
+// legacy "slave" alias.
+func parseClientType(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case clientTypeNormal:
+		return clientTypeNormal, true
+	case clientTypeMaster:
+		return clientTypeMaster, true
+	case clientTypeReplica, "slave":
+		return clientTypeReplica, true
+	case clientTypePubSub:
+		return clientTypePubSub, true
+	default:
+		return "", false
+	}
+}
+
+// clientFlags returns the CLIENT LIST flags string: S replica, M master,
+// O monitor, P pub/sub, x inside MULTI, b blocked, U unix socket, N for
+// none.
+func clientFlags(client Client) string {
+	var flags strings.Builder
+	if isReplicaConnection(client.Connection) {
+		flags.WriteByte('S')
+	}
+	if IsMasterLinkConnection(client.Connection) {
+		flags.WriteByte('M')
+	}
+	if isMonitorConnection(client.Connection) {
+		flags.WriteByte('O')
+	}
+	if connectionSubscriptionCount(client.Connection) > 0 {
+		flags.WriteByte('P')
+	}
+	if isConnectionInTransaction(client.Connection) {
+		flags.WriteByte('x')
+	}
+	if client.Blocked {
+		flags.WriteByte('b')
+	}
+	if isUnixSocketConnection(client.Connection) {
+		flags.WriteByte('U')
+	}
+
+	if flags.Len() == 0 {
+		return "N"
+	}
+
+	return flags.String()
+}
+
+func connectionAddress(address net.Addr) string {
+	if address == nil {
+		return ""
+	}
+
+	return address.String()
+}
+
+// formatClientInfo renders a client as one CLIENT LIST line.
+func formatClientInfo(client Client, now time.Time) string {
+	multi := -1
+	if isConnectionInTransaction(client.Connection) {
+		multi = queuedTransactionCommandCount(client.Connection)
+	}
+
+	lastCommand := client.LastCommand
+	if lastCommand == "" {
+		lastCommand = "NULL"
+	}
+
+	return fmt.Sprintf(
+		"id=%d addr=%s laddr=%s name=%s age=%d idle=%d flags=%s db=0 sub=%d psub=0 ssub=0 multi=%d cmd=%s user=%s resp=%d",
+		client.ID,
+		clientAddress(client.Connection),
+		clientLocalAddress(client.Connection),
+		client.Name,
+		int64(now.Sub(client.CreatedAt).Seconds()),
+		int64(now.Sub(client.LastInteraction).Seconds()),
+		clientFlags(client),
+		connectionSubscriptionCount(client.Connection),
+		multi,
+		lastCommand,
+		client.User,
+		client.ProtocolVersion,
+	)
+}
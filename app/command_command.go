@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	errCommandGetKeysInvalidCommand   = ErrorReply("ERR Invalid command specified")
+	errCommandGetKeysInvalidArguments = ErrorReply("ERR Invalid number of arguments specified for command")
+	errCommandGetKeysNoKeys           = ErrorReply("ERR The command has no key arguments")
+)
+
+// encodeCommandInfo builds the COMMAND INFO entry for spec: name, arity,
+// flags, first key, last key, key step, ACL categories, tips, key specs and
+// subcommands.
+func encodeCommandInfo(spec *CommandSpec) Reply {
+	flags := make([]Reply, 0)
+	for _, flagName := range spec.FlagNames() {
+		flags = append(flags, SimpleStringReply(flagName))
+	}
+
+	categories := make([]Reply, 0)
+	for _, category := range spec.Categories() {
+		categories = append(categories, SimpleStringReply(category))
+	}
+
+	return ArrayReply(
+		BulkStringReply(spec.Name),
+		IntegerReply(int64(spec.Arity)),
+		SetReply(flags...),
+		IntegerReply(int64(spec.FirstKey)),
+		IntegerReply(int64(spec.LastKey)),
+		IntegerReply(int64(spec.KeyStep)),
+		SetReply(categories...),
+		ArrayReply(),
+		ArrayReply(),
+		ArrayReply(),
+	)
+}
+
+func encodeCommandDocs(spec *CommandSpec) Reply {
+	return MapReply(
+		BulkStringReply("summary"), BulkStringReply(spec.Summary),
+		BulkStringReply("since"), BulkStringReply(spec.Since),
+		BulkStringReply("group"), BulkStringReply(spec.Group),
+	)
+}
+
+func handleCommandInfo(names []string) Reply {
+	if len(names) == 0 {
+		entries := make([]Reply, 0)
+		for _, spec := range CommandSpecs() {
+			entries = append(entries, encodeCommandInfo(spec))
+		}
+		return ArrayReply(entries...)
+	}
+
+	entries := make([]Reply, 0, len(names))
+	for _, name := range names {
+		spec := LookupCommandSpecByName(name)
+		if spec == nil {
+			entries = append(entries, NullArrayReply())
+			continue
+		}
+		entries = append(entries, encodeCommandInfo(spec))
+	}
+
+	return ArrayReply(entries...)
+}
+
+func handleCommandDocs(names []string) Reply {
+	specs := make([]*CommandSpec, 0)
+	if len(names) == 0 {
+		specs = CommandSpecs()
+	}
+
+	for _, name := range names {
+		if spec := LookupCommandSpecByName(name); spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+
+	elements := make([]Reply, 0, len(specs)*2)
+	for _, spec := range specs {
+		elements = append(elements, BulkStringReply(spec.Name), encodeCommandDocs(spec))
+	}
+
+	return MapReply(elements...)
+}
+
+func handleCommandGetKeys(argv []string) Reply {
+	spec := lookupClientCommandSpec(argv[0])
+	if spec == nil {
+		return errCommandGetKeysInvalidCommand
+	}
+
+	if !spec.AcceptsArgumentCount(len(argv) - 1) {
+		return errCommandGetKeysInvalidArguments
+	}
+
+	positions := spec.KeyPositions(argv)
+	if len(positions) == 0 {
+		return errCommandGetKeysNoKeys
+	}
+
+	keys := make([]string, 0, len(positions))
+	for _, position := range positions {
+		keys = append(keys, argv[position])
+	}
+
+	return BulkStringArrayReply(keys)
+}
+
+// HandleCommand processes COMMAND and its COUNT, INFO, DOCS and GETKEYS
+// subcommands, all answered from the command table.
+func HandleCommand(command *RedisCommand) Reply {
+	if len(command.Args) == 0 {
+		return handleCommandInfo(nil)
+	}
+
+	subCommand := strings.ToUpper(command.Args[0])
+	switch subCommand {
+	case "COUNT":
+		if len(command.Args) != 1 {
+			return WrongNumberOfArgumentsReply("command|count")
+		}
+		return IntegerReply(int64(len(CommandSpecs())))
+	case "INFO":
+		return handleCommandInfo(command.Args[1:])
+	case "DOCS":
+		return handleCommandDocs(command.Args[1:])
+	case "GETKEYS":
+		if len(command.Args) < 2 {
+			return WrongNumberOfArgumentsReply("command|getkeys")
+		}
+		return handleCommandGetKeys(command.Args[1:])
+	default:
+		return ErrorReply(fmt.Sprintf("ERR unknown subcommand '%s'. Try COMMAND HELP.", command.Args[0]))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleCommandCount(t *testing.T) {
+	result := HandleCommand(&RedisCommand{Type: CmdCOMMAND, Args: []string{"COUNT"}})
+
+	if result.Kind != ReplyInteger || result.Integer != int64(len(CommandSpecs())) {
+		t.Errorf("HandleCommand(COUNT) = %q, expected :%d", result.String(), len(CommandSpecs()))
+	}
+}
+
+func TestHandleCommandInfo(t *testing.T) {
+	result := HandleCommand(&RedisCommand{Type: CmdCOMMAND, Args: []string{"INFO", "get", "nosuchcommand"}}).String()
+
+	expected := "*2\r\n" +
+		"*10\r\n$3\r\nget\r\n:2\r\n*2\r\n+readonly\r\n+fast\r\n:1\r\n:1\r\n:1\r\n" +
+		"*3\r\n+@string\r\n+@read\r\n+@fast\r\n*0\r\n*0\r\n*0\r\n" +
+		"*-1\r\n"
+	if result != expected {
+		t.Errorf("HandleCommand(INFO) = %q, expected %q", result, expected)
+	}
+}
+
+func TestHandleCommandDocs(t *testing.T) {
+	result := HandleCommand(&RedisCommand{Type: CmdCOMMAND, Args: []string{"DOCS", "echo"}}).String()
+
+	expected := "*2\r\n$4\r\necho\r\n*6\r\n" +
+		"$7\r\nsummary\r\n$25\r\nReturns the given string.\r\n" +
+		"$5\r\nsince\r\n$5\r\n1.0.0\r\n" +
+		"$5\r\ngroup\r\n$10\r\nconnection\r\n"
+	if result != expected {
+		t.Errorf("HandleCommand(DOCS) = %q, expected %q", result, expected)
+	}
+}
+
+func TestHandleCommandGetKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "returns keys",
+			args:     []string{"GETKEYS", "SET", "foo", "bar"},
+			expected: "*1\r\n$3\r\nfoo\r\n",
+		},
+		{
+			name:     "returns movable keys",
+			args:     []string{"GETKEYS", "XREAD", "STREAMS", "a", "b", "0", "0"},
+			expected: "*2\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			name:     "rejects unknown command",
+			args:     []string{"GETKEYS", "NOSUCH", "foo"},
+			expected: errCommandGetKeysInvalidCommand.String(),
+		},
+		{
+			name:     "rejects wrong arity",
+			args:     []string{"GETKEYS", "GET"},
+			expected: errCommandGetKeysInvalidArguments.String(),
+		},
+		{
+			name:     "rejects keyless command",
+			args:     []string{"GETKEYS", "PING"},
+			expected: errCommandGetKeysNoKeys.String(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := HandleCommand(&RedisCommand{Type: CmdCOMMAND, Args: testCase.args}).String()
+			if result != testCase.expected {
+				t.Errorf("HandleCommand() = %q, expected %q", result, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestHandleCommandUnknownSubcommand(t *testing.T) {
+	result := HandleCommand(&RedisCommand{Type: CmdCOMMAND, Args: []string{"BOGUS"}}).String()
+
+	if !strings.HasPrefix(result, "-ERR unknown subcommand 'BOGUS'") {
+		t.Errorf("HandleCommand(BOGUS) = %q, expected unknown subcommand error", result)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import "errors"
+
+// DefaultClientQueryBufferLimit is the default client-query-buffer-limit.
+const DefaultClientQueryBufferLimit = 1024 * 1024 * 1024
+
+// maxIdleQueryBufferCapacity is the largest buffer a reader keeps once every
+// byte in it has been consumed. A larger one, grown for a big command, is
+// released so idle clients do not hold on to it.
+const maxIdleQueryBufferCapacity = 32 * 1024
+
+// ErrQueryBufferLimitExceeded is returned by Next when a client has sent more
+// unparsed bytes than client-query-buffer-limit allows.
+var ErrQueryBufferLimitExceeded = errors.New("client reached max query buffer length")
+
+// ProtocolLimits bound what a single client may make the server buffer.
+type ProtocolLimits struct {
+	MaxBulkLength      int64
+	MaxMultibulkLength int64
+	QueryBufferLimit   int64
+}
+
+// ProtocolLimitsFromConfig returns the configured limits, using the defaults
+// for any that are unset.
+func ProtocolLimitsFromConfig() ProtocolLimits {
+	config := GetConfig()
+	limits := ProtocolLimits{
+		MaxBulkLength:      config.ProtoMaxBulkLen,
+		MaxMultibulkLength: DefaultMaxMultibulkLength,
+		QueryBufferLimit:   config.ClientQueryBufferLimit,
+	}
+
+	if limits.MaxBulkLength <= 0 {
+		limits.MaxBulkLength = DefaultProtoMaxBulkLength
+	}
+	if limits.QueryBufferLimit <= 0 {
+		limits.QueryBufferLimit = DefaultClientQueryBufferLimit
+	}
+
+	return limits
+}
+
+// CommandReader accumulates the bytes read from a connection and hands out
+// complete commands. Frames split across reads stay buffered until the rest of
+// the frame arrives, and pipelined commands are returned one at a time.
+type CommandReader struct {
+	parser           *RESPParser
+	pending          []byte
+	readPosition     int
+	queryBufferLimit int64
+	// multibulk keeps the progress through a partially received array, so
+	// it is not parsed again from the start after every Feed.
+	multibulk multibulkState
+}
+
+func NewCommandReader() *CommandReader {
+	return NewCommandReaderWithLimits(ProtocolLimits{
+		MaxBulkLength:      DefaultProtoMaxBulkLength,
+		MaxMultibulkLength: DefaultMaxMultibulkLength,
+		QueryBufferLimit:   DefaultClientQueryBufferLimit,
+	})
+}
+
+func NewCommandReaderWithLimits(limits ProtocolLimits) *CommandReader {
+	return &CommandReader{
+		parser:           NewRESPParserWithLimits(limits.MaxBulkLength, limits.MaxMultibulkLength),
+		queryBufferLimit: limits.QueryBufferLimit,
+	}
+}
+
+// SetLimits changes the limits applied to the commands the reader has yet to
+// return, so CONFIG SET of proto-max-bulk-len or client-query-buffer-limit
+// reaches connected clients too.
+func (reader *CommandReader) SetLimits(limits ProtocolLimits) {
+	reader.parser.maxBulkLength = limits.MaxBulkLength
+	reader.parser.maxMultibulkLength = limits.MaxMultibulkLength
+	reader.queryBufferLimit = limits.QueryBufferLimit
+}
+
+// Feed appends freshly read bytes to the reader's buffer.
+func (reader *CommandReader) Feed(data []byte) {
+	reader.releaseDrainedBuffer()
+	if reader.readPosition > 0 {
+		remaining := copy(reader.pending, reader.pending[reader.readPosition:])
+		reader.pending = reader.pending[:remaining]
+		reader.readPosition = 0
+	}
+
+	reader.pending = append(reader.pending, data...)
+}
+
+// Next returns the next complete command and the raw bytes it was parsed from.
+// When the buffer holds only part of a command it returns a nil command and a
+// nil error; the caller should Feed more data and try again. The raw bytes are
+// only valid until the next call to Feed.
+func (reader *CommandReader) Next() (*RedisCommand, []byte, error) {
+	for {
+		unread := reader.pending[reader.readPosition:]
+		if len(unread) == 0 {
+			reader.releaseDrainedBuffer()
+			return nil, nil, nil
+		}
+
+		var command *RedisCommand
+		var consumedBytes int
+		var err error
+		if unread[0] == '*' {
+			command, consumedBytes, err = reader.parser.parseArrayResumable(unread, &reader.multibulk)
+		} else {
+			command, consumedBytes, err = reader.parser.Parse(unread)
+		}
+		if err == ErrIncompleteCommand {
+			if int64(len(unread)) > reader.queryBufferLimit {
+				return nil, nil, ErrQueryBufferLimitExceeded
+			}
+			return nil, nil, nil
+		}
+		reader.multibulk = multibulkState{}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reader.readPosition += consumedBytes
+
+		// Blank inline lines and empty arrays are consumed without producing
+		// a command.
+		if command == nil {
+			continue
+		}
+
+		return command, unread[:consumedBytes], nil
+	}
+}
+
+// Reset discards any buffered bytes, including a partially received command.
+func (reader *CommandReader) Reset() {
+	reader.pending = reader.pending[:0]
+	reader.readPosition = 0
+	reader.multibulk = multibulkState{}
+	reader.releaseDrainedBuffer()
+}
+
+// releaseDrainedBuffer drops the buffer when nothing in it is left to parse
+// and it has grown past maxIdleQueryBufferCapacity. Raw command bytes already
+// returned by Next keep their own reference to the old buffer.
+func (reader *CommandReader) releaseDrainedBuffer() {
+	if reader.readPosition == len(reader.pending) && cap(reader.pending) > maxIdleQueryBufferCapacity {
+		reader.pending = nil
+		reader.readPosition = 0
+	}
+}
+
+// Buffered returns the number of bytes received but not yet returned as part of a command.
+func (reader *CommandReader) Buffered() int {
+	return len(reader.pending) - reader.readPosition
+}
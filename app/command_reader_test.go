@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandReaderReturnsNothingUntilCommandIsComplete(t *testing.T) {
+	reader := NewCommandReader()
+
+	fullCommand := "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"
+	for index := 0; index < len(fullCommand)-1; index++ {
+		reader.Feed([]byte{fullCommand[index]})
+
+		command, rawCommand, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() after %d bytes returned error: %v", index+1, err)
+		}
+		if command != nil || rawCommand != nil {
+			t.Fatalf("Next() after %d bytes returned command %v, expected none", index+1, command)
+		}
+	}
+
+	reader.Feed([]byte{fullCommand[len(fullCommand)-1]})
+
+	command, rawCommand, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() returned error: %v", err)
+	}
+	if command == nil || command.Type != CmdSET {
+		t.Fatalf("Next() = %v, expected SET command", command)
+	}
+	if len(command.Args) != 2 || command.Args[0] != "foo" || command.Args[1] != "bar" {
+		t.Errorf("Next() args = %v, expected [foo bar]", command.Args)
+	}
+	if string(rawCommand) != fullCommand {
+		t.Errorf("Next() raw = %q, expected %q", rawCommand, fullCommand)
+	}
+	if reader.Buffered() != 0 {
+		t.Errorf("Buffered() = %d, expected 0", reader.Buffered())
+	}
+}
+
+func TestCommandReaderHandlesPipelineSplitAcrossReads(t *testing.T) {
+	reader := NewCommandReader()
+
+	reader.Feed([]byte("*1\r\n$4\r\nPING\r\n*2\r\n$4\r\nECHO\r\n$5\r\nhel"))
+
+	firstCommand, _, err := reader.Next()
+	if err != nil || firstCommand == nil || firstCommand.Type != CmdPING {
+		t.Fatalf("first Next() = %v, %v, expected PING", firstCommand, err)
+	}
+
+	secondCommand, _, err := reader.Next()
+	if err != nil || secondCommand != nil {
+		t.Fatalf("second Next() = %v, %v, expected no command yet", secondCommand, err)
+	}
+
+	reader.Feed([]byte("lo\r\n"))
+
+	secondCommand, rawCommand, err := reader.Next()
+	if err != nil || secondCommand == nil || secondCommand.Type != CmdECHO {
+		t.Fatalf("second Next() = %v, %v, expected ECHO", secondCommand, err)
+	}
+	if secondCommand.Args[0] != "hello" {
+		t.Errorf("ECHO argument = %q, expected %q", secondCommand.Args[0], "hello")
+	}
+	if string(rawCommand) != "*2\r\n$4\r\nECHO\r\n$5\r\nhello\r\n" {
+		t.Errorf("raw ECHO = %q", rawCommand)
+	}
+}
+
+func TestCommandReaderMixesInlineAndMultibulkCommands(t *testing.T) {
+	reader := NewCommandReader()
+
+	reader.Feed([]byte("PING\r\n\r\n*2\r\n$4\r\nECHO\r\n$2\r\nhi\r\nECHO \"split"))
+
+	expectedTypes := []CommandType{CmdPING, CmdECHO}
+	for _, expectedType := range expectedTypes {
+		command, _, err := reader.Next()
+		if err != nil || command == nil || command.Type != expectedType {
+			t.Fatalf("Next() = %v, %v, expected %s", command, err, expectedType)
+		}
+	}
+
+	if command, _, err := reader.Next(); command != nil || err != nil {
+		t.Fatalf("Next() = %v, %v, expected to wait for the rest of the inline command", command, err)
+	}
+
+	reader.Feed([]byte(" line\"\n"))
+
+	command, _, err := reader.Next()
+	if err != nil || command == nil || command.Type != CmdECHO {
+		t.Fatalf("Next() = %v, %v, expected ECHO", command, err)
+	}
+	if command.Args[0] != "split line" {
+		t.Errorf("inline ECHO argument = %q, expected %q", command.Args[0], "split line")
+	}
+}
+
+func TestCommandReaderReturnsErrorForMalformedCommand(t *testing.T) {
+	reader := NewCommandReader()
+
+	reader.Feed([]byte("*1\r\n+PING\r\n"))
+
+	if _, _, err := reader.Next(); err == nil {
+		t.Fatal("Next() returned no error for malformed command")
+	}
+}
+
+func TestCommandReaderReleasesLargeBufferOnceDrained(t *testing.T) {
+	reader := NewCommandReader()
+	value := strings.Repeat("x", 4*maxIdleQueryBufferCapacity)
+	reader.Feed([]byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"))
+
+	command, rawCommand, err := reader.Next()
+	if err != nil || command == nil || command.Args[1] != value {
+		t.Fatalf("Next() = %v, %v, expected the SET command", command, err)
+	}
+	if command, _, _ := reader.Next(); command != nil {
+		t.Fatalf("Next() = %v, expected no more commands", command)
+	}
+	if capacity := cap(reader.pending); capacity > maxIdleQueryBufferCapacity {
+		t.Errorf("drained buffer capacity = %d, expected at most %d", capacity, maxIdleQueryBufferCapacity)
+	}
+	if !strings.HasSuffix(string(rawCommand), value+"\r\n") {
+		t.Error("releasing the buffer changed the raw bytes of the returned command")
+	}
+
+	reader.Feed([]byte("PING\r\n"))
+	if command, _, err := reader.Next(); err != nil || command == nil || command.Type != CmdPING {
+		t.Errorf("Next() = %v, %v, expected PING after the buffer was released", command, err)
+	}
+}
+
+func TestEventReactorHandlesValuesLargerThanOneRead(t *testing.T) {
+	ResetConnectionTransactionStatesForTest()
+	ResetConnectionPubSubStatesForTest()
+	ResetConnectionWriteMutexesForTest()
+
+	serverConnection, clientConnection := net.Pipe()
+	defer clientConnection.Close()
+
+	commandChannel := make(chan []byte)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+
+	go listen(serverConnection, commandChannel)
+	go eventReactor(commandChannel, serverConnection, &waitGroup, false, nil)
+
+	largeValue := strings.Repeat("x", 3*readBufferSize+17)
+	echoCommand := "*2\r\n$4\r\nECHO\r\n$" + strconv.Itoa(len(largeValue)) + "\r\n" + largeValue + "\r\n"
+
+	go func() {
+		// Write in uneven pieces so frames straddle reads.
+		for start := 0; start < len(echoCommand); start += 1000 {
+			end := start + 1000
+			if end > len(echoCommand) {
+				end = len(echoCommand)
+			}
+			if _, err := clientConnection.Write([]byte(echoCommand[start:end])); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := clientConnection.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set read deadline: %v", err)
+	}
+
+	reader := bufio.NewReader(clientConnection)
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read reply header: %v", err)
+	}
+	expectedHeader := "$" + strconv.Itoa(len(largeValue)) + "\r\n"
+	if header != expectedHeader {
+		t.Fatalf("reply header = %q, expected %q", header, expectedHeader)
+	}
+
+	body := make([]byte, len(largeValue)+2)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		t.Fatalf("read reply body: %v", err)
+	}
+	if string(body[:len(largeValue)]) != largeValue {
+		t.Error("echoed value does not match the value sent")
+	}
+}
+
+func TestCommandReaderReportsProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedReason string
+	}{
+		{"bad multibulk length", "*abc\r\n", "invalid multibulk length"},
+		{"multibulk length over limit", "*1048577\r\n", "invalid multibulk length"},
+		{"bad bulk length", "*1\r\n$x\r\n", "invalid bulk length"},
+		{"null bulk length", "*2\r\n$4\r\nECHO\r\n$-1\r\n", "invalid bulk length"},
+		{"bulk length over limit", "*1\r\n$1025\r\n", "invalid bulk length"},
+		{"element is not a bulk string", "*1\r\n+PING\r\n", "expected '$', got '+'"},
+		{"unbalanced quotes", "SET \"foo\r\n", "unbalanced quotes in request"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := NewCommandReaderWithLimits(ProtocolLimits{
+				MaxBulkLength:      1024,
+				MaxMultibulkLength: DefaultMaxMultibulkLength,
+				QueryBufferLimit:   DefaultClientQueryBufferLimit,
+			})
+			reader.Feed([]byte(test.input))
+
+			_, _, err := reader.Next()
+			var protocolError *ProtocolError
+			if !errors.As(err, &protocolError) {
+				t.Fatalf("Next() error = %v, expected a protocol error", err)
+			}
+			if protocolError.Reason != test.expectedReason {
+				t.Errorf("protocol error reason = %q, expected %q", protocolError.Reason, test.expectedReason)
+			}
+		})
+	}
+}
+
+func TestCommandReaderRejectsOversizedInlineRequest(t *testing.T) {
+	reader := NewCommandReader()
+	reader.Feed([]byte(strings.Repeat("a", maxInlineLength+1)))
+
+	_, _, err := reader.Next()
+	var protocolError *ProtocolError
+	if !errors.As(err, &protocolError) || protocolError.Reason != "too big inline request" {
+		t.Fatalf("Next() error = %v, expected too big inline request", err)
+	}
+}
+
+func TestCommandReaderSkipsEmptyMultibulk(t *testing.T) {
+	reader := NewCommandReader()
+	reader.Feed([]byte("*0\r\n*-1\r\n*1\r\n$4\r\nPING\r\n"))
+
+	command, _, err := reader.Next()
+	if err != nil || command == nil || command.Type != CmdPING {
+		t.Fatalf("Next() = %v, %v, expected PING", command, err)
+	}
+}
+
+func TestCommandReaderEnforcesQueryBufferLimit(t *testing.T) {
+	reader := NewCommandReaderWithLimits(ProtocolLimits{
+		MaxBulkLength:      DefaultProtoMaxBulkLength,
+		MaxMultibulkLength: DefaultMaxMultibulkLength,
+		QueryBufferLimit:   64,
+	})
+
+	reader.Feed([]byte("*2\r\n$4\r\nECHO\r\n$100\r\n" + strings.Repeat("x", 30)))
+	if _, _, err := reader.Next(); err != nil {
+		t.Fatalf("Next() under the limit returned error: %v", err)
+	}
+
+	reader.Feed([]byte(strings.Repeat("x", 30)))
+	if _, _, err := reader.Next(); !errors.Is(err, ErrQueryBufferLimitExceeded) {
+		t.Fatalf("Next() error = %v, expected %v", err, ErrQueryBufferLimitExceeded)
+	}
+}
+
+func TestEventReactorRepliesToProtocolErrorAndCloses(t *testing.T) {
+	resetClientTestState(t)
+
+	serverConnection, clientConnection := net.Pipe()
+	defer clientConnection.Close()
+
+	commandChannel := make(chan []byte)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+
+	go listen(serverConnection, commandChannel)
+	go eventReactor(commandChannel, serverConnection, &waitGroup, false, nil)
+
+	go clientConnection.Write([]byte("*1\r\n$abc\r\n*1\r\n$4\r\nPING\r\n"))
+
+	if err := clientConnection.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set read deadline: %v", err)
+	}
+
+	reader := bufio.NewReader(clientConnection)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read reply: %v", err)
+	}
+	if line != "-ERR Protocol error: invalid bulk length\r\n" {
+		t.Fatalf("reply = %q, expected protocol error", line)
+	}
+
+	if _, err := reader.ReadString('\n'); err != io.EOF {
+		t.Fatalf("read after protocol error = %v, expected the connection to close", err)
+	}
+}
+
+func TestEventReactorAppliesChangedLimitsToConnectedClients(t *testing.T) {
+	resetConfigTestState(t)
+	resetClientTestState(t)
+
+	serverConnection, clientConnection := net.Pipe()
+	defer clientConnection.Close()
+
+	commandChannel := make(chan []byte)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+
+	go listen(serverConnection, commandChannel)
+	go eventReactor(commandChannel, serverConnection, &waitGroup, false, nil)
+
+	clientConnection.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(clientConnection)
+	clientConnection.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	if line, err := reader.ReadString('\n'); err != nil || line != "+PONG\r\n" {
+		t.Fatalf("PING = %q, %v", line, err)
+	}
+
+	if err := SetConfigParameters([][2]string{{"proto-max-bulk-len", "1024"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+	go clientConnection.Write([]byte("*2\r\n$4\r\nECHO\r\n$2000\r\n"))
+	if line, err := reader.ReadString('\n'); err != nil || line != "-ERR Protocol error: invalid bulk length\r\n" {
+		t.Fatalf("oversized bulk after CONFIG SET = %q, %v; expected a protocol error", line, err)
+	}
+}
+
+func largeMultibulkCommand(elements int) []byte {
+	var builder strings.Builder
+	builder.WriteString("*" + strconv.Itoa(elements+2) + "\r\n$5\r\nRPUSH\r\n$4\r\nlist\r\n")
+	for index := 0; index < elements; index++ {
+		element := strconv.Itoa(index)
+		builder.WriteString("$" + strconv.Itoa(len(element)) + "\r\n" + element + "\r\n")
+	}
+	return []byte(builder.String())
+}
+
+func TestCommandReaderResumesLargeMultibulkAcrossReads(t *testing.T) {
+	reader := NewCommandReader()
+	data := largeMultibulkCommand(100000)
+
+	var command *RedisCommand
+	for offset := 0; offset < len(data); offset += readBufferSize {
+		reader.Feed(data[offset:min(offset+readBufferSize, len(data))])
+
+		var err error
+		command, _, err = reader.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if command == nil && offset > len(data)/2 && len(reader.multibulk.elements) < 50000 {
+			t.Fatalf("after %d bytes only %d elements are kept, expected parsing to resume", offset, len(reader.multibulk.elements))
+		}
+	}
+
+	if command == nil || command.Type != CmdRPUSH || len(command.Args) != 100001 {
+		t.Fatalf("Next() = %v, expected RPUSH with 100001 arguments", command)
+	}
+	if command.Args[1] != "0" || command.Args[100000] != "99999" {
+		t.Errorf("arguments start with %q and end with %q", command.Args[1], command.Args[100000])
+	}
+	if reader.Buffered() != 0 {
+		t.Errorf("Buffered() = %d after the command, expected 0", reader.Buffered())
+	}
+}
+
+func BenchmarkCommandReaderLargeMultibulkInSmallReads(b *testing.B) {
+	data := largeMultibulkCommand(100000)
+	b.SetBytes(int64(len(data)))
+
+	for iteration := 0; iteration < b.N; iteration++ {
+		reader := NewCommandReader()
+		for offset := 0; offset < len(data); offset += readBufferSize {
+			reader.Feed(data[offset:min(offset+readBufferSize, len(data))])
+			if _, _, err := reader.Next(); err != nil {
+				b.Fatalf("Next() error = %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// CommandFlags describe how a command behaves. They drive arity checks,
+// replication, subscribed-mode rules and the COMMAND introspection replies.
+type CommandFlags uint32
+
+const (
+	FlagWrite CommandFlags = 1 << iota
+	FlagReadonly
+	FlagBlocking
+	FlagPubSub
+	FlagAdmin
+	FlagNoScript
+	FlagLoadingOK
+	FlagStale
+	FlagFast
+	FlagMayReplicate
+	FlagNoAuth
+)
+
+var commandFlagNames = []struct {
+	flag CommandFlags
+	name string
+}{
+	{FlagWrite, "write"},
+	{FlagReadonly, "readonly"},
+	{FlagBlocking, "blocking"},
+	{FlagPubSub, "pubsub"},
+	{FlagAdmin, "admin"},
+	{FlagNoScript, "noscript"},
+	{FlagLoadingOK, "loading"},
+	{FlagStale, "stale"},
+	{FlagFast, "fast"},
+	{FlagMayReplicate, "may_replicate"},
+	{FlagNoAuth, "no_auth"},
+}
+
+// KeyAccess describes what a command does with its keys, which decides the
+// key permissions ACL requires: read for commands whose reply or effect
+// depends on the stored value, write for commands that modify the key.
+type KeyAccess uint8
+
+const (
+	KeyAccessRead KeyAccess = 1 << iota
+	KeyAccessWrite
+)
+
+// CommandHandler executes a command for a connection and returns its reply.
+type CommandHandler func(connection net.Conn, command *RedisCommand) Reply
+
+// CommandSpec is one entry of the command table.
+type CommandSpec struct {
+	Type CommandType
+	// Name is the lowercase command name.
+	Name string
+	// Arity counts the command name itself. A positive arity is an exact
+	// argument count; a negative arity -N means at least N.
+	Arity int
+	Flags CommandFlags
+	// FirstKey, LastKey and KeyStep locate key arguments in the argument
+	// vector (the name is position 0). A negative LastKey counts from the end.
+	FirstKey int
+	LastKey  int
+	KeyStep  int
+	// KeysFunc locates keys for commands whose key positions depend on
+	// their arguments, such as XREAD. It returns argument vector positions.
+	KeysFunc func(argv []string) []int
+	// KeyAccess applies to every key the command names.
+	KeyAccess KeyAccess
+	Group     string
+	// ACLCategories adds categories that neither the flags nor the group
+	// imply, such as @dangerous for KEYS.
+	ACLCategories []string
+	Since         string
+	Summary       string
+	Handler       CommandHandler
+	// PropagateAs rewrites the command sent to replicas, for commands whose
+	// effect depends on timing (BLPOP propagates the LPOP it performed).
+	// Returning nil suppresses propagation.
+	PropagateAs func(command *RedisCommand, reply Reply) *RedisCommand
+}
+
+var (
+	commandSpecsByType = make(map[CommandType]*CommandSpec)
+	commandSpecsByName = make(map[string]*CommandSpec)
+)
+
+func registerCommand(spec *CommandSpec) {
+	commandSpecsByType[spec.Type] = spec
+	commandSpecsByName[spec.Name] = spec
+}
+
+// LookupCommandSpec returns the table entry for a command type, or nil.
+func LookupCommandSpec(commandType CommandType) *CommandSpec {
+	return commandSpecsByType[commandType]
+}
+
+// LookupCommandSpecByName returns the table entry for a command name in any case, or nil.
+func LookupCommandSpecByName(name string) *CommandSpec {
+	return commandSpecsByName[strings.ToLower(name)]
+}
+
+// CommandSpecs returns every table entry sorted by name.
+func CommandSpecs() []*CommandSpec {
+	specs := make([]*CommandSpec, 0, len(commandSpecsByName))
+	for _, spec := range commandSpecsByName {
+		specs = append(specs, spec)
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+
+	return specs
+}
+
+func (spec *CommandSpec) HasFlag(flag CommandFlags) bool {
+	return spec.Flags&flag != 0
+}
+
+// AcceptsArgumentCount reports whether argumentCount arguments (excluding
+// the command name) satisfy the command's arity.
+func (spec *CommandSpec) AcceptsArgumentCount(argumentCount int) bool {
+	argvLength := argumentCount + 1
+	if spec.Arity >= 0 {
+		return argvLength == spec.Arity
+	}
+
+	return argvLength >= -spec.Arity
+}
+
+// MayBlock reports whether the command can wait for other clients or
+// replicas, so its duration says little about how long it ran.
+func (spec *CommandSpec) MayBlock() bool {
+	return spec.HasFlag(FlagBlocking) || spec.Type == CmdWAIT
+}
+
+// ShouldPropagate reports whether successful executions are sent to replicas.
+func (spec *CommandSpec) ShouldPropagate() bool {
+	return spec.HasFlag(FlagWrite) || spec.HasFlag(FlagMayReplicate)
+}
+
+// FlagNames returns the COMMAND flag names for the spec.
+func (spec *CommandSpec) FlagNames() []string {
+	names := []string{}
+	for _, flagName := range commandFlagNames {
+		if spec.HasFlag(flagName.flag) {
+			names = append(names, flagName.name)
+		}
+	}
+
+	if spec.KeysFunc != nil {
+		names = append(names, "movablekeys")
+	}
+
+	return names
+}
+
+var commandGroupCategories = map[string]string{
+	"connection":   "@connection",
+	"generic":      "@keyspace",
+	"list":         "@list",
+	"pubsub":       "@pubsub",
+	"sorted-set":   "@sortedset",
+	"stream":       "@stream",
+	"string":       "@string",
+	"transactions": "@transaction",
+}
+
+// Categories returns the ACL categories of the command, derived from its
+// flags and group the same way Redis derives them.
+func (spec *CommandSpec) Categories() []string {
+	categories := []string{}
+
+	if group, exists := commandGroupCategories[spec.Group]; exists {
+		categories = append(categories, group)
+	}
+	if spec.HasFlag(FlagWrite) {
+		categories = append(categories, "@write")
+	}
+	if spec.HasFlag(FlagReadonly) {
+		categories = append(categories, "@read")
+	}
+	if spec.HasFlag(FlagAdmin) {
+		categories = append(categories, "@admin", "@dangerous")
+	}
+	if spec.HasFlag(FlagPubSub) && spec.Group != "pubsub" {
+		categories = append(categories, "@pubsub")
+	}
+	if spec.HasFlag(FlagFast) {
+		categories = append(categories, "@fast")
+	} else {
+		categories = append(categories, "@slow")
+	}
+	if spec.HasFlag(FlagBlocking) {
+		categories = append(categories, "@blocking")
+	}
+	categories = append(categories, spec.ACLCategories...)
+
+	return categories
+}
+
+// KeyPositions returns the argument vector positions holding keys for
+// argv, where argv[0] is the command name.
+func (spec *CommandSpec) KeyPositions(argv []string) []int {
+	if spec.KeysFunc != nil {
+		return spec.KeysFunc(argv)
+	}
+
+	if spec.FirstKey == 0 {
+		return nil
+	}
+
+	lastKey := spec.LastKey
+	if lastKey < 0 {
+		lastKey = len(argv) + lastKey
+	}
+
+	positions := []int{}
+	for position := spec.FirstKey; position <= lastKey && position < len(argv); position += spec.KeyStep {
+		positions = append(positions, position)
+	}
+
+	return positions
+}
+
+// CommandKeys returns the keys a parsed command accesses.
+func CommandKeys(command *RedisCommand) []string {
+	spec := LookupCommandSpec(command.Type)
+	if spec == nil {
+		return nil
+	}
+
+	argv := append([]string{spec.Name}, command.Args...)
+	keys := []string{}
+	for _, position := range spec.KeyPositions(argv) {
+		keys = append(keys, argv[position])
+	}
+
+	return keys
+}
+
+// xreadKeyPositions returns the stream key positions of XREAD: the first half
+// of the arguments after STREAMS.
+func xreadKeyPositions(argv []string) []int {
+	for index := 1; index < len(argv); index++ {
+		if !strings.EqualFold(argv[index], "STREAMS") {
+			continue
+		}
+
+		remaining := len(argv) - index - 1
+		positions := []int{}
+		for position := index + 1; position < index+1+remaining/2; position++ {
+			positions = append(positions, position)
+		}
+
+		return positions
+	}
+
+	return nil
+}
+
+func propagateBlpopAsLpop(command *RedisCommand, reply Reply) *RedisCommand {
+	if reply.Kind != ReplyArray || len(reply.Elements) != 2 {
+		return nil
+	}
+
+	return &RedisCommand{
+		Type: CmdLPOP,
+		Args: []string{reply.Elements[0].Text},
+	}
+}
+
+func withoutConnection(handler func(command *RedisCommand) Reply) CommandHandler {
+	return func(connection net.Conn, command *RedisCommand) Reply {
+		return handler(command)
+	}
+}
+
+func init() {
+	for _, spec := range []*CommandSpec{
+		{Type: CmdPING, Name: "ping", Arity: -1, Flags: FlagFast | FlagStale, Group: "connection", Since: "1.0.0",
+			Summary: "Returns the server's liveliness response.", Handler: HandlePing},
+		{Type: CmdECHO, Name: "echo", Arity: 2, Flags: FlagFast, Group: "connection", Since: "1.0.0",
+			Summary: "Returns the given string.", Handler: withoutConnection(HandleEcho)},
+		{Type: CmdHELLO, Name: "hello", Arity: -1, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast | FlagNoAuth, Group: "connection", Since: "6.0.0",
+			Summary: "Handshakes with the Redis server.", Handler: HandleHello},
+		{Type: CmdAUTH, Name: "auth", Arity: -2, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast | FlagNoAuth, Group: "connection", Since: "1.0.0",
+			Summary: "Authenticates the connection.", Handler: HandleAuth},
+		{Type: CmdQUIT, Name: "quit", Arity: -1, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast | FlagNoAuth, Group: "connection", Since: "1.0.0",
+			Summary: "Closes the connection.", Handler: HandleQuit},
+		{Type: CmdRESET, Name: "reset", Arity: 1, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast | FlagNoAuth, Group: "connection", Since: "6.2.0",
+			Summary: "Resets the connection.", Handler: HandleReset},
+
+		{Type: CmdSET, Name: "set", Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessWrite, Group: "string", Since: "1.0.0",
+			Summary: "Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.", Handler: withoutConnection(HandleSet)},
+		{Type: CmdGET, Name: "get", Arity: 2, Flags: FlagReadonly | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "string", Since: "1.0.0",
+			Summary: "Returns the string value of a key.", Handler: withoutConnection(HandleGet)},
+		{Type: CmdINCR, Name: "incr", Arity: 2, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead | KeyAccessWrite, Group: "string", Since: "1.0.0",
+			Summary: "Increments the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.", Handler: withoutConnection(HandleIncr)},
+
+		{Type: CmdKEYS, Name: "keys", Arity: 2, Flags: FlagReadonly, Group: "generic", ACLCategories: []string{"@dangerous"}, Since: "1.0.0",
+			Summary: "Returns all key names that match a pattern.", Handler: withoutConnection(HandleKeys)},
+		{Type: CmdTYPE, Name: "type", Arity: 2, Flags: FlagReadonly | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "generic", Since: "1.0.0",
+			Summary: "Determines the type of value stored at a key.", Handler: withoutConnection(HandleType)},
+		{Type: CmdWAIT, Name: "wait", Arity: 3, Flags: FlagNoScript, Group: "generic", Since: "3.0.0",
+			Summary: "Blocks until the asynchronous replication of all preceding write commands sent by the connection is completed.", Handler: withoutConnection(HandleWait)},
+
+		{Type: CmdRPUSH, Name: "rpush", Arity: -3, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessWrite, Group: "list", Since: "1.0.0",
+			Summary: "Appends one or more elements to a list. Creates the key if it doesn't exist.", Handler: withoutConnection(HandleRpush)},
+		{Type: CmdLPUSH, Name: "lpush", Arity: -3, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessWrite, Group: "list", Since: "1.0.0",
+			Summary: "Prepends one or more elements to a list. Creates the key if it doesn't exist.", Handler: withoutConnection(HandleLpush)},
+		{Type: CmdLRANGE, Name: "lrange", Arity: 4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "list", Since: "1.0.0",
+			Summary: "Returns a range of elements from a list.", Handler: withoutConnection(HandleLrange)},
+		{Type: CmdLLEN, Name: "llen", Arity: 2, Flags: FlagReadonly | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "list", Since: "1.0.0",
+			Summary: "Returns the length of a list.", Handler: withoutConnection(HandleLlen)},
+		{Type: CmdLPOP, Name: "lpop", Arity: -2, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead | KeyAccessWrite, Group: "list", Since: "1.0.0",
+			Summary: "Returns the first elements in a list after removing it. Deletes the list if the last element was popped.", Handler: withoutConnection(HandleLpop)},
+		{Type: CmdBLPOP, Name: "blpop", Arity: -3, Flags: FlagWrite | FlagBlocking | FlagNoScript, FirstKey: 1, LastKey: -2, KeyStep: 1, KeyAccess: KeyAccessRead | KeyAccessWrite, Group: "list", Since: "2.0.0",
+			Summary: "Removes and returns the first element in a list. Blocks until an element is available otherwise.", Handler: withoutConnection(HandleBlpop),
+			PropagateAs: propagateBlpopAsLpop},
+
+		{Type: CmdXADD, Name: "xadd", Arity: -5, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessWrite, Group: "stream", Since: "5.0.0",
+			Summary: "Appends a new message to a stream. Creates the key if it doesn't exist.", Handler: withoutConnection(HandleXadd)},
+		{Type: CmdXRANGE, Name: "xrange", Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "stream", Since: "5.0.0",
+			Summary: "Returns the messages from a stream within a range of IDs.", Handler: withoutConnection(HandleXrange)},
+		{Type: CmdXREAD, Name: "xread", Arity: -4, Flags: FlagReadonly | FlagBlocking, KeysFunc: xreadKeyPositions, KeyAccess: KeyAccessRead, Group: "stream", Since: "5.0.0",
+			Summary: "Returns messages from multiple streams with IDs greater than the ones requested. Blocks until a message is available otherwise.", Handler: withoutConnection(HandleXread)},
+
+		{Type: CmdZADD, Name: "zadd", Arity: -4, Flags: FlagWrite | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessWrite, Group: "sorted-set", Since: "1.2.0",
+			Summary: "Adds one or more members to a sorted set, or updates their scores. Creates the key if it doesn't exist.", Handler: withoutConnection(HandleZadd)},
+		{Type: CmdZRANK, Name: "zrank", Arity: -3, Flags: FlagReadonly | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "sorted-set", Since: "2.0.0",
+			Summary: "Returns the index of a member in a sorted set ordered by ascending scores.", Handler: withoutConnection(HandleZrank)},
+		{Type: CmdZRANGE, Name: "zrange", Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "sorted-set", Since: "1.2.0",
+			Summary: "Returns members in a sorted set within a range of indexes.", Handler: withoutConnection(HandleZrange)},
+		{Type: CmdZCARD, Name: "zcard", Arity: 2, Flags: FlagReadonly | FlagFast, FirstKey: 1, LastKey: 1, KeyStep: 1, KeyAccess: KeyAccessRead, Group: "sorted-set", Since: "1.2.0",
+			Summary: "Returns the number of members in a sorted set.", Handler: withoutConnection(HandleZcard)},
+
+		{Type: CmdMULTI, Name: "multi", Arity: 1, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast, Group: "transactions", Since: "1.2.0",
+			Summary: "Starts a transaction.", Handler: HandleMulti},
+		{Type: CmdEXEC, Name: "exec", Arity: 1, Flags: FlagNoScript | FlagLoadingOK | FlagStale, Group: "transactions", Since: "1.2.0",
+			Summary: "Executes all commands in a transaction.", Handler: HandleExec},
+		{Type: CmdDISCARD, Name: "discard", Arity: 1, Flags: FlagNoScript | FlagLoadingOK | FlagStale | FlagFast, Group: "transactions", Since: "2.0.0",
+			Summary: "Discards a transaction.", Handler: HandleDiscard},
+
+		{Type: CmdSUBSCRIBE, Name: "subscribe", Arity: -2, Flags: FlagPubSub | FlagNoScript | FlagLoadingOK | FlagStale, Group: "pubsub", Since: "2.0.0",
+			Summary: "Listens for messages published to channels.", Handler: HandleSubscribe},
+		{Type: CmdUNSUBSCRIBE, Name: "unsubscribe", Arity: -1, Flags: FlagPubSub | FlagNoScript | FlagLoadingOK | FlagStale, Group: "pubsub", Since: "2.0.0",
+			Summary: "Stops listening to messages posted to channels.", Handler: HandleUnsubscribe},
+		{Type: CmdPUBLISH, Name: "publish", Arity: 3, Flags: FlagPubSub | FlagLoadingOK | FlagStale | FlagFast | FlagMayReplicate, Group: "pubsub", Since: "2.0.0",
+			Summary: "Posts a message to a channel.", Handler: withoutConnection(HandlePublish)},
+
+		{Type: CmdCONFIG, Name: "config", Arity: -2, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "2.0.0",
+			Summary: "A container for server configuration commands.", Handler: withoutConnection(HandleConfig)},
+		{Type: CmdINFO, Name: "info", Arity: -1, Flags: FlagLoadingOK | FlagStale, Group: "server", Since: "1.0.0",
+			Summary: "Returns information and statistics about the server.", Handler: withoutConnection(HandleInfo)},
+		{Type: CmdCOMMAND, Name: "command", Arity: -1, Flags: FlagLoadingOK | FlagStale, Group: "server", Since: "2.8.13",
+			Summary: "Returns detailed information about all commands.", Handler: withoutConnection(HandleCommand)},
+		{Type: CmdCLIENT, Name: "client", Arity: -2, Flags: FlagNoScript | FlagLoadingOK | FlagStale, Group: "connection", Since: "2.4.0",
+			Summary: "A container for client connection commands.", Handler: HandleClient},
+		{Type: CmdSHUTDOWN, Name: "shutdown", Arity: -1, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "1.0.0",
+			Summary: "Synchronously saves the database(s) to disk and shuts down the Redis server.", Handler: HandleShutdown},
+		{Type: CmdREPLCONF, Name: "replconf", Arity: -1, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "3.0.0",
+			Summary: "An internal command for configuring the replication stream.", Handler: HandleReplconf},
+		{Type: CmdACL, Name: "acl", Arity: -2, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "6.0.0",
+			Summary: "A container for Access List Control commands.", Handler: HandleACL},
+		{Type: CmdSLOWLOG, Name: "slowlog", Arity: -2, Flags: FlagAdmin | FlagLoadingOK | FlagStale, Group: "server", Since: "2.2.12",
+			Summary: "A container for slow log commands.", Handler: HandleSlowlog},
+		{Type: CmdMONITOR, Name: "monitor", Arity: 1, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "1.0.0",
+			Summary: "Listens for all requests received by the server in real-time.", Handler: HandleMonitor},
+		{Type: CmdLATENCY, Name: "latency", Arity: -2, Flags: FlagAdmin | FlagNoScript | FlagLoadingOK | FlagStale, Group: "server", Since: "2.8.13",
+			Summary: "A container for latency diagnostics commands.", Handler: HandleLatency},
+		{Type: CmdPSYNC, Name: "psync", Arity: -3, Flags: FlagAdmin | FlagNoScript, Group: "server", Since: "2.8.0",
+			Summary: "An internal command used in replication.", Handler: func(connection net.Conn, command *RedisCommand) Reply {
+				return startReplicaStream(connection, HandlePsync(command, connection))
+			}},
+	} {
+		registerCommand(spec)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandTypeIsCaseInsensitive(t *testing.T) {
+	for _, name := range []string{"get", "GET", "GeT"} {
+		if commandType := ParseCommandType(name); commandType != CmdGET {
+			t.Errorf("ParseCommandType(%q) = %v, expected %v", name, commandType, CmdGET)
+		}
+	}
+}
+
+func TestCommandTableCoversEveryCommandType(t *testing.T) {
+	for commandType := CmdPING; commandType <= CmdCLIENT; commandType++ {
+		spec := LookupCommandSpec(commandType)
+		if spec == nil {
+			t.Errorf("command type %d has no table entry", commandType)
+			continue
+		}
+
+		if ParseCommandType(spec.Name) != commandType {
+			t.Errorf("ParseCommandType(%q) = %v, expected %v", spec.Name, ParseCommandType(spec.Name), commandType)
+		}
+	}
+}
+
+func TestCommandSpecAcceptsArgumentCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		commandType   CommandType
+		argumentCount int
+		expected      bool
+	}{
+		{name: "exact arity matches", commandType: CmdGET, argumentCount: 1, expected: true},
+		{name: "exact arity rejects extra", commandType: CmdGET, argumentCount: 2, expected: false},
+		{name: "minimum arity accepts more", commandType: CmdSET, argumentCount: 4, expected: true},
+		{name: "minimum arity rejects fewer", commandType: CmdSET, argumentCount: 1, expected: false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			spec := LookupCommandSpec(testCase.commandType)
+			if accepted := spec.AcceptsArgumentCount(testCase.argumentCount); accepted != testCase.expected {
+				t.Errorf("AcceptsArgumentCount(%d) = %v, expected %v", testCase.argumentCount, accepted, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *RedisCommand
+		expected []string
+	}{
+		{
+			name:     "single key",
+			command:  &RedisCommand{Type: CmdSET, Args: []string{"foo", "bar"}},
+			expected: []string{"foo"},
+		},
+		{
+			name:     "keys up to the timeout",
+			command:  &RedisCommand{Type: CmdBLPOP, Args: []string{"a", "b", "0"}},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "keys after STREAMS",
+			command:  &RedisCommand{Type: CmdXREAD, Args: []string{"BLOCK", "0", "streams", "s1", "s2", "0-0", "$"}},
+			expected: []string{"s1", "s2"},
+		},
+		{
+			name:     "no keys",
+			command:  &RedisCommand{Type: CmdPING},
+			expected: []string{},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			keys := CommandKeys(testCase.command)
+			if !reflect.DeepEqual(keys, testCase.expected) {
+				t.Errorf("CommandKeys() = %v, expected %v", keys, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestHandleConnectionCommandRejectsUnknownCommand(t *testing.T) {
+	resetTransactionTestState(t)
+	connection := testConnection(t)
+
+	result := HandleConnectionCommand(connection, &RedisCommand{
+		Type: CmdUnknown,
+		Name: "foo",
+		Args: []string{"a", "b"},
+	}).String()
+
+	expected := "-ERR unknown command 'foo', with args beginning with: 'a' 'b' \r\n"
+	if result != expected {
+		t.Errorf("HandleConnectionCommand() = %q, expected %q", result, expected)
+	}
+}
+
+func TestHandleConnectionCommandChecksArityFromTable(t *testing.T) {
+	resetTransactionTestState(t)
+	connection := testConnection(t)
+
+	result := HandleConnectionCommand(connection, &RedisCommand{
+		Type: CmdTYPE,
+		Args: []string{},
+	}).String()
+
+	expected := "-ERR wrong number of arguments for 'type' command\r\n"
+	if result != expected {
+		t.Errorf("HandleConnectionCommand() = %q, expected %q", result, expected)
+	}
+}
+
+func TestExecAbortsAfterQueueingError(t *testing.T) {
+	resetTransactionTestState(t)
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdMULTI})
+
+	setResult := HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"foo", "1"}}).String()
+	if setResult != queuedCommandResponse.String() {
+		t.Fatalf("HandleConnectionCommand(SET) = %q, expected %q", setResult, queuedCommandResponse.String())
+	}
+
+	getResult := HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET}).String()
+	if getResult != "-ERR wrong number of arguments for 'get' command\r\n" {
+		t.Fatalf("HandleConnectionCommand(GET) = %q, expected arity error", getResult)
+	}
+
+	execResult := HandleConnectionCommand(connection, &RedisCommand{Type: CmdEXEC}).String()
+	if execResult != errExecAbort.String() {
+		t.Errorf("HandleConnectionCommand(EXEC) = %q, expected %q", execResult, errExecAbort.String())
+	}
+
+	value := HandleGet(&RedisCommand{Type: CmdGET, Args: []string{"foo"}}).String()
+	if value != "$-1\r\n" {
+		t.Errorf("HandleGet(foo) = %q, expected the aborted SET not to run", value)
+	}
+}
+
+func TestPropagateAsRewritesServedBlpop(t *testing.T) {
+	served := ArrayReply(BulkStringReply("queue"), BulkStringReply("item"))
+
+	propagated := propagateBlpopAsLpop(&RedisCommand{Type: CmdBLPOP, Args: []string{"queue", "0"}}, served)
+	if propagated == nil || propagated.Type != CmdLPOP || !reflect.DeepEqual(propagated.Args, []string{"queue"}) {
+		t.Errorf("propagateBlpopAsLpop() = %+v, expected LPOP queue", propagated)
+	}
+
+	if timedOut := propagateBlpopAsLpop(&RedisCommand{Type: CmdBLPOP}, NullArrayReply()); timedOut != nil {
+		t.Errorf("propagateBlpopAsLpop() = %+v, expected nil for a timeout", timedOut)
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
-	"flag"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 )
 
@@ -15,36 +18,156 @@ type Config struct {
 	MasterPort       string
 	MasterReplId     string
 	MasterReplOffset int
+	LogLevel         string
+	LogFile          string
+	MaxClients       int
+	Timeout          int
+	TCPKeepAlive     int
+
+	ProtoMaxBulkLen        int64
+	ClientQueryBufferLimit int64
+	// ClientOutputBufferLimits holds the classes overridden by
+	// configuration; see ClientOutputBufferLimitForClass.
+	ClientOutputBufferLimits map[string]ClientOutputBufferLimit
+
+	UnixSocket     string
+	UnixSocketPerm os.FileMode
+
+	TLSPort        int
+	TLSCertFile    string
+	TLSKeyFile     string
+	TLSCACertFile  string
+	TLSAuthClients string
+	TLSReplication bool
+
+	Bind          string
+	ProtectedMode bool
+
+	RequirePass string
+	MasterAuth  string
+	MasterUser  string
+	AclFile     string
+
+	// RenamedCommands maps command names to their rename-command target;
+	// an empty target disables the command.
+	RenamedCommands map[string]string
+
+	// SlowlogLogSlowerThan is in microseconds; negative disables the slow
+	// log.
+	SlowlogLogSlowerThan int64
+	SlowlogMaxLen        int
+	// MetricsPort serves Prometheus metrics over HTTP on the MetricsBind
+	// addresses; 0 disables it.
+	MetricsPort int
+	MetricsBind string
+	// LatencyMonitorThreshold is in milliseconds; 0 disables the latency
+	// monitor.
+	LatencyMonitorThreshold int64
+
+	// ConfigFile is the absolute path of the configuration file the server
+	// was started with, if any; CONFIG REWRITE writes to it.
+	ConfigFile string
+	// ConfigFileWarnings describe directives of ConfigFile that were
+	// skipped. They are logged once logging has been configured.
+	ConfigFileWarnings []string
 }
 
 var serverConfig Config
 
-// ParseConfig parses the command-line arguments and sets the server configuration
+// ParseConfig loads the configuration file and command-line options in
+// os.Args and sets the server configuration, exiting on errors.
 func ParseConfig() {
-	var replicaOf string
-	flag.StringVar(&serverConfig.Dir, "dir", "", "the path to the directory where the RDB file is stored")
-	flag.StringVar(&serverConfig.DbFilename, "dbfilename", "", "the name of the RDB file")
-	flag.IntVar(&serverConfig.Port, "port", 6379, "the port number for the server to listen on")
-	flag.StringVar(&replicaOf, "replicaof", "", "master host and port for replication (format: 'host port')")
-	flag.Parse()
-
-	// Initialize replication values (hardcoded for this stage)
-	serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-	serverConfig.MasterReplOffset = 0
-
-	// Parse replicaof flag if provided
-	if replicaOf != "" {
-		// Split on space to get host and port
-		parts := strings.Split(replicaOf, " ")
-		if len(parts) == 2 {
-			serverConfig.IsReplica = true
-			serverConfig.MasterHost = parts[0]
-			serverConfig.MasterPort = parts[1]
+	config, err := LoadConfig(os.Args[1:])
+	if errors.Is(err, errConfigHelp) {
+		printConfigUsage()
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n*** FATAL CONFIG FILE ERROR ***\n%s\n", err.Error())
+		os.Exit(1)
+	}
+
+	serverConfig = config
+}
+
+// memorySizeValue is a flag.Value for sizes written the way redis.conf
+// writes them: a number with an optional k, kb, m, mb, g or gb unit.
+type memorySizeValue int64
+
+func (value *memorySizeValue) String() string {
+	return strconv.FormatInt(int64(*value), 10)
+}
+
+func (value *memorySizeValue) Set(text string) error {
+	size, err := parseMemorySize(text)
+	if err != nil {
+		return err
+	}
+
+	*value = memorySizeValue(size)
+	return nil
+}
+
+// parseMemorySize converts a size such as "512mb" or "1gb" to bytes. As in
+// Redis, k/m/g are powers of 1000 and kb/mb/gb powers of 1024.
+func parseMemorySize(text string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"kb", 1024},
+		{"mb", 1024 * 1024},
+		{"gb", 1024 * 1024 * 1024},
+		{"k", 1000},
+		{"m", 1000 * 1000},
+		{"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+
+	lowered := strings.ToLower(strings.TrimSpace(text))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(lowered, unit.suffix) {
+			lowered = strings.TrimSuffix(lowered, unit.suffix)
+			multiplier = unit.multiplier
+			break
 		}
 	}
+
+	number, err := strconv.ParseInt(lowered, 10, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid memory size '%s'", text)
+	}
+
+	return number * multiplier, nil
+}
+
+// yesNoValue is a flag.Value for redis.conf style yes/no booleans.
+type yesNoValue bool
+
+func (value *yesNoValue) String() string {
+	if value != nil && *value {
+		return "yes"
+	}
+	return "no"
+}
+
+func (value *yesNoValue) Set(text string) error {
+	switch strings.ToLower(text) {
+	case "yes", "true":
+		*value = true
+	case "no", "false":
+		*value = false
+	default:
+		return fmt.Errorf("argument must be 'yes' or 'no', got '%s'", text)
+	}
+	return nil
 }
 
 // GetConfig returns the current server configuration
 func GetConfig() Config {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
 	return serverConfig
 }
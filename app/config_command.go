@@ -5,32 +5,89 @@ import (
 	"strings"
 )
 
+var errConfigWithoutFile = ErrorReply("ERR The server is running without a config file")
+
 // HandleConfig processes a CONFIG command and returns a RESP response
-func HandleConfig(cmd *RedisCommand) string {
-	if len(cmd.Args) < 2 {
-		return "-ERR wrong number of arguments for 'config' command\r\n"
+func HandleConfig(cmd *RedisCommand) Reply {
+	if len(cmd.Args) == 0 {
+		return WrongNumberOfArgumentsReply("config")
 	}
 
 	subCommand := strings.ToUpper(cmd.Args[0])
-	if subCommand != "GET" {
-		return fmt.Sprintf("-ERR unknown config subcommand '%s'\r\n", subCommand)
+	args := cmd.Args[1:]
+
+	switch subCommand {
+	case "GET":
+		if len(args) == 0 {
+			return WrongNumberOfArgumentsReply("config")
+		}
+		return handleConfigGet(args)
+	case "SET":
+		if len(args) == 0 || len(args)%2 != 0 {
+			return WrongNumberOfArgumentsReply("config")
+		}
+		return handleConfigSet(args)
+	case "RESETSTAT":
+		if len(args) != 0 {
+			return WrongNumberOfArgumentsReply("config")
+		}
+		ResetServerStats()
+		return OKReply()
+	case "REWRITE":
+		if len(args) != 0 {
+			return WrongNumberOfArgumentsReply("config")
+		}
+		return handleConfigRewrite()
+	default:
+		return ErrorReply(fmt.Sprintf("ERR unknown config subcommand '%s'", subCommand))
 	}
+}
 
-	parameterName := cmd.Args[1]
+// handleConfigGet returns every parameter matching any of the glob
+// patterns, each reported once.
+func handleConfigGet(patterns []string) Reply {
 	config := GetConfig()
+	matched := make(map[*configParameter]bool)
+	elements := make([]Reply, 0)
 
-	var parameterValue string
-	switch parameterName {
-	case "dir":
-		parameterValue = config.Dir
-	case "dbfilename":
-		parameterValue = config.DbFilename
-	default:
-		return "*0\r\n" // Or handle error? Redis returns an empty array if the parameter is not found
+	for _, pattern := range patterns {
+		lowered := strings.ToLower(pattern)
+		for _, parameter := range configParameters {
+			if matched[parameter] || !globMatch(lowered, parameter.name) {
+				continue
+			}
+			matched[parameter] = true
+			elements = append(elements, BulkStringReply(parameter.name), BulkStringReply(configParameterValue(&config, parameter)))
+		}
+	}
+
+	return MapReply(elements...)
+}
+
+func handleConfigSet(args []string) Reply {
+	pairs := make([][2]string, 0, len(args)/2)
+	for index := 0; index < len(args); index += 2 {
+		pairs = append(pairs, [2]string{args[index], args[index+1]})
+	}
+
+	if err := SetConfigParameters(pairs); err != nil {
+		return ErrorReply("ERR " + err.Error())
+	}
+
+	return OKReply()
+}
+
+func handleConfigRewrite() Reply {
+	config := GetConfig()
+	if config.ConfigFile == "" {
+		return errConfigWithoutFile
+	}
+
+	if err := RewriteConfigFile(config.ConfigFile, config); err != nil {
+		LogWarning("CONFIG REWRITE failed: %s", err.Error())
+		return ErrorReply("ERR Rewriting config file: " + err.Error())
 	}
 
-	// Response format: *2\r\n$<len_param>\r\n<param>\r\n$<len_val>\r\n<val>\r\n
-	return fmt.Sprintf("*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
-		len(parameterName), parameterName,
-		len(parameterValue), parameterValue)
+	LogNotice("CONFIG REWRITE executed with success.")
+	return OKReply()
 }
@@ -1,6 +1,13 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestHandleConfig(t *testing.T) {
 	// Set up test configuration
@@ -42,9 +49,9 @@ func TestHandleConfig(t *testing.T) {
 			name: "CONFIG unknown subcommand",
 			cmd: &RedisCommand{
 				Type: CmdCONFIG,
-				Args: []string{"SET", "dir", "/tmp"},
+				Args: []string{"FOO", "dir"},
 			},
-			expected: "-ERR unknown config subcommand 'SET'\r\n",
+			expected: "-ERR unknown config subcommand 'FOO'\r\n",
 		},
 		{
 			name: "CONFIG missing arguments",
@@ -58,10 +65,193 @@ func TestHandleConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := HandleConfig(tt.cmd)
+			result := HandleConfig(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleConfig() = %q, expected %q", result, tt.expected)
 			}
 		})
 	}
 }
+
+func resetConfigTestState(t *testing.T) {
+	t.Helper()
+	originalConfig := serverConfig
+	serverConfig = defaultConfig()
+	ResetACLForTest()
+	t.Cleanup(func() {
+		serverConfig = originalConfig
+		ResetACLForTest()
+	})
+}
+
+func configCommand(args ...string) string {
+	return HandleConfig(&RedisCommand{Type: CmdCONFIG, Args: args}).String()
+}
+
+func TestConfigGetMatchesPatterns(t *testing.T) {
+	resetConfigTestState(t)
+
+	result := configCommand("GET", "tls-*-file", "MAXCLIENTS", "tls-cert-file")
+	expected := "*8\r\n$16\r\ntls-ca-cert-file\r\n$0\r\n\r\n$13\r\ntls-cert-file\r\n$0\r\n\r\n" +
+		"$12\r\ntls-key-file\r\n$0\r\n\r\n$10\r\nmaxclients\r\n$5\r\n10000\r\n"
+	if result != expected {
+		t.Errorf("CONFIG GET = %q, expected %q", result, expected)
+	}
+
+	if result := configCommand("GET", "*"); strings.Count(result, "\r\n$") != 2*len(configParameters) {
+		t.Errorf("CONFIG GET * = %q, expected every parameter", result)
+	}
+}
+
+func TestConfigSetAppliesAllOrNothing(t *testing.T) {
+	resetConfigTestState(t)
+
+	if result := configCommand("SET", "maxclients", "50", "timeout", "30"); result != "+OK\r\n" {
+		t.Fatalf("CONFIG SET = %q, expected +OK", result)
+	}
+	if config := GetConfig(); config.MaxClients != 50 || config.Timeout != 30 {
+		t.Errorf("maxclients, timeout = %d, %d; expected 50, 30", config.MaxClients, config.Timeout)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"SET", "maxclients", "10", "timeout", "-1"}, "-ERR CONFIG SET failed (possibly related to argument 'timeout') - argument must be between 0 and 1073741824 inclusive\r\n"},
+		{[]string{"SET", "maxclients", "10", "port", "7000"}, "-ERR CONFIG SET failed (possibly related to argument 'port') - can't set immutable config\r\n"},
+		{[]string{"SET", "maxclients", "10", "MAXCLIENTS", "20"}, "-ERR CONFIG SET failed (possibly related to argument 'maxclients') - duplicate parameter\r\n"},
+		{[]string{"SET", "maxclients", "10", "nosuch", "1"}, "-ERR Unknown option or number of arguments for CONFIG SET - 'nosuch'\r\n"},
+		{[]string{"SET", "loglevel", "loud"}, "-ERR CONFIG SET failed (possibly related to argument 'loglevel') - argument(s) must be one of the following: debug, verbose, notice, warning\r\n"},
+		{[]string{"SET", "maxclients"}, "-ERR wrong number of arguments for 'config' command\r\n"},
+	}
+	for _, test := range tests {
+		if result := configCommand(test.args...); result != test.expected {
+			t.Errorf("CONFIG %v = %q, expected %q", test.args, result, test.expected)
+		}
+	}
+
+	if config := GetConfig(); config.MaxClients != 50 {
+		t.Errorf("a rejected CONFIG SET changed maxclients to %d", config.MaxClients)
+	}
+}
+
+func TestConfigSetDirRequiresExistingDirectory(t *testing.T) {
+	resetConfigTestState(t)
+	directory := t.TempDir()
+	file := filepath.Join(directory, "dump.rdb")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := configCommand("SET", "dir", directory); result != "+OK\r\n" {
+		t.Fatalf("CONFIG SET dir = %q, expected +OK", result)
+	}
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{filepath.Join(directory, "missing"), "-ERR CONFIG SET failed (possibly related to argument 'dir') - no such file or directory\r\n"},
+		{file, "-ERR CONFIG SET failed (possibly related to argument 'dir') - not a directory\r\n"},
+	}
+	for _, test := range tests {
+		if result := configCommand("SET", "dir", test.dir); result != test.expected {
+			t.Errorf("CONFIG SET dir %s = %q, expected %q", test.dir, result, test.expected)
+		}
+		if dir := GetConfig().Dir; dir != directory {
+			t.Errorf("a rejected CONFIG SET changed dir to %q", dir)
+		}
+	}
+}
+
+func TestConfigSetRollbackKeepsConcurrentChanges(t *testing.T) {
+	resetConfigTestState(t)
+
+	// A parameter whose hook fails after another change lands in between.
+	failing := &configParameter{name: "test-failing-hook",
+		value: func(config *Config) flag.Value { return intRangeValue{target: &config.Timeout, min: 0, max: 100} },
+		apply: func(config Config) error {
+			configMutex.Lock()
+			serverConfig.MaxClients = 77
+			configMutex.Unlock()
+			return errors.New("hook failed")
+		}}
+	configParametersByName[failing.name] = failing
+	t.Cleanup(func() { delete(configParametersByName, failing.name) })
+
+	err := SetConfigParameters([][2]string{{"slowlog-max-len", "5"}, {"test-failing-hook", "30"}})
+	if err == nil {
+		t.Fatal("SetConfigParameters() returned no error for a failing hook")
+	}
+	config := GetConfig()
+	if config.Timeout != 0 || config.SlowlogMaxLen != DefaultSlowlogMaxLen {
+		t.Errorf("timeout, slowlog-max-len = %d, %d; expected the previous 0, %d", config.Timeout, config.SlowlogMaxLen, DefaultSlowlogMaxLen)
+	}
+	if config.MaxClients != 77 {
+		t.Errorf("maxclients = %d, expected the concurrent change to 77 to survive the rollback", config.MaxClients)
+	}
+}
+
+func TestConfigSetRequirePassUpdatesDefaultUser(t *testing.T) {
+	resetConfigTestState(t)
+
+	configCommand("SET", "requirepass", "secret")
+	if isDefaultUserPasswordless() || !checkACLCredentials(defaultUserName, "secret") {
+		t.Error("CONFIG SET requirepass did not set the default user's password")
+	}
+
+	configCommand("SET", "requirepass", "")
+	if !isDefaultUserPasswordless() {
+		t.Error("clearing requirepass did not make the default user passwordless")
+	}
+}
+
+func TestConfigResetStat(t *testing.T) {
+	totalConnectionsReceived.Store(5)
+	rejectedConnections.Store(2)
+
+	if result := configCommand("RESETSTAT"); result != "+OK\r\n" {
+		t.Fatalf("CONFIG RESETSTAT = %q, expected +OK", result)
+	}
+	if totalConnectionsReceived.Load() != 0 || rejectedConnections.Load() != 0 {
+		t.Error("CONFIG RESETSTAT did not clear the stats")
+	}
+}
+
+func TestConfigRewrite(t *testing.T) {
+	resetConfigTestState(t)
+
+	if result := configCommand("REWRITE"); result != errConfigWithoutFile.String() {
+		t.Errorf("CONFIG REWRITE without a file = %q, expected %q", result, errConfigWithoutFile.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "redis.conf")
+	original := "# Example\nmaxclients 100\nrename-command KEYS \"\"\ntimeout 5\nmaxclients 200\n"
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	serverConfig.ConfigFile = path
+
+	dataDirectory := t.TempDir()
+	configCommand("SET", "maxclients", "300", "timeout", "0", "requirepass", "two words", "dir", dataDirectory)
+	if result := configCommand("REWRITE"); result != "+OK\r\n" {
+		t.Fatalf("CONFIG REWRITE = %q, expected +OK", result)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Example\nmaxclients 300\nrename-command KEYS \"\"\ntimeout 0\n" +
+		configRewriteSignature + "\ndir " + dataDirectory + "\nrequirepass \"two words\"\n"
+	if string(contents) != expected {
+		t.Errorf("rewritten config = %q, expected %q", contents, expected)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("rewritten config permissions = %v, expected 0600", info.Mode().Perm())
+	}
+}
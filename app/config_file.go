@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configDirectiveAliases maps directive names accepted for compatibility to
+// the parameter they set.
+var configDirectiveAliases = map[string]string{
+	"slaveof": "replicaof",
+}
+
+// errConfigHelp is returned by LoadConfig when the arguments ask for usage.
+var errConfigHelp = errors.New("help requested")
+
+// ConfigFileError describes a directive that could not be applied, in the
+// form Redis prints before refusing to start.
+type ConfigFileError struct {
+	Source    string
+	Line      int
+	Directive string
+	Reason    string
+}
+
+func (e *ConfigFileError) Error() string {
+	location := "Reading the command line arguments"
+	if e.Line > 0 {
+		location = fmt.Sprintf("Reading the configuration file %s, at line %d", e.Source, e.Line)
+	}
+	return fmt.Sprintf("%s\n>>> '%s'\n%s", location, e.Directive, e.Reason)
+}
+
+// LoadConfig builds the configuration from command-line arguments. An
+// optional first argument not starting with - names a redis.conf style
+// file; options that follow, written "--name value..." or "-name value...",
+// override its directives. Unsupported directives in files are skipped and
+// recorded in ConfigFileWarnings so existing config files can be reused; on
+// the command line they are errors.
+func LoadConfig(args []string) (Config, error) {
+	config := defaultConfig()
+	config.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
+	config.MasterReplOffset = 0
+
+	if len(args) > 0 && !isConfigOption(args[0]) {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return Config{}, err
+		}
+		if err := loadConfigFile(&config, path, make(map[string]bool)); err != nil {
+			return Config{}, err
+		}
+		config.ConfigFile = path
+		args = args[1:]
+	}
+
+	directives, err := commandLineDirectives(args)
+	if err != nil {
+		return Config{}, err
+	}
+	for _, directive := range directives {
+		if err := applyConfigDirective(&config, directive[0], directive[1:]); err != nil {
+			return Config{}, &ConfigFileError{Directive: strings.Join(directive, " "), Reason: err.Error()}
+		}
+	}
+
+	return config, nil
+}
+
+// isConfigOption reports whether a command-line argument names an option
+// rather than being a value; "-1" and "-::1" are values.
+func isConfigOption(arg string) bool {
+	name := strings.TrimPrefix(arg, "-")
+	if name == arg {
+		return false
+	}
+	name = strings.TrimPrefix(name, "-")
+	return name != "" && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z')
+}
+
+// commandLineDirectives groups arguments into directives: each option starts
+// a new one and the values after it are its arguments. The "--name=value"
+// form accepted by earlier versions is still understood.
+func commandLineDirectives(args []string) ([][]string, error) {
+	directives := make([][]string, 0)
+	for _, arg := range args {
+		if isConfigOption(arg) {
+			name := strings.TrimLeft(arg, "-")
+			if name == "h" || name == "help" {
+				return nil, errConfigHelp
+			}
+			if name, value, hasValue := strings.Cut(name, "="); hasValue {
+				directives = append(directives, []string{name, value})
+				continue
+			}
+			directives = append(directives, []string{name})
+			continue
+		}
+		if len(directives) == 0 {
+			return nil, &ConfigFileError{Directive: arg, Reason: "Invalid argument: options must start with --"}
+		}
+		last := len(directives) - 1
+		directives[last] = append(directives[last], arg)
+	}
+
+	return directives, nil
+}
+
+// loadConfigFile applies every directive in path. visited holds the files
+// currently being read so include cycles are reported instead of looping.
+func loadConfigFile(config *Config, path string, visited map[string]bool) error {
+	if visited[path] {
+		return fmt.Errorf("include cycle detected at '%s'", path)
+	}
+	visited[path] = true
+	defer delete(visited, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Fatal error, can't open config file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fail := func(reason string) error {
+			return &ConfigFileError{Source: path, Line: lineNumber, Directive: line, Reason: reason}
+		}
+
+		// redis.conf lines are split exactly like inline commands.
+		fields, err := splitInlineArguments(line)
+		if err != nil {
+			return fail("Unbalanced quotes in configuration line")
+		}
+		name := strings.ToLower(fields[0])
+
+		if name == "include" {
+			if len(fields) != 2 {
+				return fail("Bad directive or wrong number of arguments")
+			}
+			included, err := filepath.Abs(fields[1])
+			if err != nil {
+				return fail(err.Error())
+			}
+			if err := loadConfigFile(config, included, visited); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isKnownConfigDirective(name) {
+			config.ConfigFileWarnings = append(config.ConfigFileWarnings,
+				fmt.Sprintf("Ignoring unsupported directive '%s' in %s at line %d", fields[0], path, lineNumber))
+			continue
+		}
+		if err := applyConfigDirective(config, name, fields[1:]); err != nil {
+			return fail(err.Error())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Error reading config file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+func isKnownConfigDirective(name string) bool {
+	if _, alias := configDirectiveAliases[name]; alias {
+		return true
+	}
+	return name == "rename-command" || lookupConfigParameter(name) != nil
+}
+
+// applyConfigDirective sets one directive. Multi-word parameters take their
+// arguments joined by spaces, so "replicaof host port" and
+// "replicaof 'host port'" mean the same; others take exactly one argument.
+func applyConfigDirective(config *Config, name string, args []string) error {
+	name = strings.ToLower(name)
+	if target, alias := configDirectiveAliases[name]; alias {
+		name = target
+	}
+
+	if name == "rename-command" {
+		if len(args) != 2 {
+			return errors.New("wrong number of arguments for rename-command")
+		}
+		if config.RenamedCommands == nil {
+			config.RenamedCommands = make(map[string]string)
+		}
+		config.RenamedCommands[strings.ToLower(args[0])] = args[1]
+		return nil
+	}
+
+	parameter := lookupConfigParameter(name)
+	if parameter == nil {
+		return errors.New("Bad directive or wrong number of arguments")
+	}
+
+	value := strings.Join(args, " ")
+	if !parameter.multiWord && len(args) != 1 {
+		return errors.New("wrong number of arguments")
+	}
+
+	return parameter.value(config).Set(value)
+}
+
+// printConfigUsage lists the supported options on standard error.
+func printConfigUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [/path/to/redis.conf] [--option value...]\n\nOptions:\n", filepath.Base(os.Args[0]))
+	for _, parameter := range configParameters {
+		fmt.Fprintf(os.Stderr, "  --%s\n        %s\n", parameter.name, parameter.usage)
+	}
+	fmt.Fprintf(os.Stderr, "  --rename-command <name> <newname>\n        exposes a command under a new name; an empty new name disables it\n")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFileForTest(t *testing.T, directory string, name string, contents string) string {
+	t.Helper()
+	path := filepath.Join(directory, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigReadsConfigFile(t *testing.T) {
+	directory := t.TempDir()
+	included := writeConfigFileForTest(t, directory, "extra.conf", "maxclients 42\n")
+	path := writeConfigFileForTest(t, directory, "redis.conf", strings.Join([]string{
+		"# Example configuration",
+		"port 7001",
+		"bind 127.0.0.1 -::1",
+		`requirepass "pass word\x21"`,
+		`masteruser 'it\'s me'`,
+		"replicaof 10.0.0.1 6380",
+		"client-output-buffer-limit pubsub 1mb 512kb 30",
+		`rename-command KEYS ""`,
+		"appendonly yes",
+		"include " + included,
+		"",
+	}, "\n"))
+
+	config, err := LoadConfig([]string{path})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.ConfigFile != path {
+		t.Errorf("ConfigFile = %q, expected %q", config.ConfigFile, path)
+	}
+	if config.Port != 7001 || config.Bind != "127.0.0.1 -::1" || config.MaxClients != 42 {
+		t.Errorf("port, bind, maxclients = %d, %q, %d", config.Port, config.Bind, config.MaxClients)
+	}
+	if config.RequirePass != "pass word!" || config.MasterUser != "it's me" {
+		t.Errorf("requirepass, masteruser = %q, %q", config.RequirePass, config.MasterUser)
+	}
+	if !config.IsReplica || config.MasterHost != "10.0.0.1" || config.MasterPort != "6380" {
+		t.Errorf("replicaof = %v %q %q", config.IsReplica, config.MasterHost, config.MasterPort)
+	}
+	if limit := config.ClientOutputBufferLimits[clientTypePubSub]; limit.HardLimit != 1024*1024 || limit.SoftSeconds != 30 {
+		t.Errorf("pubsub client-output-buffer-limit = %+v", limit)
+	}
+	if newName, renamed := config.RenamedCommands["keys"]; !renamed || newName != "" {
+		t.Errorf("RenamedCommands = %v, expected keys disabled", config.RenamedCommands)
+	}
+	if config.TCPKeepAlive != 300 {
+		t.Errorf("tcp-keepalive = %d, expected the default 300", config.TCPKeepAlive)
+	}
+	expectedWarning := fmt.Sprintf("Ignoring unsupported directive 'appendonly' in %s at line 9", path)
+	if len(config.ConfigFileWarnings) != 1 || config.ConfigFileWarnings[0] != expectedWarning {
+		t.Errorf("ConfigFileWarnings = %q, expected [%q]", config.ConfigFileWarnings, expectedWarning)
+	}
+}
+
+func TestLoadConfigCommandLineOverridesFile(t *testing.T) {
+	path := writeConfigFileForTest(t, t.TempDir(), "redis.conf", "port 7001\ntimeout 10\n")
+
+	config, err := LoadConfig([]string{path, "--port", "7002", "--replicaof", "localhost", "6379", "-bind", "127.0.0.1", "-::1"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.Port != 7002 || config.Timeout != 10 {
+		t.Errorf("port, timeout = %d, %d; expected 7002, 10", config.Port, config.Timeout)
+	}
+	if config.MasterHost != "localhost" || config.MasterPort != "6379" {
+		t.Errorf("replicaof = %q %q", config.MasterHost, config.MasterPort)
+	}
+	if config.Bind != "127.0.0.1 -::1" {
+		t.Errorf("bind = %q", config.Bind)
+	}
+}
+
+func TestLoadConfigAcceptsEqualsForm(t *testing.T) {
+	config, err := LoadConfig([]string{"--port=6380", "--dir=/tmp/a=b", "--requirepass="})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.Port != 6380 || config.Dir != "/tmp/a=b" || config.RequirePass != "" {
+		t.Errorf("port, dir, requirepass = %d, %q, %q; expected 6380, %q, %q", config.Port, config.Dir, config.RequirePass, "/tmp/a=b", "")
+	}
+	if _, err := LoadConfig([]string{"--port=6380", "6381"}); err == nil {
+		t.Error("LoadConfig(--port=6380 6381) returned no error for the extra argument")
+	}
+}
+
+func TestLoadConfigReportsErrors(t *testing.T) {
+	directory := t.TempDir()
+	badValue := writeConfigFileForTest(t, directory, "bad.conf", "# comment\nmaxclients lots\n")
+	unbalanced := writeConfigFileForTest(t, directory, "quotes.conf", `requirepass "open`+"\n")
+	cycle := filepath.Join(directory, "cycle.conf")
+	writeConfigFileForTest(t, directory, "cycle.conf", "include "+cycle+"\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"invalid value", []string{badValue}, "Reading the configuration file " + badValue + ", at line 2\n>>> 'maxclients lots'\nargument couldn't be parsed into an integer"},
+		{"unbalanced quotes", []string{unbalanced}, "Unbalanced quotes in configuration line"},
+		{"include cycle", []string{cycle}, "include cycle detected"},
+		{"missing file", []string{filepath.Join(directory, "missing.conf")}, "can't open config file"},
+		{"unknown option", []string{"--no-such-option", "1"}, "Reading the command line arguments\n>>> 'no-such-option 1'\nBad directive or wrong number of arguments"},
+		{"too many arguments", []string{"--port", "1", "2"}, "wrong number of arguments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadConfig(test.args)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, test.expected)
+			}
+		})
+	}
+
+	if _, err := LoadConfig([]string{"--help"}); !errors.Is(err, errConfigHelp) {
+		t.Errorf("LoadConfig(--help) error = %v, expected errConfigHelp", err)
+	}
+}
+
+func TestConfigRewriteOutputLoadsBack(t *testing.T) {
+	resetConfigTestState(t)
+	path := writeConfigFileForTest(t, t.TempDir(), "redis.conf", "port 7001\n")
+	serverConfig.ConfigFile = path
+	serverConfig.Port = 7001
+	serverConfig.RequirePass = "tricky \"value\"\n"
+
+	if result := configCommand("REWRITE"); result != "+OK\r\n" {
+		t.Fatalf("CONFIG REWRITE = %q", result)
+	}
+
+	config, err := LoadConfig([]string{path})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.RequirePass != serverConfig.RequirePass || config.Port != 7001 {
+		t.Errorf("requirepass, port = %q, %d after rewrite", config.RequirePass, config.Port)
+	}
+}
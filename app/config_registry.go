@@ -0,0 +1,382 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// configParameter describes one configuration parameter. Its value is read
+// and written through a flag.Value bound to a Config, so the same parsing
+// and validation serve command-line flags, CONFIG GET and CONFIG SET.
+type configParameter struct {
+	name  string
+	usage string
+	// defaultValue is applied before flags are parsed; empty keeps the zero
+	// value.
+	defaultValue string
+	// immutable parameters can only be set at startup.
+	immutable bool
+	// multiWord values hold several words and are written unquoted by
+	// CONFIG REWRITE.
+	multiWord bool
+	value     func(config *Config) flag.Value
+	// apply, when set, makes a changed value take effect after CONFIG SET.
+	apply func(config Config) error
+}
+
+var configMutex sync.RWMutex
+
+var configParameters = []*configParameter{
+	{name: "aclfile", usage: "file ACL users are loaded from and saved to with ACL LOAD and ACL SAVE", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.AclFile) }},
+	{name: "bind", usage: "space-separated addresses to listen on; prefix with - to make one optional", defaultValue: DefaultBind, immutable: true, multiWord: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.Bind) }},
+	{name: "client-output-buffer-limit", usage: "output buffer limits as '<class> <hard> <soft> <seconds>' groups", multiWord: true,
+		value: func(config *Config) flag.Value {
+			return (*clientOutputBufferLimitsValue)(&config.ClientOutputBufferLimits)
+		}},
+	{name: "client-query-buffer-limit", usage: "largest unparsed input buffered per client, e.g. 1gb", defaultValue: strconv.Itoa(DefaultClientQueryBufferLimit),
+		value: func(config *Config) flag.Value { return (*memorySizeValue)(&config.ClientQueryBufferLimit) }},
+	{name: "dbfilename", usage: "the name of the RDB file",
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.DbFilename) }},
+	{name: "dir", usage: "the path to the directory where the RDB file is stored",
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.Dir) },
+		apply: applyDir},
+	{name: "logfile", usage: "file to write logs to; empty logs to standard output", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.LogFile) }},
+	{name: "latency-monitor-threshold", usage: "sample events taking at least this many milliseconds; 0 disables the latency monitor", defaultValue: "0",
+		value: func(config *Config) flag.Value {
+			return int64RangeValue{target: &config.LatencyMonitorThreshold, min: 0, max: math.MaxInt64}
+		}},
+	{name: "loglevel", usage: "log verbosity: debug, verbose, notice or warning", defaultValue: "notice",
+		value: func(config *Config) flag.Value {
+			return enumValue{target: &config.LogLevel, allowed: []string{"debug", "verbose", "notice", "warning"}}
+		},
+		apply: func(config Config) error { return SetLogLevel(config.LogLevel) }},
+	{name: "masterauth", usage: "password a replica uses to authenticate to its master",
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.MasterAuth) }},
+	{name: "masteruser", usage: "user a replica authenticates to its master as; empty uses the default user",
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.MasterUser) }},
+	{name: "maxclients", usage: "maximum number of connected clients", defaultValue: "10000",
+		value: func(config *Config) flag.Value {
+			return intRangeValue{target: &config.MaxClients, min: 1, max: 1 << 30}
+		}},
+	{name: "metrics-bind", usage: "space-separated addresses /metrics listens on; anyone who can reach them can read it", defaultValue: DefaultMetricsBind, immutable: true, multiWord: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.MetricsBind) }},
+	{name: "metrics-port", usage: "the HTTP port serving Prometheus metrics on /metrics, without authentication, on the metrics-bind addresses (loopback by default); 0 disables it", defaultValue: "0", immutable: true,
+		value: func(config *Config) flag.Value { return intRangeValue{target: &config.MetricsPort, min: 0, max: 65535} }},
+	{name: "port", usage: "the TCP port to listen on; 0 disables TCP", defaultValue: "6379", immutable: true,
+		value: func(config *Config) flag.Value { return intRangeValue{target: &config.Port, min: 0, max: 65535} }},
+	{name: "protected-mode", usage: "refuse non-loopback clients when no password is set (yes or no)", defaultValue: "yes",
+		value: func(config *Config) flag.Value { return (*yesNoValue)(&config.ProtectedMode) }},
+	{name: "proto-max-bulk-len", usage: "largest bulk string a client may send, e.g. 512mb", defaultValue: strconv.Itoa(DefaultProtoMaxBulkLength),
+		value: func(config *Config) flag.Value { return (*memorySizeValue)(&config.ProtoMaxBulkLen) }},
+	{name: "replicaof", usage: "master host and port for replication (format: 'host port')", immutable: true, multiWord: true,
+		value: func(config *Config) flag.Value { return (*replicaOfValue)(config) }},
+	{name: "requirepass", usage: "password clients must AUTH with; empty disables authentication",
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.RequirePass) },
+		apply: func(config Config) error {
+			ApplyRequirePass(config.RequirePass)
+			return nil
+		}},
+	{name: "slowlog-log-slower-than", usage: "log commands slower than this many microseconds; -1 disables the slow log", defaultValue: strconv.Itoa(DefaultSlowlogLogSlowerThan),
+		value: func(config *Config) flag.Value {
+			return int64RangeValue{target: &config.SlowlogLogSlowerThan, min: -1, max: math.MaxInt64}
+		}},
+	{name: "slowlog-max-len", usage: "maximum number of entries kept in the slow log", defaultValue: strconv.Itoa(DefaultSlowlogMaxLen),
+		value: func(config *Config) flag.Value {
+			return intRangeValue{target: &config.SlowlogMaxLen, min: 0, max: 1 << 30}
+		},
+		apply: applySlowlogMaxLen},
+	{name: "tcp-keepalive", usage: "TCP keepalive period in seconds for client connections; 0 disables", defaultValue: "300",
+		value: func(config *Config) flag.Value {
+			return intRangeValue{target: &config.TCPKeepAlive, min: 0, max: 1 << 30}
+		}},
+	{name: "timeout", usage: "close clients idle for this many seconds; 0 disables", defaultValue: "0",
+		value: func(config *Config) flag.Value { return intRangeValue{target: &config.Timeout, min: 0, max: 1 << 30} }},
+	{name: "tls-auth-clients", usage: "whether TLS clients must present a certificate: yes, no or optional", defaultValue: tlsAuthClientsYes, immutable: true,
+		value: func(config *Config) flag.Value {
+			return enumValue{target: &config.TLSAuthClients, allowed: []string{tlsAuthClientsYes, tlsAuthClientsNo, tlsAuthClientsOptional}}
+		}},
+	{name: "tls-ca-cert-file", usage: "PEM CA bundle used to verify peer certificates", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.TLSCACertFile) }},
+	{name: "tls-cert-file", usage: "PEM certificate presented to clients and, for replication, to the master", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.TLSCertFile) }},
+	{name: "tls-key-file", usage: "PEM private key for tls-cert-file", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.TLSKeyFile) }},
+	{name: "tls-port", usage: "the TLS port to listen on; 0 disables TLS", defaultValue: "0", immutable: true,
+		value: func(config *Config) flag.Value { return intRangeValue{target: &config.TLSPort, min: 0, max: 65535} }},
+	{name: "tls-replication", usage: "connect to the master over TLS (yes or no)", defaultValue: "no", immutable: true,
+		value: func(config *Config) flag.Value { return (*yesNoValue)(&config.TLSReplication) }},
+	{name: "unixsocket", usage: "path of a Unix socket to listen on in addition to TCP", immutable: true,
+		value: func(config *Config) flag.Value { return (*stringValue)(&config.UnixSocket) }},
+	{name: "unixsocketperm", usage: "octal permissions for the Unix socket, e.g. 700", immutable: true,
+		value: func(config *Config) flag.Value { return (*fileModeValue)(&config.UnixSocketPerm) }},
+}
+
+var configParametersByName = make(map[string]*configParameter)
+
+func init() {
+	sort.Slice(configParameters, func(i, j int) bool {
+		return configParameters[i].name < configParameters[j].name
+	})
+	for _, parameter := range configParameters {
+		configParametersByName[parameter.name] = parameter
+	}
+}
+
+// lookupConfigParameter returns the parameter with the given name in any
+// case, or nil.
+func lookupConfigParameter(name string) *configParameter {
+	return configParametersByName[strings.ToLower(name)]
+}
+
+// applyConfigDefaults sets every parameter with a default to that default.
+func applyConfigDefaults(config *Config) {
+	for _, parameter := range configParameters {
+		if parameter.defaultValue == "" {
+			continue
+		}
+		if err := parameter.value(config).Set(parameter.defaultValue); err != nil {
+			panic(fmt.Sprintf("invalid default for %s: %s", parameter.name, err.Error()))
+		}
+	}
+}
+
+// defaultConfig returns a Config holding only defaults.
+func defaultConfig() Config {
+	var config Config
+	applyConfigDefaults(&config)
+	return config
+}
+
+// configParameterValue formats a parameter the way CONFIG GET reports it.
+func configParameterValue(config *Config, parameter *configParameter) string {
+	return parameter.value(config).String()
+}
+
+// ConfigSetError reports why CONFIG SET rejected its arguments.
+type ConfigSetError struct {
+	Parameter string
+	Reason    string
+}
+
+func (e *ConfigSetError) Error() string {
+	return fmt.Sprintf("CONFIG SET failed (possibly related to argument '%s') - %s", e.Parameter, e.Reason)
+}
+
+// configSetMutex serializes SetConfigParameters, so the apply hooks of one
+// CONFIG SET never interleave with another's.
+var configSetMutex sync.Mutex
+
+// SetConfigParameters applies name/value pairs atomically: either every
+// value is valid and takes effect, or the configuration is left untouched.
+func SetConfigParameters(pairs [][2]string) error {
+	configSetMutex.Lock()
+	defer configSetMutex.Unlock()
+
+	configMutex.Lock()
+	previous := serverConfig
+	updated := serverConfig
+	changed := make([]*configParameter, 0, len(pairs))
+	seen := make(map[*configParameter]bool, len(pairs))
+
+	for _, pair := range pairs {
+		parameter := lookupConfigParameter(pair[0])
+		if parameter == nil {
+			configMutex.Unlock()
+			return fmt.Errorf("Unknown option or number of arguments for CONFIG SET - '%s'", pair[0])
+		}
+		if seen[parameter] {
+			configMutex.Unlock()
+			return &ConfigSetError{Parameter: parameter.name, Reason: "duplicate parameter"}
+		}
+		if parameter.immutable {
+			configMutex.Unlock()
+			return &ConfigSetError{Parameter: parameter.name, Reason: "can't set immutable config"}
+		}
+		if err := parameter.value(&updated).Set(pair[1]); err != nil {
+			configMutex.Unlock()
+			return &ConfigSetError{Parameter: parameter.name, Reason: err.Error()}
+		}
+		seen[parameter] = true
+		changed = append(changed, parameter)
+	}
+
+	serverConfig = updated
+	configMutex.Unlock()
+
+	// The hooks run without configMutex because they may read the
+	// configuration themselves.
+	for index, parameter := range changed {
+		if parameter.apply == nil {
+			continue
+		}
+		if err := parameter.apply(updated); err != nil {
+			restoreConfig(previous, changed, changed[:index])
+			return &ConfigSetError{Parameter: parameter.name, Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// restoreConfig puts back the previous values of the parameters a failed
+// CONFIG SET changed, leaving every other field as it is now, and re-applies
+// the parameters whose new values had already taken effect.
+func restoreConfig(previous Config, changed []*configParameter, applied []*configParameter) {
+	configMutex.Lock()
+	for _, parameter := range changed {
+		parameter.value(&serverConfig).Set(parameter.value(&previous).String())
+	}
+	restored := serverConfig
+	configMutex.Unlock()
+
+	for _, parameter := range applied {
+		if parameter.apply != nil {
+			parameter.apply(restored)
+		}
+	}
+}
+
+// applyDir checks that a dir set with CONFIG SET is an existing directory,
+// as Redis does by changing into it, so a bad path is rejected up front
+// instead of failing the next save.
+func applyDir(config Config) error {
+	info, err := os.Stat(config.Dir)
+	if err != nil {
+		if pathError, isPathError := err.(*os.PathError); isPathError {
+			return pathError.Err
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return syscall.ENOTDIR
+	}
+
+	return nil
+}
+
+// stringValue is a flag.Value for free-form string parameters.
+type stringValue string
+
+func (value *stringValue) String() string {
+	if value == nil {
+		return ""
+	}
+	return string(*value)
+}
+
+func (value *stringValue) Set(text string) error {
+	*value = stringValue(text)
+	return nil
+}
+
+// intRangeValue is a flag.Value for integers limited to [min, max].
+type intRangeValue struct {
+	target   *int
+	min, max int
+}
+
+func (value intRangeValue) String() string {
+	if value.target == nil {
+		return "0"
+	}
+	return strconv.Itoa(*value.target)
+}
+
+func (value intRangeValue) Set(text string) error {
+	number, err := strconv.Atoi(text)
+	if err != nil {
+		return fmt.Errorf("argument couldn't be parsed into an integer")
+	}
+	if number < value.min || number > value.max {
+		return fmt.Errorf("argument must be between %d and %d inclusive", value.min, value.max)
+	}
+
+	*value.target = number
+	return nil
+}
+
+// int64RangeValue is a flag.Value for 64-bit integers limited to [min, max].
+type int64RangeValue struct {
+	target   *int64
+	min, max int64
+}
+
+func (value int64RangeValue) String() string {
+	if value.target == nil {
+		return "0"
+	}
+	return strconv.FormatInt(*value.target, 10)
+}
+
+func (value int64RangeValue) Set(text string) error {
+	number, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("argument couldn't be parsed into an integer")
+	}
+	if number < value.min || number > value.max {
+		return fmt.Errorf("argument must be between %d and %d inclusive", value.min, value.max)
+	}
+
+	*value.target = number
+	return nil
+}
+
+// enumValue is a flag.Value accepting one of a fixed set of words.
+type enumValue struct {
+	target  *string
+	allowed []string
+}
+
+func (value enumValue) String() string {
+	if value.target == nil {
+		return ""
+	}
+	return *value.target
+}
+
+func (value enumValue) Set(text string) error {
+	lowered := strings.ToLower(text)
+	for _, allowed := range value.allowed {
+		if lowered == allowed {
+			*value.target = allowed
+			return nil
+		}
+	}
+
+	return fmt.Errorf("argument(s) must be one of the following: %s", strings.Join(value.allowed, ", "))
+}
+
+// replicaOfValue is a flag.Value for "<host> <port>"; "no one" or an empty
+// value makes the server a master.
+type replicaOfValue Config
+
+func (value *replicaOfValue) String() string {
+	if value == nil || !value.IsReplica {
+		return ""
+	}
+	return value.MasterHost + " " + value.MasterPort
+}
+
+func (value *replicaOfValue) Set(text string) error {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || (len(fields) == 2 && strings.EqualFold(fields[0], "no") && strings.EqualFold(fields[1], "one")) {
+		value.IsReplica, value.MasterHost, value.MasterPort = false, "", ""
+		return nil
+	}
+	if len(fields) != 2 {
+		return fmt.Errorf("replicaof expects '<host> <port>', got '%s'", text)
+	}
+
+	value.IsReplica, value.MasterHost, value.MasterPort = true, fields[0], fields[1]
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const configRewriteSignature = "# Generated by CONFIG REWRITE"
+
+// RewriteConfigFile writes config back to path. Lines setting a known
+// parameter are replaced in place by its current value, later duplicates are
+// dropped, and parameters that differ from their default but are missing
+// from the file are appended. Comments and other directives are kept.
+func RewriteConfigFile(path string, config Config) error {
+	var lines []string
+	contents, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(contents) > 0 {
+		lines = strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+	}
+
+	defaults := defaultConfig()
+	written := make(map[*configParameter]bool)
+	rewritten := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == configRewriteSignature {
+			continue
+		}
+
+		fields := strings.Fields(trimmed)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			rewritten = append(rewritten, line)
+			continue
+		}
+
+		parameter := lookupConfigParameter(fields[0])
+		if parameter == nil {
+			rewritten = append(rewritten, line)
+			continue
+		}
+		if written[parameter] {
+			continue
+		}
+
+		written[parameter] = true
+		if directive, ok := configDirective(&config, parameter); ok {
+			rewritten = append(rewritten, directive)
+		}
+	}
+
+	appended := false
+	for _, parameter := range configParameters {
+		if written[parameter] || configParameterValue(&config, parameter) == configParameterValue(&defaults, parameter) {
+			continue
+		}
+
+		directive, ok := configDirective(&config, parameter)
+		if !ok {
+			continue
+		}
+		if !appended {
+			rewritten = append(rewritten, configRewriteSignature)
+			appended = true
+		}
+		rewritten = append(rewritten, directive)
+	}
+
+	return writeConfigFile(path, []byte(strings.Join(rewritten, "\n")+"\n"))
+}
+
+// configDirective renders the config file line setting parameter. It
+// reports false for multi-word parameters that are unset, such as
+// replicaof on a master, which must not appear in the file at all.
+func configDirective(config *Config, parameter *configParameter) (string, bool) {
+	value := configParameterValue(config, parameter)
+	if parameter.multiWord {
+		if value == "" {
+			return "", false
+		}
+		return parameter.name + " " + value, true
+	}
+
+	return parameter.name + " " + quoteConfigValue(value), true
+}
+
+// quoteConfigValue quotes a value when it is empty or would otherwise be
+// split or misread by the config file parser.
+func quoteConfigValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'\\#") && isPrintableASCII(value) {
+		return value
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for index := 0; index < len(value); index++ {
+		character := value[index]
+		switch {
+		case character == '\\' || character == '"':
+			builder.WriteByte('\\')
+			builder.WriteByte(character)
+		case character == '\n':
+			builder.WriteString(`\n`)
+		case character == '\r':
+			builder.WriteString(`\r`)
+		case character == '\t':
+			builder.WriteString(`\t`)
+		case character < 0x20 || character > 0x7e:
+			fmt.Fprintf(&builder, `\x%02x`, character)
+		default:
+			builder.WriteByte(character)
+		}
+	}
+	builder.WriteByte('"')
+
+	return builder.String()
+}
+
+func isPrintableASCII(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] < 0x20 || value[index] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// writeConfigFile replaces path with contents, keeping the target's
+// permissions.
+func writeConfigFile(path string, contents []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	return replaceFile(path, "temp-config-*", mode, func(file *os.File) error {
+		_, err := file.Write(contents)
+		return err
+	})
+}
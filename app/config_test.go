@@ -143,3 +143,89 @@ func TestParseConfig_NoReplicaOf(t *testing.T) {
 		t.Errorf("Expected MasterPort to be empty, got '%s'", config.MasterPort)
 	}
 }
+
+func TestParseConfig_ClientLimits(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"test", "-maxclients", "50", "-timeout", "120"}
+	flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+	serverConfig = Config{}
+
+	ParseConfig()
+
+	config := GetConfig()
+	if config.MaxClients != 50 {
+		t.Errorf("Expected maxclients 50, got %d", config.MaxClients)
+	}
+	if config.Timeout != 120 {
+		t.Errorf("Expected timeout 120, got %d", config.Timeout)
+	}
+	if config.TCPKeepAlive != 300 {
+		t.Errorf("Expected default tcp-keepalive 300, got %d", config.TCPKeepAlive)
+	}
+}
+
+func TestParseConfig_ProtocolLimits(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"test", "-proto-max-bulk-len", "1mb", "-client-query-buffer-limit", "2g"}
+	flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+	serverConfig = Config{}
+
+	ParseConfig()
+
+	config := GetConfig()
+	if config.ProtoMaxBulkLen != 1024*1024 {
+		t.Errorf("Expected proto-max-bulk-len 1048576, got %d", config.ProtoMaxBulkLen)
+	}
+	if config.ClientQueryBufferLimit != 2000000000 {
+		t.Errorf("Expected client-query-buffer-limit 2000000000, got %d", config.ClientQueryBufferLimit)
+	}
+}
+
+func TestParseMemorySize(t *testing.T) {
+	tests := map[string]int64{
+		"100":   100,
+		"1k":    1000,
+		"1kb":   1024,
+		"512mb": 512 * 1024 * 1024,
+		"1GB":   1024 * 1024 * 1024,
+		"3g":    3000000000,
+	}
+	for input, expected := range tests {
+		size, err := parseMemorySize(input)
+		if err != nil || size != expected {
+			t.Errorf("parseMemorySize(%q) = %d, %v; expected %d", input, size, err, expected)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "-1", "10xb"} {
+		if _, err := parseMemorySize(input); err == nil {
+			t.Errorf("parseMemorySize(%q) returned no error", input)
+		}
+	}
+}
+
+func TestParseConfig_TLS(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	os.Args = []string{"test", "-tls-port", "6390", "-tls-cert-file", "redis.crt", "-tls-replication", "yes", "-tls-auth-clients", "optional"}
+	flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+	serverConfig = Config{}
+
+	ParseConfig()
+
+	config := GetConfig()
+	if config.TLSPort != 6390 || config.TLSCertFile != "redis.crt" {
+		t.Errorf("Expected tls-port 6390 and tls-cert-file redis.crt, got %d and %q", config.TLSPort, config.TLSCertFile)
+	}
+	if !config.TLSReplication {
+		t.Error("Expected tls-replication to be enabled")
+	}
+	if config.TLSAuthClients != "optional" {
+		t.Errorf("Expected tls-auth-clients optional, got %q", config.TLSAuthClients)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "net"
+
+const (
+	protocolVersionRESP2 = 2
+	protocolVersionRESP3 = 3
+)
+
+// ConnectionProtocolVersion returns the RESP version the connection negotiated,
+// defaulting to RESP2 for clients that never sent HELLO.
+func ConnectionProtocolVersion(connection net.Conn) int {
+	clientRegistryMutex.Lock()
+	defer clientRegistryMutex.Unlock()
+
+	client, exists := clientsByConnection[connection]
+	if !exists {
+		return protocolVersionRESP2
+	}
+
+	return client.ProtocolVersion
+}
@@ -1,48 +1,303 @@
 package main
 
 import (
+	"errors"
 	"net"
 	"sync"
+	"time"
 )
 
+// outputFlushThreshold bounds how much a pipelined batch may accumulate
+// before it is handed to the writer early.
+const outputFlushThreshold = 64 * 1024
+
+// errOutputBufferLimitReached is returned for writes to a client that was
+// disconnected for exceeding its client-output-buffer-limit.
+var errOutputBufferLimitReached = errors.New("client output buffer limit reached")
+
+// connectionOutput is a client's output buffer.
+//
+// Replies to a pipelined batch collect in pending and are handed to the
+// client's writer goroutine when the batch ends. Out-of-band output, such as
+// pub/sub deliveries and the replication stream, is appended to the same
+// queue without waiting, so a slow client only ever stalls its own writer.
+type connectionOutput struct {
+	mutex   sync.Mutex
+	changed *sync.Cond
+	pending []byte
+	queued  []byte
+	// inFlight is the size of the chunk the writer is currently writing.
+	inFlight      int
+	writerStarted bool
+	closed        bool
+	err           error
+	// softLimitReachedAt is when the buffer last rose above the soft
+	// limit; it is zero while the buffer is below it.
+	softLimitReachedAt time.Time
+}
+
 var (
 	connectionWriteMutexes sync.Mutex
-	connectionWriteLocks   = make(map[net.Conn]*sync.Mutex)
+	connectionOutputs      = make(map[net.Conn]*connectionOutput)
 )
 
 func ResetConnectionWriteMutexesForTest() {
 	connectionWriteMutexes.Lock()
 	defer connectionWriteMutexes.Unlock()
 
-	connectionWriteLocks = make(map[net.Conn]*sync.Mutex)
+	for _, output := range connectionOutputs {
+		output.close(nil)
+	}
+	connectionOutputs = make(map[net.Conn]*connectionOutput)
 }
 
-func getConnectionWriteMutex(connection net.Conn) *sync.Mutex {
+func getConnectionOutput(connection net.Conn) *connectionOutput {
 	connectionWriteMutexes.Lock()
 	defer connectionWriteMutexes.Unlock()
 
-	mutex, exists := connectionWriteLocks[connection]
+	output, exists := connectionOutputs[connection]
 	if !exists {
-		mutex = &sync.Mutex{}
-		connectionWriteLocks[connection] = mutex
+		output = &connectionOutput{}
+		output.changed = sync.NewCond(&output.mutex)
+		connectionOutputs[connection] = output
 	}
 
-	return mutex
+	return output
 }
 
-func RemoveConnectionWriteMutex(connection net.Conn) {
+// existingConnectionOutput returns the connection's output buffer, or nil if
+// it has none.
+func existingConnectionOutput(connection net.Conn) *connectionOutput {
 	connectionWriteMutexes.Lock()
 	defer connectionWriteMutexes.Unlock()
 
-	delete(connectionWriteLocks, connection)
+	return connectionOutputs[connection]
+}
+
+// RemoveConnectionWriteMutex discards a departed client's output buffer and
+// stops its writer.
+func RemoveConnectionWriteMutex(connection net.Conn) {
+	connectionWriteMutexes.Lock()
+	output, exists := connectionOutputs[connection]
+	delete(connectionOutputs, connection)
+	connectionWriteMutexes.Unlock()
+
+	if exists {
+		output.close(nil)
+	}
+}
+
+// connectionOutputBufferSize reports how many bytes are waiting to be written
+// to the connection.
+func connectionOutputBufferSize(connection net.Conn) int {
+	connectionWriteMutexes.Lock()
+	output, exists := connectionOutputs[connection]
+	connectionWriteMutexes.Unlock()
+	if !exists {
+		return 0
+	}
+
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	return output.sizeLocked()
 }
 
+// WriteReplyToConnection encodes a reply for the connection's negotiated
+// protocol version and writes it.
+func WriteReplyToConnection(connection net.Conn, reply Reply) error {
+	return WriteToConnection(connection, reply.Encode(ConnectionProtocolVersion(connection)))
+}
+
+// WriteToConnection writes a response after any output already buffered for
+// the connection and waits until it has been written.
 func WriteToConnection(connection net.Conn, response string) error {
-	mutex := getConnectionWriteMutex(connection)
-	mutex.Lock()
-	defer mutex.Unlock()
+	output := getConnectionOutput(connection)
+	output.mutex.Lock()
+	output.pending = append(output.pending, response...)
+	output.mutex.Unlock()
+
+	return FlushConnectionOutput(connection)
+}
+
+// BufferReplyToConnection appends a reply to the connection's current batch
+// without writing it. The caller flushes with FlushConnectionOutput once the
+// batch of commands has run; a batch that grows past outputFlushThreshold is
+// handed to the writer early.
+func BufferReplyToConnection(connection net.Conn, reply Reply) error {
+	limit := clientOutputBufferLimitFor(connection)
+	output := getConnectionOutput(connection)
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	if output.closed {
+		return output.closedErrorLocked()
+	}
+
+	output.pending = append(output.pending, reply.Encode(ConnectionProtocolVersion(connection))...)
+	if len(output.pending) >= outputFlushThreshold {
+		output.queuePendingLocked(connection)
+	}
+
+	return output.enforceLimitLocked(connection, limit, time.Now())
+}
+
+// EnqueueToConnection queues data for the connection's writer and returns
+// without waiting for it to be written. A client whose queue grows past its
+// client-output-buffer-limit is disconnected.
+//
+// Only connections that opted into out-of-band output, by subscribing,
+// monitoring or becoming a replica, have a buffer to queue to; those steps
+// create it with getConnectionOutput. A delivery racing with a disconnect
+// therefore finds no buffer, rather than creating one nothing would remove.
+func EnqueueToConnection(connection net.Conn, data []byte) error {
+	limit := clientOutputBufferLimitFor(connection)
+	output := existingConnectionOutput(connection)
+	if output == nil {
+		return net.ErrClosed
+	}
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	if output.closed {
+		return output.closedErrorLocked()
+	}
+
+	// Anything still pending from the client's own batch was produced
+	// first, so it goes out first.
+	output.pending = append(output.pending, data...)
+	output.queuePendingLocked(connection)
+
+	return output.enforceLimitLocked(connection, limit, time.Now())
+}
+
+// EnqueueReplyToConnection encodes a reply for the connection and queues it
+// with EnqueueToConnection.
+func EnqueueReplyToConnection(connection net.Conn, reply Reply) error {
+	return EnqueueToConnection(connection, []byte(reply.Encode(ConnectionProtocolVersion(connection))))
+}
+
+// FlushConnectionOutput hands the connection's current batch to its writer
+// and waits until everything queued so far has been written.
+func FlushConnectionOutput(connection net.Conn) error {
+	output := getConnectionOutput(connection)
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	output.queuePendingLocked(connection)
+	for !output.closed && (len(output.queued) > 0 || output.inFlight > 0) {
+		output.changed.Wait()
+	}
+
+	if output.closed {
+		return output.closedErrorLocked()
+	}
+	return nil
+}
+
+func (output *connectionOutput) sizeLocked() int {
+	return len(output.pending) + len(output.queued) + output.inFlight
+}
+
+func (output *connectionOutput) queuePendingLocked(connection net.Conn) {
+	if len(output.pending) == 0 || output.closed {
+		return
+	}
+
+	output.queued = append(output.queued, output.pending...)
+	output.pending = output.pending[:0]
+	if cap(output.pending) > outputFlushThreshold {
+		// Let an unusually large batch's buffer be reclaimed.
+		output.pending = nil
+	}
+
+	if !output.writerStarted {
+		output.writerStarted = true
+		go output.runWriter(connection)
+	}
+	output.changed.Broadcast()
+}
+
+// runWriter writes queued output to the connection until it is closed.
+func (output *connectionOutput) runWriter(connection net.Conn) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	for {
+		for !output.closed && len(output.queued) == 0 {
+			output.changed.Wait()
+		}
+		if output.closed {
+			return
+		}
+
+		chunk := output.queued
+		output.queued = nil
+		output.inFlight = len(chunk)
 
-	_, writeError := connection.Write([]byte(response))
+		output.mutex.Unlock()
+		_, writeError := connection.Write(chunk)
+		output.mutex.Lock()
 
-	return writeError
+		output.inFlight = 0
+		if writeError != nil {
+			output.closeLocked(writeError)
+			return
+		}
+		output.changed.Broadcast()
+	}
+}
+
+// enforceLimitLocked disconnects the client when its buffer is above the
+// hard limit, or has stayed above the soft limit for too long.
+func (output *connectionOutput) enforceLimitLocked(connection net.Conn, limit ClientOutputBufferLimit, now time.Time) error {
+	size := int64(output.sizeLocked())
+
+	exceeded := limit.HardLimit > 0 && size >= limit.HardLimit
+	if !exceeded && limit.SoftLimit > 0 && size >= limit.SoftLimit {
+		if output.softLimitReachedAt.IsZero() {
+			output.softLimitReachedAt = now
+		} else if now.Sub(output.softLimitReachedAt) >= time.Duration(limit.SoftSeconds)*time.Second {
+			exceeded = true
+		}
+	} else if !exceeded {
+		output.softLimitReachedAt = time.Time{}
+	}
+
+	if !exceeded {
+		return nil
+	}
+
+	outputBufferLimitDisconnections.Add(1)
+	LogWarning("Client %s scheduled to be closed ASAP for overcoming of output buffer limits.", clientAddress(connection))
+	output.closeLocked(errOutputBufferLimitReached)
+	connection.Close()
+
+	return errOutputBufferLimitReached
+}
+
+func (output *connectionOutput) close(err error) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	output.closeLocked(err)
+}
+
+func (output *connectionOutput) closeLocked(err error) {
+	if output.closed {
+		return
+	}
+
+	output.closed = true
+	output.err = err
+	output.pending = nil
+	output.queued = nil
+	output.changed.Broadcast()
+}
+
+func (output *connectionOutput) closedErrorLocked() error {
+	if output.err != nil {
+		return output.err
+	}
+	return net.ErrClosed
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingConnection records every Write so tests can check batching.
+type countingConnection struct {
+	net.Conn
+	mutex  sync.Mutex
+	writes []string
+}
+
+func (connection *countingConnection) Write(data []byte) (int, error) {
+	connection.mutex.Lock()
+	connection.writes = append(connection.writes, string(data))
+	connection.mutex.Unlock()
+	return len(data), nil
+}
+
+func (connection *countingConnection) Writes() []string {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return append([]string(nil), connection.writes...)
+}
+
+func TestBufferedRepliesAreWrittenOnFlush(t *testing.T) {
+	ResetConnectionWriteMutexesForTest()
+	serverConnection, _ := testConnectionPair(t)
+	connection := &countingConnection{Conn: serverConnection}
+
+	BufferReplyToConnection(connection, SimpleStringReply("PONG"))
+	BufferReplyToConnection(connection, IntegerReply(1))
+	if writes := connection.Writes(); len(writes) != 0 {
+		t.Fatalf("writes before flush = %q, expected none", writes)
+	}
+
+	if err := FlushConnectionOutput(connection); err != nil {
+		t.Fatalf("FlushConnectionOutput() error = %v", err)
+	}
+	writes := connection.Writes()
+	if len(writes) != 1 || writes[0] != "+PONG\r\n:1\r\n" {
+		t.Fatalf("writes = %q, expected one write of both replies", writes)
+	}
+}
+
+func TestWriteToConnectionFlushesBufferedRepliesFirst(t *testing.T) {
+	ResetConnectionWriteMutexesForTest()
+	serverConnection, _ := testConnectionPair(t)
+	connection := &countingConnection{Conn: serverConnection}
+
+	BufferReplyToConnection(connection, SimpleStringReply("OK"))
+	if err := WriteToConnection(connection, "+message\r\n"); err != nil {
+		t.Fatalf("WriteToConnection() error = %v", err)
+	}
+
+	writes := connection.Writes()
+	if len(writes) != 1 || writes[0] != "+OK\r\n+message\r\n" {
+		t.Fatalf("writes = %q, expected the buffered reply before the message", writes)
+	}
+}
+
+func TestBufferReplyToConnectionFlushesLargeBatches(t *testing.T) {
+	ResetConnectionWriteMutexesForTest()
+	serverConnection, _ := testConnectionPair(t)
+	connection := &countingConnection{Conn: serverConnection}
+
+	BufferReplyToConnection(connection, BulkStringReply(strings.Repeat("x", outputFlushThreshold)))
+	deadline := time.Now().Add(time.Second)
+	for len(connection.Writes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if writes := connection.Writes(); len(writes) != 1 {
+		t.Fatalf("writes = %d, expected the oversized batch to be flushed", len(writes))
+	}
+}
+
+func TestEventReactorFlushesPipelineOnce(t *testing.T) {
+	resetClientTestState(t)
+	serverConnection, _ := testConnectionPair(t)
+	connection := &countingConnection{Conn: serverConnection}
+
+	commandChannel := make(chan []byte)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+	go eventReactor(commandChannel, connection, &waitGroup, false, nil)
+
+	commandChannel <- []byte(strings.Repeat("*1\r\n$4\r\nPING\r\n", 100))
+	close(commandChannel)
+
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("eventReactor did not exit")
+	}
+
+	writes := connection.Writes()
+	if len(writes) != 1 {
+		t.Fatalf("writes = %d, expected one flush for the pipeline", len(writes))
+	}
+	if writes[0] != strings.Repeat("+PONG\r\n", 100) {
+		t.Errorf("pipeline replies = %q", writes[0])
+	}
+}
+
+func TestEnqueueAfterDisconnectLeavesNoOutputBehind(t *testing.T) {
+	resetPublishTestState(t)
+	serverConnection, clientConnection := testConnectionPair(t)
+	startDrainConnection(clientConnection)
+	HandleSubscribe(serverConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+
+	UnregisterClient(serverConnection)
+	if err := EnqueueToConnection(serverConnection, []byte("+late\r\n")); err == nil {
+		t.Error("EnqueueToConnection() after disconnect returned no error")
+	}
+	if output := existingConnectionOutput(serverConnection); output != nil {
+		t.Error("EnqueueToConnection() after disconnect created an output buffer")
+	}
+}
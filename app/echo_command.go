@@ -1,17 +1,11 @@
 package main
 
-import "fmt"
-
-// HandleEcho processes an ECHO command and returns a RESP bulk string response
-func HandleEcho(cmd *RedisCommand) string {
+// HandleEcho processes an ECHO command and returns a bulk string reply
+func HandleEcho(cmd *RedisCommand) Reply {
 	// ECHO command should have exactly 1 argument
 	if len(cmd.Args) != 1 {
-		// Return error as RESP error type
-		return "-ERR wrong number of arguments for 'echo' command\r\n"
+		return WrongNumberOfArgumentsReply("echo")
 	}
 
-	message := cmd.Args[0]
-
-	// Format as RESP bulk string: $<length>\r\n<data>\r\n
-	return fmt.Sprintf("$%d\r\n%s\r\n", len(message), message)
+	return BulkStringReply(cmd.Args[0])
 }
@@ -52,7 +52,7 @@ func TestHandleEcho(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := HandleEcho(tt.cmd)
+			result := HandleEcho(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleEcho() = %q, expected %q", result, tt.expected)
 			}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// activeExpireCycleInterval matches Redis's default hz of 10.
+	activeExpireCycleInterval = 100 * time.Millisecond
+	// activeExpireKeysPerLoop and activeExpireTimeLimit bound one cycle like
+	// Redis's ACTIVE_EXPIRE_CYCLE_KEYS_PER_LOOP and its 25% CPU budget.
+	activeExpireKeysPerLoop = 20
+	activeExpireTimeLimit   = 25 * time.Millisecond
+)
+
+// activeExpireCycle deletes expired keys that nobody reads. It samples keys
+// with an expiry and keeps going while more than a quarter of a sample had
+// expired, until the time limit. It returns the keys it deleted.
+func activeExpireCycle(cache *Cache) []string {
+	start := time.Now()
+	var deleted []string
+	for {
+		sampled, expired := cache.DeleteExpiredSample(activeExpireKeysPerLoop, CurrentTimeMilliseconds())
+		deleted = append(deleted, expired...)
+		if len(expired)*4 <= sampled || time.Since(start) >= activeExpireTimeLimit {
+			return deleted
+		}
+	}
+}
+
+// runActiveExpireCycle runs one activeExpireCycle, propagates a DEL for every
+// key it deleted so replicas drop them too, and samples its duration as the
+// expire-cycle latency event.
+func runActiveExpireCycle() {
+	start := CurrentTimeMilliseconds()
+	deleted := activeExpireCycle(GetInstance())
+	for _, key := range deleted {
+		PropagateCommand(encodeDelCommand(key))
+	}
+	if len(deleted) > 0 {
+		LogDebug("Active expire cycle deleted %d keys", len(deleted))
+	}
+	recordLatencyEvent(latencyEventExpireCycle, time.Duration(CurrentTimeMilliseconds()-start)*time.Millisecond)
+}
+
+// StartActiveExpireCycle runs runActiveExpireCycle ten times a second. As in
+// Redis, replicas skip the cycle; their keys still expire when accessed.
+func StartActiveExpireCycle() {
+	go func() {
+		ticker := time.NewTicker(activeExpireCycleInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if GetConfig().IsReplica {
+				continue
+			}
+
+			runActiveExpireCycle()
+		}
+	}()
+}
+
+// encodeDelCommand encodes the DEL that replaces an expiry on replicas, as
+// Redis propagates it.
+func encodeDelCommand(key string) []byte {
+	return []byte(fmt.Sprintf("*2\r\n$3\r\nDEL\r\n$%d\r\n%s\r\n", len(key), key))
+}
@@ -1,19 +1,16 @@
 package main
 
-import "fmt"
-
-func HandleGet(cmd *RedisCommand) string {
+func HandleGet(cmd *RedisCommand) Reply {
 	if len(cmd.Args) != 1 {
-		return "-ERR wrong number of arguments for 'get' command\r\n"
+		return WrongNumberOfArgumentsReply("get")
 	}
 
 	cache := GetInstance()
-	value := cache.Get(cmd.Args[0])
+	value := cache.LookupRead(cmd.Args[0])
 
 	if value == nil {
-		return "$-1\r\n"
+		return NullReply()
 	}
 
-	valueStr := value.(string)
-	return fmt.Sprintf("$%d\r\n%s\r\n", len(valueStr), valueStr)
+	return BulkStringReply(value.(string))
 }
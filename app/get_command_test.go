@@ -68,7 +68,7 @@ func TestHandleGet(t *testing.T) {
 				tt.setup()
 			}
 
-			result := HandleGet(tt.cmd)
+			result := HandleGet(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleGet() = %q, expected %q", result, tt.expected)
 			}
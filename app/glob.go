@@ -0,0 +1,94 @@
+package main
+
+// globMatch reports whether text matches a Redis glob-style pattern:
+// * matches any run of characters, ? any single character, [abc] and [a-z]
+// a character class (negated with ^), and \ escapes the next character.
+func globMatch(pattern string, text string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for index := 0; index <= len(text); index++ {
+				if globMatch(pattern[1:], text[index:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(text) == 0 {
+				return false
+			}
+			text = text[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(text) == 0 {
+				return false
+			}
+			matched, rest := matchGlobClass(pattern[1:], text[0])
+			if !matched {
+				return false
+			}
+			pattern = rest
+			text = text[1:]
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(text) == 0 || pattern[0] != text[0] {
+				return false
+			}
+			pattern = pattern[1:]
+			text = text[1:]
+		}
+	}
+
+	return len(text) == 0
+}
+
+// matchGlobClass matches character against the class starting just after
+// '[' and returns the pattern remaining after the closing ']'.
+func matchGlobClass(pattern string, character byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if pattern[1] == character {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			low, high := pattern[0], pattern[2]
+			if low > high {
+				low, high = high, low
+			}
+			if character >= low && character <= high {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == character {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+
+	if len(pattern) > 0 {
+		// Skip the closing ']'.
+		pattern = pattern[1:]
+	}
+
+	return matched != negate, pattern
+}
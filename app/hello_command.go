@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	serverName    = "redis"
+	serverVersion = "7.2.0"
+)
+
+var (
+	errHelloUnsupportedProtocol = ErrorReply("NOPROTO unsupported protocol version")
+	errHelloProtocolNotInteger  = ErrorReply("ERR Protocol version is not an integer or out of range")
+	errWrongPassword            = ErrorReply("WRONGPASS invalid username-password pair or user is disabled.")
+	errInvalidClientName        = ErrorReply("ERR Client names cannot contain spaces, newlines or special characters.")
+	errHelloNotAuthenticated    = ErrorReply("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+)
+
+type helloCommandArguments struct {
+	protocolVersion int
+	authUsername    string
+	authPassword    string
+	hasAuth         bool
+	clientName      string
+	hasClientName   bool
+}
+
+func parseHelloCommandArguments(command *RedisCommand) (arguments helloCommandArguments, errorResponse Reply) {
+	if len(command.Args) == 0 {
+		return arguments, Reply{}
+	}
+
+	protocolVersion, parseError := strconv.Atoi(command.Args[0])
+	if parseError != nil {
+		return arguments, errHelloProtocolNotInteger
+	}
+
+	if protocolVersion != protocolVersionRESP2 && protocolVersion != protocolVersionRESP3 {
+		return arguments, errHelloUnsupportedProtocol
+	}
+
+	arguments.protocolVersion = protocolVersion
+
+	for index := 1; index < len(command.Args); index++ {
+		option := strings.ToUpper(command.Args[index])
+		remaining := len(command.Args) - index - 1
+
+		switch {
+		case option == "AUTH" && remaining >= 2:
+			arguments.hasAuth = true
+			arguments.authUsername = command.Args[index+1]
+			arguments.authPassword = command.Args[index+2]
+			index += 2
+		case option == "SETNAME" && remaining >= 1:
+			arguments.hasClientName = true
+			arguments.clientName = command.Args[index+1]
+			index++
+		default:
+			return arguments, ErrorReply("ERR Syntax error in HELLO option '" + command.Args[index] + "'")
+		}
+	}
+
+	return arguments, Reply{}
+}
+
+func isValidClientName(name string) bool {
+	for _, character := range name {
+		if character < '!' || character > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func encodeHelloResponse(protocolVersion int, clientID int64) Reply {
+	role := "master"
+	if GetConfig().IsReplica {
+		role = "replica"
+	}
+
+	return MapReply(
+		BulkStringReply("server"), BulkStringReply(serverName),
+		BulkStringReply("version"), BulkStringReply(serverVersion),
+		BulkStringReply("proto"), IntegerReply(int64(protocolVersion)),
+		BulkStringReply("id"), IntegerReply(clientID),
+		BulkStringReply("mode"), BulkStringReply("standalone"),
+		BulkStringReply("role"), BulkStringReply(role),
+		BulkStringReply("modules"), ArrayReply(),
+	)
+}
+
+// HandleHello negotiates the connection's protocol version and replies with
+// a description of the server in the negotiated protocol.
+func HandleHello(connection net.Conn, command *RedisCommand) Reply {
+	arguments, errorResponse := parseHelloCommandArguments(command)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	if arguments.hasAuth && !authenticateUser(arguments.authUsername, arguments.authPassword) {
+		recordACLDenial(connection, aclDeniedAuth, "AUTH", arguments.authUsername)
+		return errWrongPassword
+	}
+	if !arguments.hasAuth && !isClientAuthenticated(connection) {
+		return errHelloNotAuthenticated
+	}
+
+	if arguments.hasClientName && !isValidClientName(arguments.clientName) {
+		return errInvalidClientName
+	}
+
+	client := updateClient(connection, func(client *Client) {
+		if arguments.protocolVersion != 0 {
+			client.ProtocolVersion = arguments.protocolVersion
+		}
+		if arguments.hasClientName {
+			client.Name = arguments.clientName
+		}
+		if arguments.hasAuth {
+			client.User = arguments.authUsername
+			client.Authenticated = true
+		}
+	})
+
+	return encodeHelloResponse(client.ProtocolVersion, client.ID)
+}
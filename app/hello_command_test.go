@@ -0,0 +1,226 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetHelloTestState(t *testing.T) {
+	t.Helper()
+	ResetClientRegistryForTest()
+	ResetConnectionPubSubStatesForTest()
+	ResetConnectionTransactionStatesForTest()
+	ResetConnectionWriteMutexesForTest()
+}
+
+func TestParseHelloCommandArguments(t *testing.T) {
+	tests := []struct {
+		name                    string
+		args                    []string
+		expectedProtocolVersion int
+		expectedClientName      string
+		expectedError           string
+	}{
+		{
+			name: "accepts no arguments",
+			args: []string{},
+		},
+		{
+			name:                    "parses protocol version",
+			args:                    []string{"3"},
+			expectedProtocolVersion: 3,
+		},
+		{
+			name:                    "parses auth and setname",
+			args:                    []string{"2", "auth", "default", "secret", "SETNAME", "worker"},
+			expectedProtocolVersion: 2,
+			expectedClientName:      "worker",
+		},
+		{
+			name:          "rejects unsupported protocol version",
+			args:          []string{"4"},
+			expectedError: errHelloUnsupportedProtocol.String(),
+		},
+		{
+			name:          "rejects non-integer protocol version",
+			args:          []string{"three"},
+			expectedError: errHelloProtocolNotInteger.String(),
+		},
+		{
+			name:          "rejects unknown option",
+			args:          []string{"3", "FOO"},
+			expectedError: "-ERR Syntax error in HELLO option 'FOO'\r\n",
+		},
+		{
+			name:          "rejects auth without password",
+			args:          []string{"3", "AUTH", "default"},
+			expectedError: "-ERR Syntax error in HELLO option 'AUTH'\r\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			arguments, errorResponse := parseHelloCommandArguments(&RedisCommand{
+				Type: CmdHELLO,
+				Args: testCase.args,
+			})
+
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseHelloCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
+			}
+
+			if errorResponse.IsEmpty() && arguments.protocolVersion != testCase.expectedProtocolVersion {
+				t.Errorf("parseHelloCommandArguments() protocolVersion = %d, expected %d", arguments.protocolVersion, testCase.expectedProtocolVersion)
+			}
+
+			if errorResponse.IsEmpty() && arguments.clientName != testCase.expectedClientName {
+				t.Errorf("parseHelloCommandArguments() clientName = %q, expected %q", arguments.clientName, testCase.expectedClientName)
+			}
+		})
+	}
+}
+
+func TestHandleHelloNegotiatesRESP3(t *testing.T) {
+	resetHelloTestState(t)
+	connection := testConnection(t)
+
+	result := HandleHello(connection, &RedisCommand{
+		Type: CmdHELLO,
+		Args: []string{"3"},
+	}).Encode(protocolVersionRESP3)
+
+	if !strings.HasPrefix(result, "%7\r\n$6\r\nserver\r\n$5\r\nredis\r\n") {
+		t.Errorf("HandleHello() = %q, expected a RESP3 map", result)
+	}
+	if !strings.Contains(result, "$5\r\nproto\r\n:3\r\n") {
+		t.Errorf("HandleHello() = %q, expected proto 3", result)
+	}
+	if ConnectionProtocolVersion(connection) != protocolVersionRESP3 {
+		t.Errorf("ConnectionProtocolVersion() = %d, expected 3", ConnectionProtocolVersion(connection))
+	}
+}
+
+func TestHandleHelloWithoutArgumentsKeepsRESP2(t *testing.T) {
+	resetHelloTestState(t)
+	connection := testConnection(t)
+
+	result := HandleHello(connection, &RedisCommand{
+		Type: CmdHELLO,
+		Args: []string{},
+	}).String()
+
+	if !strings.HasPrefix(result, "*14\r\n") {
+		t.Errorf("HandleHello() = %q, expected a flat RESP2 array", result)
+	}
+	if ConnectionProtocolVersion(connection) != protocolVersionRESP2 {
+		t.Errorf("ConnectionProtocolVersion() = %d, expected 2", ConnectionProtocolVersion(connection))
+	}
+}
+
+func TestHandleHelloRejectsUnknownUser(t *testing.T) {
+	resetHelloTestState(t)
+	connection := testConnection(t)
+
+	result := HandleHello(connection, &RedisCommand{
+		Type: CmdHELLO,
+		Args: []string{"3", "AUTH", "someone", "secret"},
+	}).String()
+
+	if result != errWrongPassword.String() {
+		t.Errorf("HandleHello() = %q, expected %q", result, errWrongPassword.String())
+	}
+	if ConnectionProtocolVersion(connection) != protocolVersionRESP2 {
+		t.Error("failed HELLO should not change the protocol version")
+	}
+}
+
+func TestHandleHelloRejectsInvalidClientName(t *testing.T) {
+	resetHelloTestState(t)
+	connection := testConnection(t)
+
+	result := HandleHello(connection, &RedisCommand{
+		Type: CmdHELLO,
+		Args: []string{"3", "SETNAME", "has space"},
+	}).String()
+
+	if result != errInvalidClientName.String() {
+		t.Errorf("HandleHello() = %q, expected %q", result, errInvalidClientName.String())
+	}
+}
+
+func TestRESP3SubscriberReceivesPushFrames(t *testing.T) {
+	resetHelloTestState(t)
+	serverConnection, clientConnection := testConnectionPair(t)
+
+	HandleHello(serverConnection, &RedisCommand{Type: CmdHELLO, Args: []string{"3"}})
+
+	subscribeResult := HandleSubscribe(serverConnection, &RedisCommand{
+		Type: CmdSUBSCRIBE,
+		Args: []string{"news"},
+	}).Encode(protocolVersionRESP3)
+	if subscribeResult != ">3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n" {
+		t.Errorf("HandleSubscribe() = %q, expected a push frame", subscribeResult)
+	}
+
+	echoResult := HandleConnectionCommand(serverConnection, &RedisCommand{
+		Type: CmdECHO,
+		Args: []string{"still-allowed"},
+	}).Encode(protocolVersionRESP3)
+	if echoResult != "$13\r\nstill-allowed\r\n" {
+		t.Errorf("ECHO while subscribed over RESP3 = %q", echoResult)
+	}
+
+	resultChannel := make(chan string, 1)
+	go func() {
+		resultChannel <- HandlePublish(&RedisCommand{
+			Type: CmdPUBLISH,
+			Args: []string{"news", "hi"},
+		}).String()
+	}()
+
+	message, readError := readAvailableBytes(clientConnection)
+	if readError != nil {
+		t.Fatalf("read pushed message: %v", readError)
+	}
+	expectedMessage := ">3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$2\r\nhi\r\n"
+	if string(message) != expectedMessage {
+		t.Errorf("pushed message = %q, expected %q", message, expectedMessage)
+	}
+
+	select {
+	case <-resultChannel:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("timed out waiting for publish result")
+	}
+}
+
+func TestRESP3EncodersFallBackToRESP2(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{"null resp3", encodeNull(protocolVersionRESP3), "_\r\n"},
+		{"null resp2", encodeNull(protocolVersionRESP2), "$-1\r\n"},
+		{"boolean resp3", encodeBoolean(protocolVersionRESP3, true), "#t\r\n"},
+		{"boolean resp2", encodeBoolean(protocolVersionRESP2, false), ":0\r\n"},
+		{"double resp3", encodeDouble(protocolVersionRESP3, 1.5), ",1.5\r\n"},
+		{"double resp2", encodeDouble(protocolVersionRESP2, 1.5), "$3\r\n1.5\r\n"},
+		{"infinite double", encodeDouble(protocolVersionRESP3, math.Inf(1)), ",inf\r\n"},
+		{"map resp3", encodeMapHeader(protocolVersionRESP3, 2), "%2\r\n"},
+		{"map resp2", encodeMapHeader(protocolVersionRESP2, 2), "*4\r\n"},
+		{"set resp3", encodeSetHeader(protocolVersionRESP3, 2), "~2\r\n"},
+		{"push resp3", encodePushHeader(protocolVersionRESP3, 3), ">3\r\n"},
+		{"push resp2", encodePushHeader(protocolVersionRESP2, 3), "*3\r\n"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.actual != testCase.expected {
+				t.Errorf("got %q, expected %q", testCase.actual, testCase.expected)
+			}
+		})
+	}
+}
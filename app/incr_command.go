@@ -1,21 +1,18 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-)
+import "strconv"
 
-func parseIncrCommandArguments(command *RedisCommand) (key string, errorResponse string) {
+func parseIncrCommandArguments(command *RedisCommand) (key string, errorResponse Reply) {
 	if len(command.Args) != 1 {
-		return "", "-ERR wrong number of arguments for 'incr' command\r\n"
+		return "", WrongNumberOfArgumentsReply("incr")
 	}
 
-	return command.Args[0], ""
+	return command.Args[0], Reply{}
 }
 
-func HandleIncr(command *RedisCommand) string {
+func HandleIncr(command *RedisCommand) Reply {
 	key, errorResponse := parseIncrCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
@@ -29,11 +26,11 @@ func HandleIncr(command *RedisCommand) string {
 
 	currentValue, parseError := strconv.Atoi(valueString)
 	if parseError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	incrementedValue := currentValue + 1
 	cache.Set(key, strconv.Itoa(incrementedValue), nil)
 
-	return fmt.Sprintf(":%d\r\n", incrementedValue)
+	return IntegerReply(int64(incrementedValue))
 }
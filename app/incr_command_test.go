@@ -38,8 +38,8 @@ func TestParseIncrCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseIncrCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseIncrCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if key != testCase.expectedKey {
@@ -60,7 +60,7 @@ func TestHandleIncrIncrementsExistingNumericValue(t *testing.T) {
 	result := HandleIncr(&RedisCommand{
 		Type: CmdINCR,
 		Args: []string{"foo"},
-	})
+	}).String()
 
 	if result != ":42\r\n" {
 		t.Errorf("HandleIncr() = %q, expected %q", result, ":42\r\n")
@@ -78,7 +78,7 @@ func TestHandleIncrIncrementsValueOnRepeatedCalls(t *testing.T) {
 	firstResult := HandleIncr(&RedisCommand{
 		Type: CmdINCR,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if firstResult != ":6\r\n" {
 		t.Errorf("first HandleIncr() = %q, expected %q", firstResult, ":6\r\n")
 	}
@@ -86,7 +86,7 @@ func TestHandleIncrIncrementsValueOnRepeatedCalls(t *testing.T) {
 	secondResult := HandleIncr(&RedisCommand{
 		Type: CmdINCR,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if secondResult != ":7\r\n" {
 		t.Errorf("second HandleIncr() = %q, expected %q", secondResult, ":7\r\n")
 	}
@@ -114,7 +114,7 @@ func TestHandleIncrSetsMissingKeyToOne(t *testing.T) {
 			result := HandleIncr(&RedisCommand{
 				Type: CmdINCR,
 				Args: []string{testCase.key},
-			})
+			}).String()
 
 			if result != ":1\r\n" {
 				t.Errorf("HandleIncr() = %q, expected %q", result, ":1\r\n")
@@ -123,7 +123,7 @@ func TestHandleIncrSetsMissingKeyToOne(t *testing.T) {
 			getResult := HandleGet(&RedisCommand{
 				Type: CmdGET,
 				Args: []string{testCase.key},
-			})
+			}).String()
 			if getResult != "$1\r\n1\r\n" {
 				t.Errorf("HandleGet() = %q, expected %q", getResult, "$1\r\n1\r\n")
 			}
@@ -156,7 +156,7 @@ func TestHandleIncrArgumentParsing(t *testing.T) {
 			result := HandleIncr(&RedisCommand{
 				Type: CmdINCR,
 				Args: testCase.args,
-			})
+			}).String()
 
 			if result != testCase.expected {
 				t.Errorf("HandleIncr() = %q, expected %q", result, testCase.expected)
@@ -1,22 +1,327 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
 
-// HandleInfo processes an INFO command and returns a RESP bulk string response
-func HandleInfo(cmd *RedisCommand) string {
-	// INFO command can have 0 or 1 argument (section name)
-	// For this stage, we only support the replication section
-	// The response should include role, master_replid, and master_repl_offset
+// infoSection is one "# Name" block of INFO output.
+type infoSection struct {
+	name string
+	// inDefault sections are returned by INFO without arguments; every
+	// section is returned by INFO all and INFO everything.
+	inDefault bool
+	format    func() string
+}
+
+var infoSections = []infoSection{
+	{name: "server", inDefault: true, format: formatServerInfoSection},
+	{name: "clients", inDefault: true, format: formatClientsInfoSection},
+	{name: "memory", inDefault: true, format: formatMemoryInfoSection},
+	{name: "persistence", inDefault: true, format: formatPersistenceInfoSection},
+	{name: "stats", inDefault: true, format: formatStatsInfoSection},
+	{name: "replication", inDefault: true, format: formatReplicationInfoSection},
+	{name: "commandstats", format: formatCommandStatsInfoSection},
+	{name: "latencystats", format: formatLatencyStatsInfoSection},
+	{name: "keyspace", inDefault: true, format: formatKeyspaceInfoSection},
+}
 
+// formatInfoFields renders a section header followed by "name:value" lines.
+func formatInfoFields(title string, fields ...string) string {
+	var builder strings.Builder
+	builder.WriteString("# " + title + "\r\n")
+	for index := 0; index+1 < len(fields); index += 2 {
+		builder.WriteString(fields[index] + ":" + fields[index+1] + "\r\n")
+	}
+	return builder.String()
+}
+
+// formatServerInfoSection renders the server section of INFO.
+func formatServerInfoSection() string {
 	config := GetConfig()
-	role := "master"
+	uptime := time.Since(serverStartTime)
+	executable, _ := os.Executable()
+
+	return formatInfoFields("Server",
+		"redis_version", serverVersion,
+		"redis_mode", "standalone",
+		"os", runtime.GOOS+" "+runtime.GOARCH,
+		"arch_bits", fmt.Sprint(strconv.IntSize),
+		"process_id", fmt.Sprint(os.Getpid()),
+		"run_id", serverRunID,
+		"tcp_port", fmt.Sprint(config.Port),
+		"server_time_usec", fmt.Sprint(time.Now().UnixMicro()),
+		"uptime_in_seconds", fmt.Sprint(int64(uptime.Seconds())),
+		"uptime_in_days", fmt.Sprint(int64(uptime.Hours()/24)),
+		"executable", executable,
+		"config_file", config.ConfigFile,
+		"unixsocket", config.UnixSocket,
+	)
+}
+
+// clientCounts returns how many clients are connected, blocked in a
+// blocking command and subscribed to channels.
+func clientCounts() (connected int, blocked int, pubsub int) {
+	for _, client := range ClientsSnapshot() {
+		connected++
+		if client.Blocked {
+			blocked++
+		}
+		if clientType(client) == clientTypePubSub {
+			pubsub++
+		}
+	}
+	return connected, blocked, pubsub
+}
+
+// formatClientsInfoSection renders the clients section of INFO.
+func formatClientsInfoSection() string {
+	config := GetConfig()
+	connectedClients, blockedClients, pubsubClients := clientCounts()
+
+	return formatInfoFields("Clients",
+		"connected_clients", fmt.Sprint(connectedClients),
+		"maxclients", fmt.Sprint(config.MaxClients),
+		"blocked_clients", fmt.Sprint(blockedClients),
+		"pubsub_clients", fmt.Sprint(pubsubClients),
+		"timeout", fmt.Sprint(config.Timeout),
+		"tcp_keepalive", fmt.Sprint(config.TCPKeepAlive),
+	)
+}
+
+var usedMemoryPeak atomic.Uint64
+
+// memoryUsage returns the Go runtime's view of the heap: the bytes in use,
+// the bytes obtained from the OS and the highest use seen so far.
+func memoryUsage() (used uint64, rss uint64, peak uint64) {
+	var memoryStats runtime.MemStats
+	runtime.ReadMemStats(&memoryStats)
+
+	used = memoryStats.HeapAlloc
+	for {
+		peak := usedMemoryPeak.Load()
+		if used <= peak || usedMemoryPeak.CompareAndSwap(peak, used) {
+			break
+		}
+	}
+	return used, memoryStats.Sys, usedMemoryPeak.Load()
+}
+
+// formatMemoryInfoSection renders the memory section of INFO.
+func formatMemoryInfoSection() string {
+	used, rss, peak := memoryUsage()
+
+	return formatInfoFields("Memory",
+		"used_memory", fmt.Sprint(used),
+		"used_memory_human", formatHumanBytes(used),
+		"used_memory_rss", fmt.Sprint(rss),
+		"used_memory_rss_human", formatHumanBytes(rss),
+		"used_memory_peak", fmt.Sprint(peak),
+		"used_memory_peak_human", formatHumanBytes(peak),
+		"maxmemory", "0",
+		"maxmemory_human", "0B",
+		"maxmemory_policy", "noeviction",
+		"mem_allocator", "go",
+	)
+}
+
+// formatHumanBytes renders a byte count the way Redis's *_human fields do.
+func formatHumanBytes(bytes uint64) string {
+	units := []string{"K", "M", "G", "T", "P"}
+	if bytes < 1024 {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	value := float64(bytes) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.2f%s", value, units[unit])
+}
+
+// formatPersistenceInfoSection renders the persistence section of INFO.
+func formatPersistenceInfoSection() string {
+	return formatInfoFields("Persistence",
+		"loading", "0",
+		"rdb_changes_since_last_save", fmt.Sprint(changesSinceLastSave.Load()),
+		"rdb_bgsave_in_progress", "0",
+		"rdb_last_save_time", fmt.Sprint(lastSaveTime.Load()),
+		"rdb_last_bgsave_status", "ok",
+		"aof_enabled", "0",
+	)
+}
+
+// formatStatsInfoSection renders the stats section of INFO.
+func formatStatsInfoSection() string {
+	return formatInfoFields("Stats",
+		"total_connections_received", fmt.Sprint(totalConnectionsReceived.Load()),
+		"total_commands_processed", fmt.Sprint(totalCommandsProcessed.Load()),
+		"rejected_connections", fmt.Sprint(rejectedConnections.Load()),
+		"keyspace_hits", fmt.Sprint(keyspaceHits.Load()),
+		"keyspace_misses", fmt.Sprint(keyspaceMisses.Load()),
+		"pubsub_channels", fmt.Sprint(activeChannelCount()),
+		"client_output_buffer_limit_disconnections", fmt.Sprint(outputBufferLimitDisconnections.Load()),
+		"total_error_replies", fmt.Sprint(totalErrorReplies.Load()),
+	)
+}
+
+// formatReplicationInfoSection renders the replication section of INFO. A
+// master lists its replicas with the offset each last acknowledged and the
+// seconds since that acknowledgement; a replica describes its master link.
+func formatReplicationInfoSection() string {
+	config := GetConfig()
+
 	if config.IsReplica {
-		role = "slave"
+		link := masterLinkSnapshot()
+		linkStatus, lastIO := "down", "-1"
+		if link.up {
+			linkStatus = "up"
+			lastIO = fmt.Sprint(int64(time.Since(link.lastInteraction).Seconds()))
+		}
+
+		return formatInfoFields("Replication",
+			"role", "slave",
+			"master_host", config.MasterHost,
+			"master_port", config.MasterPort,
+			"master_link_status", linkStatus,
+			"master_last_io_seconds_ago", lastIO,
+			"master_sync_in_progress", "0",
+			"slave_repl_offset", fmt.Sprint(link.processedOffset),
+			"connected_slaves", "0",
+			"master_replid", config.MasterReplId,
+			"master_repl_offset", fmt.Sprint(link.processedOffset),
+		)
+	}
+
+	replicaStates := ReplicasSnapshot()
+	fields := []string{"role", "master", "connected_slaves", fmt.Sprint(len(replicaStates))}
+	for index, replica := range replicaStates {
+		host, port := replicaEndpoint(replica)
+		lag := int64(time.Since(replica.lastAcknowledgedAt).Seconds())
+
+		fields = append(fields, fmt.Sprintf("slave%d", index),
+			fmt.Sprintf("ip=%s,port=%d,state=online,offset=%d,lag=%d", host, port, replica.lastAcknowledgedOffset, lag))
+	}
+	fields = append(fields,
+		"master_replid", config.MasterReplId,
+		"master_repl_offset", fmt.Sprint(CurrentMasterReplicationOffset()),
+	)
+
+	return formatInfoFields("Replication", fields...)
+}
+
+// replicaEndpoint returns the host a replica connects from and the port it
+// announced with REPLCONF listening-port.
+func replicaEndpoint(replica ReplicaState) (host string, port int) {
+	host, _, err := net.SplitHostPort(clientAddress(replica.connection))
+	if err != nil {
+		host = clientAddress(replica.connection)
+	}
+	return host, snapshotClient(replica.connection).ReplicaListeningPort
+}
+
+// formatCommandStatsInfoSection renders one line per command that has been
+// called or rejected since the last CONFIG RESETSTAT.
+func formatCommandStatsInfoSection() string {
+	stats := commandStatsSnapshot()
+	names := make([]string, 0, len(stats))
+	byName := make(map[string]commandStats, len(stats))
+	for commandType, commandStats := range stats {
+		name := commandType.String()
+		if spec := LookupCommandSpec(commandType); spec != nil {
+			name = spec.Name
+		}
+		names = append(names, name)
+		byName[name] = commandStats
+	}
+	sort.Strings(names)
+
+	fields := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		commandStats := byName[name]
+		perCall := 0.0
+		if commandStats.calls > 0 {
+			perCall = float64(commandStats.microseconds) / float64(commandStats.calls)
+		}
+		fields = append(fields, "cmdstat_"+name, fmt.Sprintf("calls=%d,usec=%d,usec_per_call=%.2f,rejected_calls=%d,failed_calls=%d",
+			commandStats.calls, commandStats.microseconds, perCall, commandStats.rejectedCalls, commandStats.failedCalls))
+	}
+
+	return formatInfoFields("Commandstats", fields...)
+}
+
+// formatKeyspaceInfoSection renders the keyspace section of INFO; the
+// database is listed only while it holds keys.
+func formatKeyspaceInfoSection() string {
+	keys, expires := GetInstance().KeyspaceCounts()
+	if keys == 0 {
+		return formatInfoFields("Keyspace")
+	}
+
+	return formatInfoFields("Keyspace", "db0", fmt.Sprintf("keys=%d,expires=%d,avg_ttl=0", keys, expires))
+}
+
+// HandleInfo processes an INFO command and returns a RESP bulk string
+// response. Without arguments it returns the default sections; "all" and
+// "everything" return every section, "default" the default ones, and any
+// other argument names a section. Unknown sections are ignored.
+func HandleInfo(cmd *RedisCommand) Reply {
+	selected := make(map[string]bool)
+	if len(cmd.Args) == 0 {
+		selected["default"] = true
+	}
+	for _, argument := range cmd.Args {
+		selected[strings.ToLower(argument)] = true
 	}
 
-	response := fmt.Sprintf("role:%s\nmaster_replid:%s\nmaster_repl_offset:%d",
-		role, config.MasterReplId, config.MasterReplOffset)
+	all := selected["all"] || selected["everything"]
+	sections := make([]string, 0, len(infoSections))
+	for _, section := range infoSections {
+		if all || selected[section.name] || (selected["default"] && section.inDefault) {
+			sections = append(sections, section.format())
+		}
+	}
+
+	return BulkStringReply(strings.Join(sections, "\r\n"))
+}
+
+// latencyStatsPercentiles are the percentiles INFO latencystats reports, as
+// Redis's default latency-tracking-info-percentiles.
+var latencyStatsPercentiles = []float64{50, 99, 99.9}
+
+func formatLatencyStatsInfoSection() string {
+	stats := commandStatsSnapshot()
+	names := make([]string, 0, len(stats))
+	byName := make(map[string]commandStats, len(stats))
+	for commandType, commandStats := range stats {
+		spec := LookupCommandSpec(commandType)
+		if spec == nil || commandStats.calls == 0 {
+			continue
+		}
+		names = append(names, spec.Name)
+		byName[spec.Name] = commandStats
+	}
+	sort.Strings(names)
+
+	fields := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		commandStats := byName[name]
+		percentiles := make([]string, 0, len(latencyStatsPercentiles))
+		for _, percentile := range latencyStatsPercentiles {
+			percentiles = append(percentiles, fmt.Sprintf("p%s=%.3f",
+				strconv.FormatFloat(percentile, 'f', -1, 64), float64(commandStats.latency.percentile(percentile))))
+		}
+		fields = append(fields, "latency_percentiles_usec_"+name, strings.Join(percentiles, ","))
+	}
 
-	// Format as RESP bulk string: $<length>\r\n<data>\r\n
-	return fmt.Sprintf("$%d\r\n%s\r\n", len(response), response)
+	return formatInfoFields("Latencystats", fields...)
 }
@@ -1,92 +1,220 @@
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
-func TestHandleInfo(t *testing.T) {
-	// Save original config
+func TestHandleInfoReplicationSection(t *testing.T) {
 	originalConfig := serverConfig
-	defer func() { serverConfig = originalConfig }() // Restore config after test
+	defer func() { serverConfig = originalConfig }()
+	resetReplicationStateForTest()
 
 	tests := []struct {
 		name     string
 		setup    func()
-		cmd      *RedisCommand
+		args     []string
 		expected string
 	}{
 		{
-			name: "info command without arguments - master",
+			name: "master",
 			setup: func() {
-				serverConfig.IsReplica = false
-				serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-				serverConfig.MasterReplOffset = 0
+				serverConfig = Config{MasterReplId: "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"}
 			},
-			cmd: &RedisCommand{
-				Type: CmdINFO,
-				Args: []string{},
-			},
-			expected: "$87\r\nrole:master\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nmaster_repl_offset:0\r\n",
+			args: []string{"replication"},
+			expected: "# Replication\r\nrole:master\r\nconnected_slaves:0\r\n" +
+				"master_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\nmaster_repl_offset:0\r\n",
 		},
 		{
-			name: "info command with replication argument - master",
+			name: "replica with its master link down",
 			setup: func() {
-				serverConfig.IsReplica = false
-				serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-				serverConfig.MasterReplOffset = 0
-			},
-			cmd: &RedisCommand{
-				Type: CmdINFO,
-				Args: []string{"replication"},
-			},
-			expected: "$87\r\nrole:master\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nmaster_repl_offset:0\r\n",
-		},
-		{
-			name: "info command with multiple arguments - master",
-			setup: func() {
-				serverConfig.IsReplica = false
-				serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-				serverConfig.MasterReplOffset = 0
-			},
-			cmd: &RedisCommand{
-				Type: CmdINFO,
-				Args: []string{"replication", "extra"},
-			},
-			expected: "$87\r\nrole:master\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nmaster_repl_offset:0\r\n",
-		},
-		{
-			name: "info command without arguments - replica",
-			setup: func() {
-				serverConfig.IsReplica = true
-				serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-				serverConfig.MasterReplOffset = 0
-			},
-			cmd: &RedisCommand{
-				Type: CmdINFO,
-				Args: []string{},
-			},
-			expected: "$86\r\nrole:slave\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nmaster_repl_offset:0\r\n",
-		},
-		{
-			name: "info command with replication argument - replica",
-			setup: func() {
-				serverConfig.IsReplica = true
-				serverConfig.MasterReplId = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
-				serverConfig.MasterReplOffset = 0
-			},
-			cmd: &RedisCommand{
-				Type: CmdINFO,
-				Args: []string{"replication"},
+				serverConfig = Config{IsReplica: true, MasterHost: "localhost", MasterPort: "6380", MasterReplId: "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"}
 			},
-			expected: "$86\r\nrole:slave\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nmaster_repl_offset:0\r\n",
+			args: []string{"REPLICATION", "nosuchsection"},
+			expected: "# Replication\r\nrole:slave\r\nmaster_host:localhost\r\nmaster_port:6380\r\nmaster_link_status:down\r\n" +
+				"master_last_io_seconds_ago:-1\r\nmaster_sync_in_progress:0\r\nslave_repl_offset:0\r\nconnected_slaves:0\r\n" +
+				"master_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\nmaster_repl_offset:0\r\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
-			result := HandleInfo(tt.cmd)
+			result := HandleInfo(&RedisCommand{Type: CmdINFO, Args: tt.args}).Text
 			if result != tt.expected {
 				t.Errorf("HandleInfo() = %q, expected %q", result, tt.expected)
 			}
 		})
 	}
 }
+
+func TestHandleInfoReportsReplicaOffsets(t *testing.T) {
+	resetClientTestState(t)
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	HandleReplconf(replicaConnection, &RedisCommand{Type: CmdREPLCONF, Args: []string{"listening-port", "6381"}})
+	RegisterReplica(replicaConnection)
+	UpdateReplicaAcknowledgementOffset(replicaConnection, 42)
+	RecordPropagatedReplicationBytes(100)
+
+	info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"replication"}}).Text
+	for _, field := range []string{"connected_slaves:1\r\n", ",port=6381,state=online,offset=42,lag=0\r\n", "master_repl_offset:100\r\n"} {
+		if !strings.Contains(info, field) {
+			t.Errorf("INFO replication = %q, expected it to contain %q", info, field)
+		}
+	}
+}
+
+func TestHandleInfoForgetsDisconnectedReplicas(t *testing.T) {
+	resetClientTestState(t)
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	RegisterReplica(replicaConnection)
+
+	if info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"replication"}}).Text; !strings.Contains(info, "connected_slaves:1\r\n") {
+		t.Fatalf("INFO replication = %q, expected the replica to be connected", info)
+	}
+
+	disconnectThroughEventReactor(t, replicaConnection)
+
+	info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"replication"}}).Text
+	if !strings.Contains(info, "connected_slaves:0\r\n") || strings.Contains(info, "slave0:") {
+		t.Errorf("INFO replication = %q, expected no connected replicas", info)
+	}
+}
+
+func TestKeyspaceHitsCountReadsOfEveryType(t *testing.T) {
+	resetTransactionTestState(t)
+	ResetServerStats()
+	t.Cleanup(ResetServerStats)
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdRPUSH, Args: []string{"queue", "job"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdZADD, Args: []string{"scores", "1", "alice"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdXADD, Args: []string{"events", "1-1", "field", "value"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdINCR, Args: []string{"counter"}})
+	GetInstance().SetWithExpiry("stale", "v", time.Now().UnixMilli()-1000)
+
+	for _, command := range []*RedisCommand{
+		{Type: CmdLRANGE, Args: []string{"queue", "0", "-1"}},
+		{Type: CmdLLEN, Args: []string{"queue"}},
+		{Type: CmdZCARD, Args: []string{"scores"}},
+		{Type: CmdXRANGE, Args: []string{"events", "-", "+"}},
+		{Type: CmdTYPE, Args: []string{"counter"}},
+		{Type: CmdLLEN, Args: []string{"nosuchlist"}},
+		{Type: CmdGET, Args: []string{"stale"}},
+		// An invalid range returns before the stream is looked up.
+		{Type: CmdXRANGE, Args: []string{"events", "bogus", "+"}},
+	} {
+		HandleConnectionCommand(connection, command)
+	}
+
+	if hits, misses := keyspaceHits.Load(), keyspaceMisses.Load(); hits != 5 || misses != 2 {
+		t.Errorf("keyspace hits = %d, misses = %d; expected 5 and 2", hits, misses)
+	}
+}
+
+func TestHandleInfoSectionSelection(t *testing.T) {
+	headers := func(info string) []string {
+		var found []string
+		for _, line := range strings.Split(info, "\r\n") {
+			if strings.HasPrefix(line, "# ") {
+				found = append(found, strings.TrimPrefix(line, "# "))
+			}
+		}
+		return found
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "Server Clients Memory Persistence Stats Replication Keyspace"},
+		{[]string{"default"}, "Server Clients Memory Persistence Stats Replication Keyspace"},
+		{[]string{"all"}, "Server Clients Memory Persistence Stats Replication Commandstats Latencystats Keyspace"},
+		{[]string{"everything"}, "Server Clients Memory Persistence Stats Replication Commandstats Latencystats Keyspace"},
+		{[]string{"keyspace", "memory"}, "Memory Keyspace"},
+		{[]string{"nosuchsection"}, ""},
+	}
+
+	for _, test := range tests {
+		info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: test.args}).Text
+		if got := strings.Join(headers(info), " "); got != test.expected {
+			t.Errorf("INFO %v sections = %q, expected %q", test.args, got, test.expected)
+		}
+	}
+}
+
+func TestInfoCountsCommandsAndKeyspace(t *testing.T) {
+	resetTransactionTestState(t)
+	ResetServerStats()
+	t.Cleanup(ResetServerStats)
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"present", "v"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"expiring", "v", "PX", "100000"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"present"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"missing"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"a", "b"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdINCR, Args: []string{"present"}})
+
+	info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"stats", "commandstats", "keyspace"}}).Text
+	for _, field := range []string{
+		"total_commands_processed:5\r\n",
+		"keyspace_hits:1\r\n",
+		"keyspace_misses:1\r\n",
+		"total_error_replies:2\r\n",
+		"cmdstat_get:calls=2,usec=",
+		",rejected_calls=1,failed_calls=0\r\n",
+		"cmdstat_incr:calls=1,",
+		",rejected_calls=0,failed_calls=1\r\n",
+		"db0:keys=2,expires=1,avg_ttl=0\r\n",
+	} {
+		if !strings.Contains(info, field) {
+			t.Errorf("INFO = %q, expected it to contain %q", info, field)
+		}
+	}
+
+	ResetServerStats()
+	if info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"commandstats"}}).Text; info != "# Commandstats\r\n" {
+		t.Errorf("INFO commandstats after reset = %q, expected no commands", info)
+	}
+}
+
+func TestHandleInfoServerReportsListeners(t *testing.T) {
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig = Config{Port: 6380, UnixSocket: "/tmp/redis.sock"}
+
+	result := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"server"}}).String()
+	for _, field := range []string{"tcp_port:6380\r\n", "unixsocket:/tmp/redis.sock\r\n"} {
+		if !strings.Contains(result, field) {
+			t.Errorf("INFO server = %q, expected it to contain %q", result, field)
+		}
+	}
+}
+
+func TestInfoStatsWhileClientsSubscribe(t *testing.T) {
+	resetClientTestState(t)
+	subscriberConnection, subscriberPeer := testConnectionPair(t)
+	startDrainConnection(subscriberPeer)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 200; index++ {
+			HandleSubscribe(subscriberConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+			HandleUnsubscribe(subscriberConnection, &RedisCommand{Type: CmdUNSUBSCRIBE, Args: []string{"news"}})
+		}
+	}()
+
+	for index := 0; index < 200; index++ {
+		HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"stats"}})
+	}
+	<-done
+
+	if info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"stats"}}).Text; !strings.Contains(info, "pubsub_channels:0\r\n") {
+		t.Errorf("INFO stats = %q, expected no active channels", info)
+	}
+}
@@ -1,13 +1,8 @@
 package main
 
-import (
-	"fmt"
-	"strings"
-)
-
-func HandleKeys(cmd *RedisCommand) string {
+func HandleKeys(cmd *RedisCommand) Reply {
 	if len(cmd.Args) == 0 {
-		return "-ERR wrong number of arguments for 'keys' command\r\n"
+		return WrongNumberOfArgumentsReply("keys")
 	}
 
 	pattern := cmd.Args[0]
@@ -22,12 +17,5 @@ func HandleKeys(cmd *RedisCommand) string {
 		// For other patterns, we could implement a basic glob matcher
 	}
 
-	// Format as RESP Array
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("*%d\r\n", len(matchedKeys)))
-	for _, key := range matchedKeys {
-		sb.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(key), key))
-	}
-
-	return sb.String()
+	return BulkStringArrayReply(matchedKeys)
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyHistorySize is how many samples each event keeps, like Redis's
+// LATENCY_TS_LEN.
+const latencyHistorySize = 160
+
+// Latency events sampled when they take at least latency-monitor-threshold
+// milliseconds.
+const (
+	latencyEventCommand     = "command"
+	latencyEventFastCommand = "fast-command"
+	latencyEventExpireCycle = "expire-cycle"
+	latencyEventRDBSave     = "rdb-save"
+	latencyEventRDBFsync    = "rdb-fsync"
+)
+
+// latencySample is the worst latency an event had within one second.
+type latencySample struct {
+	timestamp    int64
+	milliseconds int64
+}
+
+// latencyEventSeries is the history of one event, oldest sample first.
+type latencyEventSeries struct {
+	samples []latencySample
+	max     int64
+}
+
+var (
+	latencyMutex  sync.Mutex
+	latencyEvents = make(map[string]*latencyEventSeries)
+)
+
+// recordLatencyEvent samples event when it took at least
+// latency-monitor-threshold milliseconds. A threshold of 0 disables the
+// latency monitor.
+func recordLatencyEvent(event string, duration time.Duration) {
+	threshold := GetConfig().LatencyMonitorThreshold
+	milliseconds := duration.Milliseconds()
+	if threshold <= 0 || milliseconds < threshold {
+		return
+	}
+
+	addLatencySample(event, time.Now().Unix(), milliseconds)
+}
+
+// addLatencySample stores a sample, merging it with the latest one when
+// both fall in the same second.
+func addLatencySample(event string, timestamp int64, milliseconds int64) {
+	latencyMutex.Lock()
+	defer latencyMutex.Unlock()
+
+	series, exists := latencyEvents[event]
+	if !exists {
+		series = &latencyEventSeries{}
+		latencyEvents[event] = series
+	}
+	if milliseconds > series.max {
+		series.max = milliseconds
+	}
+
+	if last := len(series.samples) - 1; last >= 0 && series.samples[last].timestamp == timestamp {
+		if milliseconds > series.samples[last].milliseconds {
+			series.samples[last].milliseconds = milliseconds
+		}
+		return
+	}
+
+	series.samples = append(series.samples, latencySample{timestamp: timestamp, milliseconds: milliseconds})
+	if len(series.samples) > latencyHistorySize {
+		series.samples = series.samples[len(series.samples)-latencyHistorySize:]
+	}
+}
+
+// recordCommandLatency samples an executed command as a command or
+// fast-command event. Commands that may block are skipped.
+func recordCommandLatency(spec *CommandSpec, duration time.Duration) {
+	if spec.MayBlock() {
+		return
+	}
+
+	if spec.HasFlag(FlagFast) {
+		recordLatencyEvent(latencyEventFastCommand, duration)
+	} else {
+		recordLatencyEvent(latencyEventCommand, duration)
+	}
+}
+
+// latencyEventSnapshot is one event as LATENCY LATEST reports it.
+type latencyEventSnapshot struct {
+	name    string
+	samples []latencySample
+	max     int64
+}
+
+// latencyEventsSnapshot returns a copy of every event's history ordered by
+// event name.
+func latencyEventsSnapshot() []latencyEventSnapshot {
+	latencyMutex.Lock()
+	defer latencyMutex.Unlock()
+
+	snapshots := make([]latencyEventSnapshot, 0, len(latencyEvents))
+	for name, series := range latencyEvents {
+		snapshots = append(snapshots, latencyEventSnapshot{
+			name:    name,
+			samples: append([]latencySample(nil), series.samples...),
+			max:     series.max,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].name < snapshots[j].name
+	})
+
+	return snapshots
+}
+
+// latencyEventHistory returns a copy of the samples of one event.
+func latencyEventHistory(event string) []latencySample {
+	latencyMutex.Lock()
+	defer latencyMutex.Unlock()
+
+	series, exists := latencyEvents[event]
+	if !exists {
+		return nil
+	}
+	return append([]latencySample(nil), series.samples...)
+}
+
+// ResetLatencyEvents drops the history of the named events, or of every
+// event when none are named, and returns how many were dropped.
+func ResetLatencyEvents(events ...string) int {
+	latencyMutex.Lock()
+	defer latencyMutex.Unlock()
+
+	if len(events) == 0 {
+		reset := len(latencyEvents)
+		latencyEvents = make(map[string]*latencyEventSeries)
+		return reset
+	}
+
+	reset := 0
+	for _, event := range events {
+		if _, exists := latencyEvents[event]; exists {
+			delete(latencyEvents, event)
+			reset++
+		}
+	}
+	return reset
+}
+
+// latencyHistogramBuckets covers durations up to 2^39 microseconds, more
+// than six days.
+const latencyHistogramBuckets = 40
+
+// latencyHistogram counts command durations in power-of-two microsecond
+// buckets: bucket i holds durations up to 2^i microseconds.
+type latencyHistogram [latencyHistogramBuckets]int64
+
+func (histogram *latencyHistogram) record(duration time.Duration) {
+	microseconds := duration.Microseconds()
+	bucket := 0
+	if microseconds > 1 {
+		bucket = bits.Len64(uint64(microseconds - 1))
+	}
+	if bucket >= latencyHistogramBuckets {
+		bucket = latencyHistogramBuckets - 1
+	}
+
+	histogram[bucket]++
+}
+
+// cumulative returns, for every bucket that holds samples, its upper bound
+// in microseconds and how many samples are at or below it.
+func (histogram *latencyHistogram) cumulative() (bounds []int64, counts []int64) {
+	total := int64(0)
+	for bucket, count := range histogram {
+		if count == 0 {
+			continue
+		}
+		total += count
+		bounds = append(bounds, int64(1)<<bucket)
+		counts = append(counts, total)
+	}
+
+	return bounds, counts
+}
+
+// percentile returns the upper bound in microseconds of the bucket holding
+// the given percentile, or 0 without samples.
+func (histogram *latencyHistogram) percentile(percentile float64) int64 {
+	bounds, counts := histogram.cumulative()
+	if len(counts) == 0 {
+		return 0
+	}
+
+	target := percentile / 100 * float64(counts[len(counts)-1])
+	for index, count := range counts {
+		if float64(count) >= target {
+			return bounds[index]
+		}
+	}
+	return bounds[len(bounds)-1]
+}
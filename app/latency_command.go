@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+func parseLatencyCommandArguments(command *RedisCommand) (subcommand string, args []string, errorResponse Reply) {
+	if len(command.Args) == 0 {
+		return "", nil, WrongNumberOfArgumentsReply("latency")
+	}
+
+	subcommand = strings.ToUpper(command.Args[0])
+	args = command.Args[1:]
+	switch subcommand {
+	case "LATEST":
+		if len(args) != 0 {
+			return "", nil, WrongNumberOfArgumentsReply("latency|latest")
+		}
+	case "HISTORY":
+		if len(args) != 1 {
+			return "", nil, WrongNumberOfArgumentsReply("latency|history")
+		}
+	case "RESET", "HISTOGRAM":
+	default:
+		return "", nil, ErrorReply(fmt.Sprintf("ERR unknown subcommand '%s'. Try LATENCY HELP.", command.Args[0]))
+	}
+
+	return subcommand, args, Reply{}
+}
+
+// HandleLatency implements LATENCY LATEST, HISTORY, RESET and HISTOGRAM.
+func HandleLatency(connection net.Conn, command *RedisCommand) Reply {
+	subcommand, args, errorResponse := parseLatencyCommandArguments(command)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	switch subcommand {
+	case "LATEST":
+		return latencyLatestReply()
+	case "HISTORY":
+		return latencyHistoryReply(args[0])
+	case "RESET":
+		return IntegerReply(int64(ResetLatencyEvents(args...)))
+	default:
+		return latencyHistogramReply(args)
+	}
+}
+
+// latencyLatestReply lists every event as [name, timestamp of the latest
+// sample, its latency, the highest latency seen].
+func latencyLatestReply() Reply {
+	events := latencyEventsSnapshot()
+	replies := make([]Reply, 0, len(events))
+	for _, event := range events {
+		latest := event.samples[len(event.samples)-1]
+		replies = append(replies, ArrayReply(
+			BulkStringReply(event.name),
+			IntegerReply(latest.timestamp),
+			IntegerReply(latest.milliseconds),
+			IntegerReply(event.max),
+		))
+	}
+
+	return ArrayReply(replies...)
+}
+
+// latencyHistoryReply lists the samples of one event as [timestamp,
+// latency] pairs, oldest first.
+func latencyHistoryReply(event string) Reply {
+	samples := latencyEventHistory(event)
+	replies := make([]Reply, 0, len(samples))
+	for _, sample := range samples {
+		replies = append(replies, ArrayReply(IntegerReply(sample.timestamp), IntegerReply(sample.milliseconds)))
+	}
+
+	return ArrayReply(replies...)
+}
+
+// latencyHistogramReply maps each requested command, or every command that
+// has been called when none are named, to its call count and cumulative
+// latency histogram. Unknown commands and commands never called are left
+// out.
+func latencyHistogramReply(names []string) Reply {
+	stats := commandStatsSnapshot()
+
+	var types []CommandType
+	if len(names) == 0 {
+		for commandType := range stats {
+			types = append(types, commandType)
+		}
+	} else {
+		for _, name := range names {
+			if spec := LookupCommandSpecByName(name); spec != nil {
+				types = append(types, spec.Type)
+			}
+		}
+	}
+
+	byName := make(map[string]commandStats, len(types))
+	for _, commandType := range types {
+		if commandStats, exists := stats[commandType]; exists && commandStats.calls > 0 {
+			byName[LookupCommandSpec(commandType).Name] = commandStats
+		}
+	}
+	names = make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	replies := make([]Reply, 0, 2*len(names))
+	for _, name := range names {
+		commandStats := byName[name]
+		bounds, counts := commandStats.latency.cumulative()
+		buckets := make([]Reply, 0, 2*len(bounds))
+		for index := range bounds {
+			buckets = append(buckets, IntegerReply(bounds[index]), IntegerReply(counts[index]))
+		}
+
+		replies = append(replies, BulkStringReply(name), MapReply(
+			BulkStringReply("calls"), IntegerReply(commandStats.calls),
+			BulkStringReply("histogram_usec"), MapReply(buckets...),
+		))
+	}
+
+	return MapReply(replies...)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetLatencyTestState(t *testing.T) {
+	t.Helper()
+	resetConfigTestState(t)
+	resetTransactionTestState(t)
+	ResetLatencyEvents()
+	ResetServerStats()
+	t.Cleanup(func() {
+		ResetLatencyEvents()
+		ResetServerStats()
+	})
+}
+
+func latencyCommand(args ...string) Reply {
+	return HandleLatency(nil, &RedisCommand{Type: CmdLATENCY, Args: args})
+}
+
+func TestLatencyEventsRespectThreshold(t *testing.T) {
+	resetLatencyTestState(t)
+
+	recordLatencyEvent(latencyEventCommand, time.Second)
+	if reply := latencyCommand("LATEST").String(); reply != "*0\r\n" {
+		t.Fatalf("LATENCY LATEST with the monitor disabled = %q, expected no events", reply)
+	}
+
+	serverConfig.LatencyMonitorThreshold = 100
+	recordLatencyEvent(latencyEventCommand, 50*time.Millisecond)
+	recordLatencyEvent(latencyEventRDBSave, 150*time.Millisecond)
+
+	events := latencyEventsSnapshot()
+	if len(events) != 1 || events[0].name != latencyEventRDBSave || events[0].max != 150 {
+		t.Errorf("events = %+v, expected only rdb-save at 150ms", events)
+	}
+}
+
+func TestLatencyHistoryMergesSamplesPerSecondAndIsBounded(t *testing.T) {
+	resetLatencyTestState(t)
+
+	addLatencySample("test", 1000, 10)
+	addLatencySample("test", 1000, 30)
+	addLatencySample("test", 1000, 20)
+	addLatencySample("test", 1001, 5)
+
+	if reply := latencyCommand("HISTORY", "test").String(); reply != "*2\r\n*2\r\n:1000\r\n:30\r\n*2\r\n:1001\r\n:5\r\n" {
+		t.Errorf("LATENCY HISTORY = %q, expected the worst sample of each second", reply)
+	}
+	if reply := latencyCommand("LATEST").String(); reply != "*1\r\n*4\r\n$4\r\ntest\r\n:1001\r\n:5\r\n:30\r\n" {
+		t.Errorf("LATENCY LATEST = %q, expected the latest sample and the maximum", reply)
+	}
+
+	for second := int64(0); second < latencyHistorySize+10; second++ {
+		addLatencySample("bounded", 2000+second, 1)
+	}
+	history := latencyEventHistory("bounded")
+	if len(history) != latencyHistorySize || history[0].timestamp != 2010 {
+		t.Errorf("history has %d samples starting at %d, expected %d starting at 2010", len(history), history[0].timestamp, latencyHistorySize)
+	}
+
+	if reply := latencyCommand("RESET", "test", "missing").String(); reply != ":1\r\n" {
+		t.Errorf("LATENCY RESET test missing = %q, expected :1", reply)
+	}
+	if reply := latencyCommand("RESET").String(); reply != ":1\r\n" {
+		t.Errorf("LATENCY RESET = %q, expected :1", reply)
+	}
+}
+
+func TestLatencyHistogramBuckets(t *testing.T) {
+	var histogram latencyHistogram
+	for _, duration := range []time.Duration{0, time.Microsecond, 3 * time.Microsecond, 4 * time.Microsecond, 100 * time.Microsecond} {
+		histogram.record(duration)
+	}
+
+	bounds, counts := histogram.cumulative()
+	if got, expected := formatInt64Pairs(bounds, counts), "1:2 4:4 128:5"; got != expected {
+		t.Errorf("cumulative() = %s, expected %s", got, expected)
+	}
+	if p50, p99 := histogram.percentile(50), histogram.percentile(99); p50 != 4 || p99 != 128 {
+		t.Errorf("p50 = %d, p99 = %d; expected 4 and 128", p50, p99)
+	}
+}
+
+func formatInt64Pairs(keys []int64, values []int64) string {
+	pairs := make([]string, 0, len(keys))
+	for index := range keys {
+		pairs = append(pairs, fmt.Sprintf("%d:%d", keys[index], values[index]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func TestLatencyHistogramReportsCalledCommands(t *testing.T) {
+	resetLatencyTestState(t)
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"key", "value"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"key", "value"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdECHO, Args: []string{"hello"}})
+
+	reply := latencyCommand("HISTOGRAM", "set", "get", "nosuchcommand").String()
+	if !strings.HasPrefix(reply, "*2\r\n$3\r\nset\r\n*4\r\n$5\r\ncalls\r\n:2\r\n$14\r\nhistogram_usec\r\n") {
+		t.Errorf("LATENCY HISTOGRAM set get = %q, expected only set with 2 calls", reply)
+	}
+
+	if reply := latencyCommand("HISTOGRAM").String(); !strings.HasPrefix(reply, "*4\r\n$4\r\necho\r\n") {
+		t.Errorf("LATENCY HISTOGRAM = %q, expected echo and set", reply)
+	}
+
+	info := HandleInfo(&RedisCommand{Type: CmdINFO, Args: []string{"latencystats"}}).Text
+	if !strings.Contains(info, "latency_percentiles_usec_set:p50=") || !strings.Contains(info, ",p99.9=") {
+		t.Errorf("INFO latencystats = %q, expected percentiles for set", info)
+	}
+}
+
+func TestBlockingCommandTimeIsNotCountedAsExecution(t *testing.T) {
+	resetLatencyTestState(t)
+
+	recordCommandCall(LookupCommandSpec(CmdBLPOP), time.Second, Reply{})
+	stats := commandStatsSnapshot()[CmdBLPOP]
+	if _, counts := stats.latency.cumulative(); stats.calls != 1 || stats.microseconds != 0 || len(counts) != 0 {
+		t.Errorf("BLPOP calls, usec, histogram = %d, %d, %v; expected the call without its blocked time", stats.calls, stats.microseconds, counts)
+	}
+}
+
+func TestLatencyRejectsInvalidArguments(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"HISTORY"}, "-ERR wrong number of arguments for 'latency|history' command\r\n"},
+		{[]string{"LATEST", "x"}, "-ERR wrong number of arguments for 'latency|latest' command\r\n"},
+		{[]string{"FOO"}, "-ERR unknown subcommand 'FOO'. Try LATENCY HELP.\r\n"},
+	} {
+		if result := latencyCommand(test.args...).String(); result != test.expected {
+			t.Errorf("LATENCY %v = %q, expected %q", test.args, result, test.expected)
+		}
+	}
+}
+
+func TestActiveExpireCycleDeletesExpiredKeys(t *testing.T) {
+	resetTransactionTestState(t)
+	cache := GetInstance()
+	now := time.Now().UnixMilli()
+	for _, key := range []string{"a", "b", "c"} {
+		cache.SetWithExpiry(key, "v", now-1000)
+	}
+	cache.SetWithExpiry("live", "v", now+100000)
+	cache.Set("persistent", "v", nil)
+
+	if deleted := activeExpireCycle(cache); len(deleted) != 3 {
+		t.Errorf("activeExpireCycle() deleted %q, expected 3 keys", deleted)
+	}
+	if keys, expires := cache.KeyspaceCounts(); keys != 2 || expires != 1 || len(cache.cache) != 2 {
+		t.Errorf("after the cycle keys = %d, expires = %d, stored = %d; expected 2, 1, 2", keys, expires, len(cache.cache))
+	}
+}
+
+func TestActiveExpireCycleSamplesOnlyKeysWithAnExpiry(t *testing.T) {
+	resetTransactionTestState(t)
+	cache := GetInstance()
+	for index := 0; index < 1000; index++ {
+		cache.Set(fmt.Sprint("persistent-", index), "v", nil)
+	}
+	cache.SetWithExpiry("stale", "v", CurrentTimeMilliseconds()-1000)
+
+	if sampled, expired := cache.DeleteExpiredSample(activeExpireKeysPerLoop, CurrentTimeMilliseconds()); sampled != 1 || len(expired) != 1 || expired[0] != "stale" {
+		t.Errorf("DeleteExpiredSample() = %d, %q; expected to sample and delete only stale", sampled, expired)
+	}
+	if len(cache.expires) != 0 {
+		t.Errorf("expires index = %v, expected it to be empty", cache.expires)
+	}
+}
+
+func TestActiveExpireCyclePropagatesDeletes(t *testing.T) {
+	resetTransactionTestState(t)
+	resetReplicationStateForTest()
+	defer resetReplicationStateForTest()
+
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	RegisterReplica(replicaConnection)
+	GetInstance().SetWithExpiry("stale", "v", CurrentTimeMilliseconds()-1000)
+
+	go runActiveExpireCycle()
+
+	expected := "*2\r\n$3\r\nDEL\r\n$5\r\nstale\r\n"
+	received := make([]byte, len(expected))
+	replicaPeer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(replicaPeer, received); err != nil || string(received) != expected {
+		t.Errorf("replica received %q, %v; expected %q", received, err, expected)
+	}
+}
+
+func TestCacheExpiresKeysByTheServerClock(t *testing.T) {
+	resetTransactionTestState(t)
+	cache := GetInstance()
+	cache.SetWithExpiry("session", "v", 2000)
+
+	withFixedCurrentTimeMilliseconds(1999, func() {
+		if !cache.Exists("session") || cache.Get("session") != "v" {
+			t.Error("session expired before its expiry by the server clock")
+		}
+	})
+	withFixedCurrentTimeMilliseconds(2000, func() {
+		if cache.Get("session") != nil {
+			t.Error("session is still readable at its expiry by the server clock")
+		}
+	})
+}
+
+func TestActiveExpireCycleSamplesExpireCycleLatency(t *testing.T) {
+	resetLatencyTestState(t)
+	GetInstance().SetWithExpiry("stale", "v", time.Now().UnixMilli()-1000)
+
+	// Every clock reading is 20ms after the previous one, so the cycle
+	// appears to take at least that long.
+	now := time.Now().UnixMilli()
+	originalCurrentTimeMilliseconds := CurrentTimeMilliseconds
+	CurrentTimeMilliseconds = func() int64 {
+		now += 20
+		return now
+	}
+	t.Cleanup(func() { CurrentTimeMilliseconds = originalCurrentTimeMilliseconds })
+
+	serverConfig.LatencyMonitorThreshold = 1000
+	runActiveExpireCycle()
+	if events := latencyEventsSnapshot(); len(events) != 0 {
+		t.Fatalf("events = %+v, expected none below the threshold", events)
+	}
+
+	GetInstance().SetWithExpiry("stale", "v", time.Now().UnixMilli()-1000)
+	serverConfig.LatencyMonitorThreshold = 10
+	runActiveExpireCycle()
+	events := latencyEventsSnapshot()
+	if len(events) != 1 || events[0].name != latencyEventExpireCycle || events[0].max < 20 {
+		t.Errorf("events = %+v, expected an expire-cycle sample of at least 20ms", events)
+	}
+	if GetInstance().Exists("stale") {
+		t.Error("the expire cycle did not delete the expired key")
+	}
+}
@@ -19,10 +19,10 @@ func (cache *Cache) PushListRight(listKey string, elements ...string) int {
 	}
 
 	list.Elements = append(list.Elements, elements...)
-	cache.cache[listKey] = CacheItem{
+	cache.storeLocked(listKey, CacheItem{
 		Value:      list,
 		Expiration: 0,
-	}
+	})
 
 	return len(list.Elements)
 }
@@ -45,16 +45,17 @@ func (cache *Cache) PushListLeft(listKey string, elements ...string) int {
 		list.Elements = append([]string{element}, list.Elements...)
 	}
 
-	cache.cache[listKey] = CacheItem{
+	cache.storeLocked(listKey, CacheItem{
 		Value:      list,
 		Expiration: 0,
-	}
+	})
 
 	return len(list.Elements)
 }
 
+// GetList returns the list stored at listKey for a command that reads it.
 func (cache *Cache) GetList(listKey string) *List {
-	value := cache.Get(listKey)
+	value := cache.LookupRead(listKey)
 	if value == nil {
 		return nil
 	}
@@ -69,6 +70,7 @@ func (cache *Cache) GetListLength(listKey string) int {
 
 	var list *List
 	item, exists := cache.cache[listKey]
+	recordKeyspaceLookup(exists)
 	if exists {
 		list, _ = item.Value.(*List)
 	}
@@ -101,10 +103,10 @@ func (cache *Cache) PopListLeft(listKey string, count int) ([]string, bool) {
 	poppedElements := append([]string(nil), list.Elements[:count]...)
 	list.Elements = list.Elements[count:]
 
-	cache.cache[listKey] = CacheItem{
+	cache.storeLocked(listKey, CacheItem{
 		Value:      list,
 		Expiration: 0,
-	}
+	})
 
 	return poppedElements, true
 }
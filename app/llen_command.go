@@ -1,22 +1,20 @@
 package main
 
-import "fmt"
-
-func parseLlenCommandArguments(command *RedisCommand) (listKey string, errorResponse string) {
+func parseLlenCommandArguments(command *RedisCommand) (listKey string, errorResponse Reply) {
 	if len(command.Args) != 1 {
-		return "", "-ERR wrong number of arguments for 'llen' command\r\n"
+		return "", WrongNumberOfArgumentsReply("llen")
 	}
 
-	return command.Args[0], ""
+	return command.Args[0], Reply{}
 }
 
-func HandleLlen(command *RedisCommand) string {
+func HandleLlen(command *RedisCommand) Reply {
 	listKey, errorResponse := parseLlenCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	listLength := GetInstance().GetListLength(listKey)
 
-	return fmt.Sprintf(":%d\r\n", listLength)
+	return IntegerReply(int64(listLength))
 }
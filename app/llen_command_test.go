@@ -38,8 +38,8 @@ func TestParseLlenCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseLlenCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseLlenCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if listKey != testCase.expectedListKey {
@@ -55,7 +55,7 @@ func TestHandleLlenReturnsListLengthAfterRpush(t *testing.T) {
 	rpushResult := HandleRpush(&RedisCommand{
 		Type: CmdRPUSH,
 		Args: []string{"list_key", "a", "b", "c", "d"},
-	})
+	}).String()
 	if rpushResult != ":4\r\n" {
 		t.Fatalf("HandleRpush() = %q, expected %q", rpushResult, ":4\r\n")
 	}
@@ -63,7 +63,7 @@ func TestHandleLlenReturnsListLengthAfterRpush(t *testing.T) {
 	result := HandleLlen(&RedisCommand{
 		Type: CmdLLEN,
 		Args: []string{"list_key"},
-	})
+	}).String()
 
 	if result != ":4\r\n" {
 		t.Errorf("HandleLlen() = %q, expected %q", result, ":4\r\n")
@@ -76,7 +76,7 @@ func TestHandleLlenReturnsListLengthAfterLpush(t *testing.T) {
 	lpushResult := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key", "a", "b", "c"},
-	})
+	}).String()
 	if lpushResult != ":3\r\n" {
 		t.Fatalf("HandleLpush() = %q, expected %q", lpushResult, ":3\r\n")
 	}
@@ -84,7 +84,7 @@ func TestHandleLlenReturnsListLengthAfterLpush(t *testing.T) {
 	result := HandleLlen(&RedisCommand{
 		Type: CmdLLEN,
 		Args: []string{"list_key"},
-	})
+	}).String()
 
 	if result != ":3\r\n" {
 		t.Errorf("HandleLlen() = %q, expected %q", result, ":3\r\n")
@@ -97,7 +97,7 @@ func TestHandleLlenReturnsZeroForMissingList(t *testing.T) {
 	result := HandleLlen(&RedisCommand{
 		Type: CmdLLEN,
 		Args: []string{"missing_list_key"},
-	})
+	}).String()
 
 	if result != ":0\r\n" {
 		t.Errorf("HandleLlen() = %q, expected %q", result, ":0\r\n")
@@ -110,7 +110,7 @@ func TestHandleLlenArgumentParsing(t *testing.T) {
 	result := HandleLlen(&RedisCommand{
 		Type: CmdLLEN,
 		Args: []string{},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'llen' command\r\n" {
 		t.Errorf("HandleLlen() = %q, expected %q", result, "-ERR wrong number of arguments for 'llen' command\r\n")
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel orders log messages by severity. Messages below the configured
+// level are discarded.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelVerbose
+	LogLevelNotice
+	LogLevelWarning
+)
+
+const logTimestampLayout = "02 Jan 2006 15:04:05.000"
+
+var logLevelNames = map[string]LogLevel{
+	"debug":   LogLevelDebug,
+	"verbose": LogLevelVerbose,
+	"notice":  LogLevelNotice,
+	"warning": LogLevelWarning,
+}
+
+// marker returns the character Redis prints between the timestamp and the
+// message for the level.
+func (level LogLevel) marker() byte {
+	switch level {
+	case LogLevelDebug:
+		return '.'
+	case LogLevelVerbose:
+		return '-'
+	case LogLevelNotice:
+		return '*'
+	default:
+		return '#'
+	}
+}
+
+func (level LogLevel) String() string {
+	for name, candidate := range logLevelNames {
+		if candidate == level {
+			return name
+		}
+	}
+
+	return "unknown"
+}
+
+// ParseLogLevel converts a loglevel setting to a LogLevel.
+func ParseLogLevel(name string) (LogLevel, error) {
+	level, exists := logLevelNames[strings.ToLower(name)]
+	if !exists {
+		return 0, fmt.Errorf("invalid loglevel '%s', expected debug, verbose, notice or warning", name)
+	}
+
+	return level, nil
+}
+
+var (
+	logMutex  sync.Mutex
+	logLevel            = LogLevelNotice
+	logOutput io.Writer = os.Stdout
+	logFile   *os.File
+)
+
+// ConfigureLogging sets the minimum level and destination of log messages.
+// An empty logfile logs to standard output.
+func ConfigureLogging(levelName string, logfile string) error {
+	level, err := ParseLogLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stdout
+	var file *os.File
+	if logfile != "" {
+		file, err = os.OpenFile(logfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("can't open the log file: %w", err)
+		}
+		output = file
+	}
+
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	if logFile != nil {
+		logFile.Close()
+	}
+	logLevel = level
+	logOutput = output
+	logFile = file
+
+	return nil
+}
+
+// SetLogLevel changes the minimum level of logged messages.
+func SetLogLevel(levelName string) error {
+	level, err := ParseLogLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	logLevel = level
+	return nil
+}
+
+// SetLogOutputForTest redirects log messages to output at level and returns
+// a function restoring the previous settings.
+func SetLogOutputForTest(output io.Writer, level LogLevel) func() {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	previousOutput, previousLevel := logOutput, logLevel
+	logOutput, logLevel = output, level
+
+	return func() {
+		logMutex.Lock()
+		defer logMutex.Unlock()
+
+		logOutput, logLevel = previousOutput, previousLevel
+	}
+}
+
+// LogEnabled reports whether messages at level are currently written, so
+// callers can skip building expensive messages.
+func LogEnabled(level LogLevel) bool {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	return level >= logLevel
+}
+
+// logRoleMarker returns 'S' on a replica and 'M' on a master.
+func logRoleMarker() byte {
+	if GetConfig().IsReplica {
+		return 'S'
+	}
+
+	return 'M'
+}
+
+// formatLogLine renders a message in the Redis log format:
+// "pid:role dd Mon yyyy hh:mm:ss.mmm <marker> message".
+func formatLogLine(now time.Time, level LogLevel, message string) string {
+	return fmt.Sprintf("%d:%c %s %c %s\n",
+		os.Getpid(),
+		logRoleMarker(),
+		now.Format(logTimestampLayout),
+		level.marker(),
+		strings.TrimRight(message, "\n"),
+	)
+}
+
+// Logf writes a formatted message if level is enabled.
+func Logf(level LogLevel, format string, args ...any) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	if level < logLevel {
+		return
+	}
+
+	io.WriteString(logOutput, formatLogLine(time.Now(), level, fmt.Sprintf(format, args...)))
+}
+
+func LogDebug(format string, args ...any) {
+	Logf(LogLevelDebug, format, args...)
+}
+
+func LogVerbose(format string, args ...any) {
+	Logf(LogLevelVerbose, format, args...)
+}
+
+func LogNotice(format string, args ...any) {
+	Logf(LogLevelNotice, format, args...)
+}
+
+func LogWarning(format string, args ...any) {
+	Logf(LogLevelWarning, format, args...)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected LogLevel
+		hasError bool
+	}{
+		{name: "debug", expected: LogLevelDebug},
+		{name: "VERBOSE", expected: LogLevelVerbose},
+		{name: "notice", expected: LogLevelNotice},
+		{name: "warning", expected: LogLevelWarning},
+		{name: "loud", hasError: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			level, err := ParseLogLevel(testCase.name)
+			if (err != nil) != testCase.hasError {
+				t.Fatalf("ParseLogLevel(%q) error = %v, expected error %v", testCase.name, err, testCase.hasError)
+			}
+			if !testCase.hasError && level != testCase.expected {
+				t.Errorf("ParseLogLevel(%q) = %v, expected %v", testCase.name, level, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestFormatLogLine(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 14, 7, 9, 123000000, time.UTC)
+
+	line := formatLogLine(now, LogLevelWarning, "disk is full\n")
+
+	pattern := regexp.MustCompile(`^\d+:M 05 Mar 2024 14:07:09\.123 # disk is full\n$`)
+	if !pattern.MatchString(line) {
+		t.Errorf("formatLogLine() = %q, expected to match %s", line, pattern)
+	}
+}
+
+func TestLogfDiscardsMessagesBelowLevel(t *testing.T) {
+	var output bytes.Buffer
+	restore := SetLogOutputForTest(&output, LogLevelNotice)
+	defer restore()
+
+	LogDebug("debug message")
+	LogVerbose("verbose message")
+	LogNotice("notice %d", 1)
+	LogWarning("warning %d", 2)
+
+	logged := output.String()
+	if strings.Contains(logged, "debug message") || strings.Contains(logged, "verbose message") {
+		t.Errorf("log output %q contains messages below notice", logged)
+	}
+	if !strings.Contains(logged, " * notice 1\n") || !strings.Contains(logged, " # warning 2\n") {
+		t.Errorf("log output %q is missing notice or warning messages", logged)
+	}
+}
+
+func TestConfigureLoggingWritesToLogfile(t *testing.T) {
+	restore := SetLogOutputForTest(os.Stdout, LogLevelNotice)
+	defer restore()
+
+	logfile := filepath.Join(t.TempDir(), "redis.log")
+	if err := ConfigureLogging("verbose", logfile); err != nil {
+		t.Fatalf("ConfigureLogging() error = %v", err)
+	}
+	defer ConfigureLogging("notice", "")
+
+	LogVerbose("written to file")
+
+	contents, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(contents), " - written to file\n") {
+		t.Errorf("logfile contents = %q, expected the verbose message", contents)
+	}
+}
+
+func TestConfigureLoggingRejectsUnknownLevel(t *testing.T) {
+	if err := ConfigureLogging("chatty", ""); err == nil {
+		t.Error("ConfigureLogging() error = nil, expected an error for an unknown level")
+	}
+}
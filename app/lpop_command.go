@@ -1,37 +1,26 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-	"strings"
-)
+import "strconv"
 
-func parseLpopCommandArguments(command *RedisCommand) (listKey string, popCount string, hasPopCount bool, errorResponse string) {
+func parseLpopCommandArguments(command *RedisCommand) (listKey string, popCount string, hasPopCount bool, errorResponse Reply) {
 	if len(command.Args) < 1 || len(command.Args) > 2 {
-		return "", "", false, "-ERR wrong number of arguments for 'lpop' command\r\n"
+		return "", "", false, WrongNumberOfArgumentsReply("lpop")
 	}
 
 	if len(command.Args) == 1 {
-		return command.Args[0], "", false, ""
+		return command.Args[0], "", false, Reply{}
 	}
 
-	return command.Args[0], command.Args[1], true, ""
+	return command.Args[0], command.Args[1], true, Reply{}
 }
 
-func encodeLpopArrayResponse(elements []string) string {
-	var builder strings.Builder
-
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(elements)))
-	for _, element := range elements {
-		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(element), element))
-	}
-
-	return builder.String()
+func encodeLpopArrayResponse(elements []string) Reply {
+	return BulkStringArrayReply(elements)
 }
 
-func HandleLpop(command *RedisCommand) string {
+func HandleLpop(command *RedisCommand) Reply {
 	listKey, popCountString, hasPopCount, errorResponse := parseLpopCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
@@ -39,7 +28,7 @@ func HandleLpop(command *RedisCommand) string {
 	if hasPopCount {
 		parsedPopCount, parsePopCountError := strconv.Atoi(popCountString)
 		if parsePopCountError != nil {
-			return "-ERR value is not an integer or out of range\r\n"
+			return errNotIntegerReply
 		}
 
 		popCount = parsedPopCount
@@ -47,11 +36,11 @@ func HandleLpop(command *RedisCommand) string {
 
 	poppedElements, popped := GetInstance().PopListLeft(listKey, popCount)
 	if !popped {
-		return "$-1\r\n"
+		return NullReply()
 	}
 
 	if !hasPopCount {
-		return fmt.Sprintf("$%d\r\n%s\r\n", len(poppedElements[0]), poppedElements[0])
+		return BulkStringReply(poppedElements[0])
 	}
 
 	return encodeLpopArrayResponse(poppedElements)
@@ -54,8 +54,8 @@ func TestParseLpopCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseLpopCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseLpopCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if listKey != testCase.expectedListKey {
@@ -79,7 +79,7 @@ func TestHandleLpopReturnsFirstElement(t *testing.T) {
 	rpushResult := HandleRpush(&RedisCommand{
 		Type: CmdRPUSH,
 		Args: []string{"list_key", "one", "two", "three", "four", "five"},
-	})
+	}).String()
 	if rpushResult != ":5\r\n" {
 		t.Fatalf("HandleRpush() = %q, expected %q", rpushResult, ":5\r\n")
 	}
@@ -87,7 +87,7 @@ func TestHandleLpopReturnsFirstElement(t *testing.T) {
 	result := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 
 	expected := formatExpectedBulkStringResponse("one")
 	if result != expected {
@@ -106,7 +106,7 @@ func TestHandleLpopRemovesFirstElementFromList(t *testing.T) {
 	popResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 	if popResult != formatExpectedBulkStringResponse("one") {
 		t.Fatalf("HandleLpop() = %q, expected %q", popResult, formatExpectedBulkStringResponse("one"))
 	}
@@ -114,7 +114,7 @@ func TestHandleLpopRemovesFirstElementFromList(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("two", "three", "four", "five")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -132,7 +132,7 @@ func TestHandleLpopCodecraftersStageExample(t *testing.T) {
 	popResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 	if popResult != formatExpectedBulkStringResponse("a") {
 		t.Fatalf("HandleLpop() = %q, expected %q", popResult, formatExpectedBulkStringResponse("a"))
 	}
@@ -144,7 +144,7 @@ func TestHandleLpopReturnsNullBulkStringForMissingList(t *testing.T) {
 	result := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"missing_list_key"},
-	})
+	}).String()
 
 	if result != "$-1\r\n" {
 		t.Errorf("HandleLpop() = %q, expected %q", result, "$-1\r\n")
@@ -162,7 +162,7 @@ func TestHandleLpopReturnsNullBulkStringForEmptyList(t *testing.T) {
 	firstPopResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 	if firstPopResult != formatExpectedBulkStringResponse("only") {
 		t.Fatalf("first HandleLpop() = %q, expected %q", firstPopResult, formatExpectedBulkStringResponse("only"))
 	}
@@ -170,7 +170,7 @@ func TestHandleLpopReturnsNullBulkStringForEmptyList(t *testing.T) {
 	secondPopResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key"},
-	})
+	}).String()
 	if secondPopResult != "$-1\r\n" {
 		t.Errorf("second HandleLpop() = %q, expected %q", secondPopResult, "$-1\r\n")
 	}
@@ -182,7 +182,7 @@ func TestHandleLpopArgumentParsing(t *testing.T) {
 	result := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'lpop' command\r\n" {
 		t.Errorf("HandleLpop() = %q, expected %q", result, "-ERR wrong number of arguments for 'lpop' command\r\n")
@@ -200,7 +200,7 @@ func TestHandleLpopRemovesMultipleElements(t *testing.T) {
 	popResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key", "2"},
-	})
+	}).String()
 	expectedPopResult := formatExpectedLrangeResponse("one", "two")
 	if popResult != expectedPopResult {
 		t.Fatalf("HandleLpop() = %q, expected %q", popResult, expectedPopResult)
@@ -209,7 +209,7 @@ func TestHandleLpopRemovesMultipleElements(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("three", "four", "five")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -227,7 +227,7 @@ func TestHandleLpopRemovesAllElementsWhenCountExceedsListLength(t *testing.T) {
 	popResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key", "10"},
-	})
+	}).String()
 	expectedPopResult := formatExpectedLrangeResponse("a", "b", "c", "d")
 	if popResult != expectedPopResult {
 		t.Fatalf("HandleLpop() = %q, expected %q", popResult, expectedPopResult)
@@ -236,7 +236,7 @@ func TestHandleLpopRemovesAllElementsWhenCountExceedsListLength(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	if rangeResult != "*0\r\n" {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, "*0\r\n")
 	}
@@ -253,7 +253,7 @@ func TestHandleLpopCodecraftersMultipleElementsExample(t *testing.T) {
 	popResult := HandleLpop(&RedisCommand{
 		Type: CmdLPOP,
 		Args: []string{"list_key", "2"},
-	})
+	}).String()
 	expectedPopResult := formatExpectedLrangeResponse("a", "b")
 	if popResult != expectedPopResult {
 		t.Fatalf("HandleLpop() = %q, expected %q", popResult, expectedPopResult)
@@ -262,7 +262,7 @@ func TestHandleLpopCodecraftersMultipleElementsExample(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("c", "d")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -1,23 +1,21 @@
 package main
 
-import "fmt"
-
-func parseLpushCommandArguments(command *RedisCommand) (listKey string, elements []string, errorResponse string) {
+func parseLpushCommandArguments(command *RedisCommand) (listKey string, elements []string, errorResponse Reply) {
 	if len(command.Args) < 2 {
-		return "", nil, "-ERR wrong number of arguments for 'lpush' command\r\n"
+		return "", nil, WrongNumberOfArgumentsReply("lpush")
 	}
 
-	return command.Args[0], command.Args[1:], ""
+	return command.Args[0], command.Args[1:], Reply{}
 }
 
-func HandleLpush(command *RedisCommand) string {
+func HandleLpush(command *RedisCommand) Reply {
 	listKey, elements, errorResponse := parseLpushCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	listLength := GetInstance().PushListLeft(listKey, elements...)
 	notifyBlockingBlpopWaiters(listKey)
 
-	return fmt.Sprintf(":%d\r\n", listLength)
+	return IntegerReply(int64(listLength))
 }
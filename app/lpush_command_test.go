@@ -40,8 +40,8 @@ func TestParseLpushCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseLpushCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseLpushCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if listKey != testCase.expectedListKey {
@@ -86,7 +86,7 @@ func TestHandleLpushReturnsListLength(t *testing.T) {
 			result := HandleLpush(&RedisCommand{
 				Type: CmdLPUSH,
 				Args: testCase.args,
-			})
+			}).String()
 
 			if result != testCase.expected {
 				t.Errorf("HandleLpush() = %q, expected %q", result, testCase.expected)
@@ -101,7 +101,7 @@ func TestHandleLpushPrependsElementsInReverseOrder(t *testing.T) {
 	lengthResult := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key", "a", "b", "c"},
-	})
+	}).String()
 	if lengthResult != ":3\r\n" {
 		t.Fatalf("HandleLpush() = %q, expected %q", lengthResult, ":3\r\n")
 	}
@@ -109,7 +109,7 @@ func TestHandleLpushPrependsElementsInReverseOrder(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("c", "b", "a")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -122,7 +122,7 @@ func TestHandleLpushCodecraftersStageExample(t *testing.T) {
 	firstResult := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key", "c"},
-	})
+	}).String()
 	if firstResult != ":1\r\n" {
 		t.Fatalf("first HandleLpush() = %q, expected %q", firstResult, ":1\r\n")
 	}
@@ -130,7 +130,7 @@ func TestHandleLpushCodecraftersStageExample(t *testing.T) {
 	secondResult := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key", "b", "a"},
-	})
+	}).String()
 	if secondResult != ":3\r\n" {
 		t.Fatalf("second HandleLpush() = %q, expected %q", secondResult, ":3\r\n")
 	}
@@ -138,7 +138,7 @@ func TestHandleLpushCodecraftersStageExample(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("a", "b", "c")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -151,7 +151,7 @@ func TestHandleLpushPrependsToExistingList(t *testing.T) {
 	rpushResult := HandleRpush(&RedisCommand{
 		Type: CmdRPUSH,
 		Args: []string{"list_key", "x", "y"},
-	})
+	}).String()
 	if rpushResult != ":2\r\n" {
 		t.Fatalf("HandleRpush() = %q, expected %q", rpushResult, ":2\r\n")
 	}
@@ -159,7 +159,7 @@ func TestHandleLpushPrependsToExistingList(t *testing.T) {
 	lpushResult := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key", "a", "b"},
-	})
+	}).String()
 	if lpushResult != ":4\r\n" {
 		t.Fatalf("HandleLpush() = %q, expected %q", lpushResult, ":4\r\n")
 	}
@@ -167,7 +167,7 @@ func TestHandleLpushPrependsToExistingList(t *testing.T) {
 	rangeResult := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0", "-1"},
-	})
+	}).String()
 	expectedRangeResult := formatExpectedLrangeResponse("b", "a", "x", "y")
 	if rangeResult != expectedRangeResult {
 		t.Errorf("HandleLrange() = %q, expected %q", rangeResult, expectedRangeResult)
@@ -180,7 +180,7 @@ func TestHandleLpushArgumentParsing(t *testing.T) {
 	result := HandleLpush(&RedisCommand{
 		Type: CmdLPUSH,
 		Args: []string{"list_key"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'lpush' command\r\n" {
 		t.Errorf("HandleLpush() = %q, expected %q", result, "-ERR wrong number of arguments for 'lpush' command\r\n")
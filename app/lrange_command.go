@@ -1,17 +1,13 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-	"strings"
-)
+import "strconv"
 
-func parseLrangeCommandArguments(command *RedisCommand) (listKey string, startIndex string, stopIndex string, errorResponse string) {
+func parseLrangeCommandArguments(command *RedisCommand) (listKey string, startIndex string, stopIndex string, errorResponse Reply) {
 	if len(command.Args) != 3 {
-		return "", "", "", "-ERR wrong number of arguments for 'lrange' command\r\n"
+		return "", "", "", WrongNumberOfArgumentsReply("lrange")
 	}
 
-	return command.Args[0], command.Args[1], command.Args[2], ""
+	return command.Args[0], command.Args[1], command.Args[2], Reply{}
 }
 
 func normalizeLrangeIndex(index int, listLength int) int {
@@ -26,32 +22,32 @@ func normalizeLrangeIndex(index int, listLength int) int {
 	return index
 }
 
-func HandleLrange(command *RedisCommand) string {
+func HandleLrange(command *RedisCommand) Reply {
 	listKey, startIndexString, stopIndexString, errorResponse := parseLrangeCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	startIndex, parseStartError := strconv.Atoi(startIndexString)
 	if parseStartError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	stopIndex, parseStopError := strconv.Atoi(stopIndexString)
 	if parseStopError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	list := GetInstance().GetList(listKey)
 	if list == nil {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	listLength := len(list.Elements)
 
 	startIndex = normalizeLrangeIndex(startIndex, listLength)
 	if startIndex >= listLength {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	stopIndex = normalizeLrangeIndex(stopIndex, listLength)
@@ -60,16 +56,8 @@ func HandleLrange(command *RedisCommand) string {
 	}
 
 	if startIndex > stopIndex {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
-	selectedElements := list.Elements[startIndex : stopIndex+1]
-
-	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(selectedElements)))
-	for _, element := range selectedElements {
-		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(element), element))
-	}
-
-	return builder.String()
+	return BulkStringArrayReply(list.Elements[startIndex : stopIndex+1])
 }
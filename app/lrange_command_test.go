@@ -17,7 +17,7 @@ func seedListKeyWithFiveElements(t *testing.T) {
 	result := HandleRpush(&RedisCommand{
 		Type: CmdRPUSH,
 		Args: []string{"list_key", "a", "b", "c", "d", "e"},
-	})
+	}).String()
 	if result != ":5\r\n" {
 		t.Fatalf("HandleRpush() = %q, expected %q", result, ":5\r\n")
 	}
@@ -77,8 +77,8 @@ func TestParseLrangeCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseLrangeCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseLrangeCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if listKey != testCase.expectedListKey {
@@ -167,7 +167,7 @@ func TestHandleLrange(t *testing.T) {
 			result := HandleLrange(&RedisCommand{
 				Type: CmdLRANGE,
 				Args: testCase.args,
-			})
+			}).String()
 
 			if result != testCase.expected {
 				t.Errorf("HandleLrange() = %q, expected %q", result, testCase.expected)
@@ -182,7 +182,7 @@ func TestHandleLrangeArgumentParsing(t *testing.T) {
 	result := HandleLrange(&RedisCommand{
 		Type: CmdLRANGE,
 		Args: []string{"list_key", "0"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'lrange' command\r\n" {
 		t.Errorf("HandleLrange() = %q, expected %q", result, "-ERR wrong number of arguments for 'lrange' command\r\n")
@@ -248,7 +248,7 @@ func TestHandleLrangeNegativeIndexes(t *testing.T) {
 			result := HandleLrange(&RedisCommand{
 				Type: CmdLRANGE,
 				Args: testCase.args,
-			})
+			}).String()
 
 			if result != testCase.expected {
 				t.Errorf("HandleLrange() = %q, expected %q", result, testCase.expected)
@@ -1,35 +1,42 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 )
 
 // Ensures gofmt doesn't remove the "net" and "os" imports in stage 1 (feel free to remove this!)
 var _ = net.Listen
 var _ = os.Exit
 
+// readBufferSize is the number of bytes requested from the socket per read.
+// Commands larger than this are reassembled by the reactor's CommandReader.
+const readBufferSize = 16 * 1024
+
 func listen(conn net.Conn, channel chan []byte) {
 	defer conn.Close()   // Ensure the connection for this client is closed when listen exits
 	defer close(channel) // Close the channel to signal eventReactor to stop
 
-	buffer := make([]byte, 1024)
+	buffer := make([]byte, readBufferSize)
 	for {
 		// Try to read data from the connection.
 		n, err := conn.Read(buffer)
 		if err != nil {
 			if err == io.EOF {
 				// Connection closed by client, normal termination for this loop
-				fmt.Printf("Client %s closed the connection.\n", conn.RemoteAddr())
+				LogVerbose("Client %s closed the connection.", conn.RemoteAddr())
 			} else {
 				// Some other read error occurred
-				fmt.Printf("Error reading from connection %s: %s\n", conn.RemoteAddr(), err.Error())
+				LogVerbose("Error reading from connection %s: %s", conn.RemoteAddr(), err.Error())
 			}
 			break // Exit the loop
 		}
@@ -44,33 +51,50 @@ func listen(conn net.Conn, channel chan []byte) {
 func ping_command(conn net.Conn) {
 	_, err := conn.Write([]byte("+PONG\r\n"))
 	if err != nil {
-		fmt.Printf("Error writing PONG to connection %s: %s\n", conn.RemoteAddr(), err.Error())
+		LogWarning("Error writing PONG to connection %s: %s", conn.RemoteAddr(), err.Error())
 	}
 }
 
 func eventReactor(channel chan []byte, conn net.Conn, wg *sync.WaitGroup, isMasterConn bool, masterReplicationProcessedCommandBytes *int) {
 	defer wg.Done()
-	defer RemoveConnectionTransactionState(conn)
-	defer RemoveConnectionPubSubState(conn)
-	defer RemoveConnectionWriteMutex(conn)
+	RegisterClient(conn)
+	defer UnregisterClient(conn)
+
+	if isMasterConn {
+		MarkMasterLinkConnection(conn)
+		defer UnmarkMasterLinkConnection(conn)
+		defer setMasterLinkUp(false)
+	}
+
+	commandReader := NewCommandReaderWithLimits(ProtocolLimitsFromConfig())
+	connectionClosing := false
 	for {
 		// Wait for data from the listen goroutine
 		// The ok variable will be false if the channel is closed
 		buffer, ok := <-channel
 		if !ok {
-			fmt.Printf("Channel closed for %s, eventReactor exiting.\n", conn.RemoteAddr())
+			LogDebug("Channel closed for %s, eventReactor exiting.", conn.RemoteAddr())
 			break // Exit loop if channel is closed
 		}
 
-		// Parse the RESP command
-		parser := NewRESPParser()
+		// After QUIT the connection is closed; keep draining until the
+		// listen goroutine notices and closes the channel.
+		if connectionClosing {
+			continue
+		}
+
+		// Limits changed by CONFIG SET apply from the next read on.
+		commandReader.SetLimits(ProtocolLimitsFromConfig())
+
+		// Commands may arrive split across reads or several to a read; the
+		// reader keeps partial frames until the rest of their bytes arrive.
+		commandReader.Feed(buffer)
 
-		// Handle multiple commands in one buffer
-		pos := 0
-		for pos < len(buffer) {
-			cmd, nextPos, err := parser.Parse(buffer[pos:])
+		for {
+			cmd, rawCommand, err := commandReader.Next()
 			if err != nil {
-				fmt.Printf("Error parsing RESP message at pos %d: %s\n", pos, err.Error())
+				closeConnectionAfterReadError(conn, err, isMasterConn)
+				connectionClosing = true
 				break
 			}
 
@@ -78,15 +102,15 @@ func eventReactor(channel chan []byte, conn net.Conn, wg *sync.WaitGroup, isMast
 				break
 			}
 
-			fmt.Printf("Parsed command from %s: Type=%s, Args=%v\n", conn.RemoteAddr(), cmd.Type.String(), cmd.Args)
+			LogDebug("Parsed command from %s: Type=%s, Args=%v", conn.RemoteAddr(), cmd.Type.String(), cmd.Args)
 
-			commandByteLength := nextPos
+			commandByteLength := len(rawCommand)
 			if isMasterConn && masterReplicationProcessedCommandBytes != nil &&
 				cmd.Type == CmdREPLCONF && len(cmd.Args) > 0 && strings.EqualFold(cmd.Args[0], "GETACK") {
 				acknowledgementRESP := FormatReplicaReplconfAcknowledgementRESPArray(*masterReplicationProcessedCommandBytes)
 				_, writeError := conn.Write([]byte(acknowledgementRESP))
 				if writeError != nil {
-					fmt.Printf("Error writing REPLCONF ACK to master connection %s: %s\n", conn.RemoteAddr(), writeError.Error())
+					LogWarning("Error writing REPLCONF ACK to master connection %s: %s", conn.RemoteAddr(), writeError.Error())
 				}
 			}
 
@@ -95,38 +119,75 @@ func eventReactor(channel chan []byte, conn net.Conn, wg *sync.WaitGroup, isMast
 				if parseError == nil {
 					UpdateReplicaAcknowledgementOffset(conn, acknowledgedOffset)
 				}
-				pos += nextPos
 				continue
 			}
 
-			// Propagate write commands to replicas (only if we are master)
-			if cmd.Type.IsWrite() && !isMasterConn && !ShouldQueueCommandDuringTransaction(conn, cmd) {
-				PropagateCommand(buffer[pos : pos+nextPos])
-			}
-
+			recordClientCommand(conn, cmd)
+			beginCommand()
 			response := HandleConnectionCommand(conn, cmd)
+			endCommand()
 
-			// Send response back to client ONLY if it's not the master connection
+			// Send response back to client ONLY if it's not the master
+			// connection. Replies are buffered and flushed once the batch of
+			// pipelined commands read so far has run.
 			if !isMasterConn {
-				writeError := WriteToConnection(conn, response)
+				writeError := BufferReplyToConnection(conn, response)
 				if writeError != nil {
-					fmt.Printf("Error writing response to connection %s: %s\n", conn.RemoteAddr(), writeError.Error())
-				} else {
-					fmt.Printf("Sent response to %s: %q\n", conn.RemoteAddr(), response)
+					LogVerbose("Error writing response to connection %s: %s", conn.RemoteAddr(), writeError.Error())
+				} else if LogEnabled(LogLevelDebug) {
+					LogDebug("Buffered response to %s: %q", conn.RemoteAddr(), response.String())
 				}
 			} else {
-				fmt.Printf("Replica processed command %s silently\n", cmd.Type.String())
+				LogDebug("Replica processed command %s silently", cmd.Type.String())
+			}
+
+			if cmd.Type == CmdQUIT && !isMasterConn {
+				flushConnectionOutput(conn)
+				conn.Close()
+				connectionClosing = true
+				break
 			}
 
 			if isMasterConn && masterReplicationProcessedCommandBytes != nil {
 				*masterReplicationProcessedCommandBytes += commandByteLength
+				recordMasterLinkOffset(*masterReplicationProcessedCommandBytes)
 			}
+		}
 
-			pos += nextPos
+		if !connectionClosing {
+			flushConnectionOutput(conn)
 		}
 	}
 }
 
+func flushConnectionOutput(conn net.Conn) {
+	if err := FlushConnectionOutput(conn); err != nil {
+		LogVerbose("Error writing response to connection %s: %s", conn.RemoteAddr(), err.Error())
+	}
+}
+
+// closeConnectionAfterReadError handles input the reader cannot recover
+// from. The stream position is lost, so like Redis the client is told about
+// a protocol error and then disconnected rather than resynchronised.
+func closeConnectionAfterReadError(conn net.Conn, err error, isMasterConn bool) {
+	var protocolError *ProtocolError
+	switch {
+	case errors.As(err, &protocolError):
+		LogVerbose("Protocol error (%s) from client %s", protocolError.Reason, conn.RemoteAddr())
+		if !isMasterConn {
+			if writeError := WriteReplyToConnection(conn, ErrorReply("ERR "+protocolError.Error())); writeError != nil {
+				LogVerbose("Error writing protocol error to connection %s: %s", conn.RemoteAddr(), writeError.Error())
+			}
+		}
+	case errors.Is(err, ErrQueryBufferLimitExceeded):
+		LogWarning("Closing client %s that reached max query buffer length", conn.RemoteAddr())
+	default:
+		LogWarning("Error parsing RESP message from %s: %s", conn.RemoteAddr(), err.Error())
+	}
+
+	conn.Close()
+}
+
 func main() {
 	// Parse command-line arguments
 	ParseConfig()
@@ -134,11 +195,32 @@ func main() {
 	// Load RDB file
 	config := GetConfig()
 
+	if err := ConfigureLogging(config.LogLevel, config.LogFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Fatal config error: %s\n", err.Error())
+		os.Exit(1)
+	}
+	for _, warning := range config.ConfigFileWarnings {
+		LogWarning("%s", warning)
+	}
+
+	if err := ApplyCommandRenames(config.RenamedCommands); err != nil {
+		fmt.Fprintf(os.Stderr, "Fatal config error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	ApplyRequirePass(config.RequirePass)
+	if config.AclFile != "" {
+		if err := LoadACLFile(config.AclFile); err != nil {
+			LogWarning("Aborting Redis startup because of ACL errors: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+
 	// If we are a replica, initiate handshake with master
 	if config.IsReplica {
 		go func() {
 			if err := InitiateHandshake(config); err != nil {
-				fmt.Printf("Error during handshake: %v\n", err)
+				LogWarning("Error during handshake: %v", err)
 			}
 		}()
 	}
@@ -147,38 +229,100 @@ func main() {
 		rdbPath := filepath.Join(config.Dir, config.DbFilename)
 		err := LoadRDB(rdbPath)
 		if err != nil {
-			fmt.Printf("Error loading RDB file: %s\n", err.Error())
+			LogWarning("Error loading RDB file: %s", err.Error())
+		}
+	}
+
+	if config.Port == 0 && config.TLSPort == 0 && config.UnixSocket == "" {
+		LogWarning("Configured to not listen anywhere, exiting.")
+		os.Exit(1)
+	}
+
+	var listeners []net.Listener
+	if config.Port != 0 {
+		tcpListeners, err := ListenTCP(config.Bind, config.Port, nil)
+		if err != nil {
+			LogWarning("Failed to bind to port %d: %s", config.Port, err.Error())
+			os.Exit(1)
+		}
+		listeners = append(listeners, tcpListeners...)
+	}
+	if config.TLSPort != 0 {
+		tlsConfig, err := ServerTLSConfig(config)
+		if err != nil {
+			LogWarning("Failed to configure TLS: %s", err.Error())
+			os.Exit(1)
+		}
+		tlsListeners, err := ListenTCP(config.Bind, config.TLSPort, tlsConfig)
+		if err != nil {
+			LogWarning("Failed to bind to TLS port %d: %s", config.TLSPort, err.Error())
+			os.Exit(1)
+		}
+		listeners = append(listeners, tlsListeners...)
+	}
+	if config.UnixSocket != "" {
+		l, err := listenUnixSocket(config.UnixSocket, config.UnixSocketPerm)
+		if err != nil {
+			LogWarning("Failed opening Unix socket: %s", err.Error())
+			os.Exit(1)
 		}
+		listeners = append(listeners, l)
+	}
+	for _, l := range listeners {
+		defer l.Close()
+		RegisterServerListener(l)
 	}
 
-	// You can use print statements as follows for debugging, they'll be visible when running tests.
-	fmt.Println("Logs from your program will appear here!")
+	if config.MetricsPort != 0 {
+		metricsListeners, err := ListenTCP(config.MetricsBind, config.MetricsPort, nil)
+		if err != nil {
+			LogWarning("Failed to bind to metrics port %d: %s", config.MetricsPort, err.Error())
+			os.Exit(1)
+		}
+		for _, l := range metricsListeners {
+			defer l.Close()
+			RegisterServerListener(l)
+			LogNotice("Serving metrics on http://%s/metrics", l.Addr().String())
+		}
+		ServeMetrics(metricsListeners)
+	}
 
-	// Uncomment this block to pass the first stage
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go HandleShutdownSignals(signals)
 
-	l, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", config.Port))
-	if err != nil {
-		fmt.Printf("Failed to bind to port %d: %s\n", config.Port, err.Error())
-		os.Exit(1)
+	StartIdleClientReaper()
+	StartActiveExpireCycle()
+
+	LogNotice("Server initialized")
+	for _, l := range listeners {
+		LogNotice("Ready to accept connections %s on %s", l.Addr().Network(), l.Addr().String())
+		go serveConnections(l)
 	}
-	defer l.Close()
-	fmt.Printf("Server listening on 0.0.0.0:%d\n", config.Port)
 
+	// The listeners are closed by a shutdown, which exits the process once
+	// clients are closed.
+	select {}
+}
+
+// serveConnections accepts clients from one listener until it is closed.
+// TCP and Unix socket clients are handled identically.
+func serveConnections(l net.Listener) {
 	for { // Loop indefinitely to accept multiple connections
 		conn, err := l.Accept()
 		if err != nil {
-			fmt.Println("Error accepting connection:", err.Error())
-			// If the listener is closed, Accept will return an error, and the loop will break.
-			// For other temporary errors, we might want to continue.
-			// Checking for net.ErrClosed specifically can make this more robust.
-			if _, ok := err.(*net.OpError); ok && err.Error() == "use of closed network connection" {
-				fmt.Println("Listener closed, server shutting down.")
-				break
+			if errors.Is(err, net.ErrClosed) {
+				LogNotice("Listener %s closed, no longer accepting connections.", l.Addr().String())
+				return
 			}
+			LogWarning("Error accepting connection: %s", err.Error())
 			continue
 		}
 
-		fmt.Printf("Accepted new connection from %s\n", conn.RemoteAddr())
+		LogVerbose("Accepted new connection from %s", clientAddress(conn))
+		if !admitConnection(conn) {
+			continue
+		}
 
 		// For each connection, set up its own channel and WaitGroup
 		clientChannel := make(chan []byte)
@@ -189,9 +333,7 @@ func main() {
 		// Pass the per-client WaitGroup to its eventReactor
 		go eventReactor(clientChannel, conn, &clientWg, false, nil)
 
-		// The main goroutine does NOT wait here using clientWg.Wait().
+		// The accept loop does NOT wait here using clientWg.Wait().
 		// It loops back to accept the next client.
-		// clientWg.Wait() would be used if we wanted to do something *after* a specific client is done,
-		// but not to block accepting other clients.
 	}
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMetricsBind keeps /metrics, which has no authentication, on the
+// loopback interfaces unless metrics-bind says otherwise.
+const DefaultMetricsBind = "127.0.0.1 -::1"
+
+// metricsKeyTypes are the value types redis_keys always reports, so a type
+// with no keys shows up as 0 rather than disappearing.
+var metricsKeyTypes = []string{"list", "stream", "string", "zset"}
+
+// metricsWriter renders the Prometheus text exposition format.
+type metricsWriter struct {
+	builder strings.Builder
+}
+
+// family starts a metric family with its HELP and TYPE lines.
+func (writer *metricsWriter) family(name string, metricType string, help string) {
+	fmt.Fprintf(&writer.builder, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample writes one sample; labels are name, value pairs.
+func (writer *metricsWriter) sample(name string, value float64, labels ...string) {
+	writer.builder.WriteString(name)
+	if len(labels) > 0 {
+		writer.builder.WriteByte('{')
+		for index := 0; index+1 < len(labels); index += 2 {
+			if index > 0 {
+				writer.builder.WriteByte(',')
+			}
+			fmt.Fprintf(&writer.builder, `%s="%s"`, labels[index], escapeMetricLabelValue(labels[index+1]))
+		}
+		writer.builder.WriteByte('}')
+	}
+	writer.builder.WriteByte(' ')
+	writer.builder.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	writer.builder.WriteByte('\n')
+}
+
+// metric writes a family holding a single unlabelled sample.
+func (writer *metricsWriter) metric(name string, metricType string, help string, value float64) {
+	writer.family(name, metricType, help)
+	writer.sample(name, value)
+}
+
+var metricLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeMetricLabelValue(value string) string {
+	return metricLabelValueEscaper.Replace(value)
+}
+
+// formatMetrics renders every metric from the counters INFO reports.
+func formatMetrics() string {
+	var writer metricsWriter
+	connectedClients, blockedClients, pubsubClients := clientCounts()
+	used, rss, peak := memoryUsage()
+
+	writer.metric("redis_uptime_in_seconds", "gauge", "Seconds since the server started.", float64(int64(time.Since(serverStartTime).Seconds())))
+	writer.metric("redis_connected_clients", "gauge", "Number of client connections.", float64(connectedClients))
+	writer.metric("redis_blocked_clients", "gauge", "Clients waiting in a blocking command.", float64(blockedClients))
+	writer.metric("redis_pubsub_clients", "gauge", "Clients subscribed to at least one channel.", float64(pubsubClients))
+	writer.metric("redis_pubsub_channels", "gauge", "Channels with at least one subscriber.", float64(activeChannelCount()))
+	writer.metric("redis_memory_used_bytes", "gauge", "Heap bytes in use.", float64(used))
+	writer.metric("redis_memory_rss_bytes", "gauge", "Bytes obtained from the operating system.", float64(rss))
+	writer.metric("redis_memory_peak_bytes", "gauge", "Highest heap use seen.", float64(peak))
+
+	writer.metric("redis_connections_received_total", "counter", "Connections accepted.", float64(totalConnectionsReceived.Load()))
+	writer.metric("redis_rejected_connections_total", "counter", "connections rejected because of maxclients", float64(rejectedConnections.Load()))
+	writer.metric("redis_commands_processed_total", "counter", "Commands executed.", float64(totalCommandsProcessed.Load()))
+	writer.metric("redis_error_replies_total", "counter", "Error replies sent.", float64(totalErrorReplies.Load()))
+	writer.metric("redis_keyspace_hits_total", "counter", "Key lookups that found the key.", float64(keyspaceHits.Load()))
+	writer.metric("redis_keyspace_misses_total", "counter", "Key lookups that did not find the key.", float64(keyspaceMisses.Load()))
+	writeCommandMetrics(&writer)
+
+	keyCounts := GetInstance().KeyCountsByType()
+	writer.family("redis_keys", "gauge", "Keys by value type.")
+	for _, keyType := range metricsKeyTypes {
+		writer.sample("redis_keys", float64(keyCounts[keyType]), "db", "db0", "type", keyType)
+	}
+	_, expires := GetInstance().KeyspaceCounts()
+	writer.family("redis_keys_expiring", "gauge", "Keys with an expiry.")
+	writer.sample("redis_keys_expiring", float64(expires), "db", "db0")
+
+	writeReplicationMetrics(&writer)
+
+	return writer.builder.String()
+}
+
+// writeCommandMetrics writes the INFO commandstats counters labelled by
+// command name.
+func writeCommandMetrics(writer *metricsWriter) {
+	stats := commandStatsSnapshot()
+	names := make([]string, 0, len(stats))
+	byName := make(map[string]commandStats, len(stats))
+	for commandType, commandStats := range stats {
+		if spec := LookupCommandSpec(commandType); spec != nil {
+			names = append(names, spec.Name)
+			byName[spec.Name] = commandStats
+		}
+	}
+	sort.Strings(names)
+
+	families := []struct {
+		name  string
+		help  string
+		value func(commandStats) float64
+	}{
+		{"redis_commands_total", "Calls by command.", func(stats commandStats) float64 { return float64(stats.calls) }},
+		{"redis_commands_duration_seconds_total", "Time spent executing each command.", func(stats commandStats) float64 {
+			return float64(stats.microseconds) / 1e6
+		}},
+		{"redis_commands_rejected_calls_total", "Calls refused before execution by command.", func(stats commandStats) float64 { return float64(stats.rejectedCalls) }},
+		{"redis_commands_failed_calls_total", "Calls that replied with an error by command.", func(stats commandStats) float64 { return float64(stats.failedCalls) }},
+	}
+	for _, family := range families {
+		writer.family(family.name, "counter", family.help)
+		for _, name := range names {
+			writer.sample(family.name, family.value(byName[name]), "cmd", name)
+		}
+	}
+}
+
+// writeReplicationMetrics writes the replication offset and, on a master,
+// how far behind each replica is.
+func writeReplicationMetrics(writer *metricsWriter) {
+	if GetConfig().IsReplica {
+		link := masterLinkSnapshot()
+		linkUp := 0.0
+		if link.up {
+			linkUp = 1
+		}
+		writer.metric("redis_master_link_up", "gauge", "Whether the link to the master is up.", linkUp)
+		writer.metric("redis_replication_offset", "gauge", "Replication offset processed from the master.", float64(link.processedOffset))
+		return
+	}
+
+	masterOffset := CurrentMasterReplicationOffset()
+	replicaStates := ReplicasSnapshot()
+	writer.metric("redis_replication_offset", "gauge", "Replication offset sent to replicas.", float64(masterOffset))
+	writer.metric("redis_connected_replicas", "gauge", "Number of connected replicas.", float64(len(replicaStates)))
+
+	writer.family("redis_replica_lag_seconds", "gauge", "Seconds since each replica last acknowledged.")
+	for _, replica := range replicaStates {
+		host, port := replicaEndpoint(replica)
+		writer.sample("redis_replica_lag_seconds", float64(int64(time.Since(replica.lastAcknowledgedAt).Seconds())), "ip", host, "port", strconv.Itoa(port))
+	}
+	writer.family("redis_replica_offset_lag_bytes", "gauge", "Bytes of replication stream each replica has yet to acknowledge.")
+	for _, replica := range replicaStates {
+		host, port := replicaEndpoint(replica)
+		writer.sample("redis_replica_offset_lag_bytes", float64(masterOffset-replica.lastAcknowledgedOffset), "ip", host, "port", strconv.Itoa(port))
+	}
+}
+
+// handleMetrics serves GET /metrics in the Prometheus text format.
+func handleMetrics(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		response.Header().Set("Allow", "GET, HEAD")
+		http.Error(response, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(response, formatMetrics())
+}
+
+// ServeMetrics serves /metrics on the listeners until they are closed.
+func ServeMetrics(listeners []net.Listener) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) {
+				LogWarning("Metrics server on %s stopped: %s", listener.Addr().String(), err.Error())
+			}
+		}(listener)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsReportInfoCounters(t *testing.T) {
+	resetClientTestState(t)
+	resetTransactionTestState(t)
+	ResetServerStats()
+	t.Cleanup(ResetServerStats)
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdSET, Args: []string{"name", "value"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdRPUSH, Args: []string{"queue", "job"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"name"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"missing"}})
+
+	// Start replication afresh so the writes above do not count toward the
+	// offset.
+	resetReplicationStateForTest()
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	HandleReplconf(replicaConnection, &RedisCommand{Type: CmdREPLCONF, Args: []string{"listening-port", "6381"}})
+	RegisterReplica(replicaConnection)
+	UpdateReplicaAcknowledgementOffset(replicaConnection, 42)
+	RecordPropagatedReplicationBytes(100)
+
+	recorder := httptest.NewRecorder()
+	handleMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, expected the Prometheus text format", contentType)
+	}
+
+	metrics := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE redis_connected_clients gauge\nredis_connected_clients 2\n",
+		"# TYPE redis_commands_processed_total counter\nredis_commands_processed_total 4\n",
+		"redis_commands_total{cmd=\"get\"} 2\n",
+		"redis_commands_total{cmd=\"rpush\"} 1\n",
+		"redis_keyspace_hits_total 1\n",
+		"redis_keyspace_misses_total 1\n",
+		"redis_keys{db=\"db0\",type=\"list\"} 1\n",
+		"redis_keys{db=\"db0\",type=\"string\"} 1\n",
+		"redis_keys{db=\"db0\",type=\"zset\"} 0\n",
+		"redis_replication_offset 100\n",
+		"redis_connected_replicas 1\n",
+		"redis_replica_lag_seconds{ip=\"pipe\",port=\"6381\"} 0\n",
+		"redis_replica_offset_lag_bytes{ip=\"pipe\",port=\"6381\"} 58\n",
+		"redis_blocked_clients 0\n",
+		"redis_pubsub_channels 0\n",
+		"# TYPE redis_memory_used_bytes gauge\n",
+		"# HELP redis_rejected_connections_total connections rejected because of maxclients\n",
+	} {
+		if !strings.Contains(metrics, line) {
+			t.Errorf("metrics do not contain %q:\n%s", line, metrics)
+		}
+	}
+}
+
+func TestMetricsForgetDisconnectedReplicas(t *testing.T) {
+	resetClientTestState(t)
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	HandleReplconf(replicaConnection, &RedisCommand{Type: CmdREPLCONF, Args: []string{"listening-port", "6381"}})
+	RegisterReplica(replicaConnection)
+
+	disconnectThroughEventReactor(t, replicaConnection)
+
+	recorder := httptest.NewRecorder()
+	handleMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := recorder.Body.String()
+	if !strings.Contains(metrics, "redis_connected_replicas 0\n") {
+		t.Errorf("metrics do not report zero replicas:\n%s", metrics)
+	}
+	if strings.Contains(metrics, "redis_replica_lag_seconds{") || strings.Contains(metrics, "redis_replica_offset_lag_bytes{") {
+		t.Errorf("metrics still report the disconnected replica:\n%s", metrics)
+	}
+}
+
+func TestEscapeMetricLabelValue(t *testing.T) {
+	if escaped := escapeMetricLabelValue("a\\b\"c\nd"); escaped != `a\\b\"c\nd` {
+		t.Errorf("escapeMetricLabelValue() = %q", escaped)
+	}
+}
+
+func TestServeMetricsOverHTTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	ServeMetrics([]net.Listener{listener})
+
+	response, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || !strings.Contains(string(body), "redis_uptime_in_seconds") {
+		t.Errorf("GET /metrics = %d %q, expected metrics", response.StatusCode, body)
+	}
+
+	response, err = http.Get("http://" + listener.Addr().String() + "/other")
+	if err != nil {
+		t.Fatalf("GET /other error = %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /other = %d, expected 404", response.StatusCode)
+	}
+}
+
+func TestMetricsListenOnLoopbackByDefault(t *testing.T) {
+	config, err := LoadConfig([]string{"--metrics-port", "9121"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.MetricsBind != DefaultMetricsBind {
+		t.Fatalf("metrics-bind = %q, expected the default %q", config.MetricsBind, DefaultMetricsBind)
+	}
+
+	listeners, err := ListenTCP(config.MetricsBind, 0, nil)
+	if err != nil {
+		t.Fatalf("ListenTCP() error = %v", err)
+	}
+	for _, listener := range listeners {
+		defer listener.Close()
+		if address := listener.Addr().(*net.TCPAddr); !address.IP.IsLoopback() {
+			t.Errorf("metrics listener on %s, expected only loopback addresses", address)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	monitorMutex       sync.Mutex
+	monitorConnections = make(map[net.Conn]struct{})
+)
+
+// HandleMonitor turns the connection into a feed of every command the
+// server executes from now on.
+func HandleMonitor(connection net.Conn, command *RedisCommand) Reply {
+	getConnectionOutput(connection)
+	monitorMutex.Lock()
+	monitorConnections[connection] = struct{}{}
+	monitorMutex.Unlock()
+
+	return OKReply()
+}
+
+// isMonitorConnection reports whether the connection issued MONITOR.
+func isMonitorConnection(connection net.Conn) bool {
+	monitorMutex.Lock()
+	defer monitorMutex.Unlock()
+
+	_, exists := monitorConnections[connection]
+	return exists
+}
+
+// RemoveMonitorConnection stops feeding the connection; called by RESET and
+// when the client disconnects.
+func RemoveMonitorConnection(connection net.Conn) {
+	monitorMutex.Lock()
+	defer monitorMutex.Unlock()
+
+	delete(monitorConnections, connection)
+}
+
+// feedMonitors sends an executed command to every monitor. Like Redis,
+// administrative commands are never shown and AUTH credentials are
+// redacted.
+func feedMonitors(connection net.Conn, spec *CommandSpec, command *RedisCommand) {
+	if spec.HasFlag(FlagAdmin) {
+		return
+	}
+
+	monitorMutex.Lock()
+	monitors := make([]net.Conn, 0, len(monitorConnections))
+	for monitor := range monitorConnections {
+		monitors = append(monitors, monitor)
+	}
+	monitorMutex.Unlock()
+
+	if len(monitors) == 0 {
+		return
+	}
+
+	line := []byte(formatMonitorLine(time.Now(), clientAddress(connection), spec, command))
+	for _, monitor := range monitors {
+		if err := EnqueueToConnection(monitor, line); err != nil {
+			LogVerbose("Error feeding monitor %s: %v", clientAddress(monitor), err)
+		}
+	}
+}
+
+// formatMonitorLine renders a command the way Redis's MONITOR does:
+// +<seconds>.<micros> [<db> <addr>] "name" "arg" ...
+func formatMonitorLine(now time.Time, address string, spec *CommandSpec, command *RedisCommand) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "+%d.%06d [0 %s]", now.Unix(), now.Nanosecond()/1000, address)
+
+	name := command.Name
+	if name == "" {
+		name = spec.Name
+	}
+	for _, argument := range append([]string{name}, redactedCommandArguments(spec, command.Args)...) {
+		line.WriteByte(' ')
+		line.WriteString(quoteMonitorArgument(argument))
+	}
+	line.WriteString("\r\n")
+
+	return line.String()
+}
+
+// quoteMonitorArgument quotes an argument like Redis's sdscatrepr.
+func quoteMonitorArgument(argument string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for index := 0; index < len(argument); index++ {
+		character := argument[index]
+		switch character {
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(character)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\a':
+			quoted.WriteString(`\a`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		default:
+			if character < 0x20 || character > 0x7e {
+				fmt.Fprintf(&quoted, `\x%02x`, character)
+			} else {
+				quoted.WriteByte(character)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+
+	return quoted.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var monitorLinePattern = regexp.MustCompile(`^\+\d+\.\d{6} \[0 ([^\]]+)\] (.*)\r\n$`)
+
+func startMonitor(t *testing.T) *bufio.Reader {
+	t.Helper()
+	resetClientTestState(t)
+	resetTransactionTestState(t)
+
+	monitorServerConnection, monitorClientConnection := testConnectionPair(t)
+	t.Cleanup(func() { RemoveMonitorConnection(monitorServerConnection) })
+
+	reply := HandleConnectionCommand(monitorServerConnection, parseClientCommand(t, "MONITOR"))
+	if reply.String() != "+OK\r\n" {
+		t.Fatalf("MONITOR = %q, expected +OK", reply.String())
+	}
+	if flags := clientFlags(snapshotClient(monitorServerConnection)); flags != "O" {
+		t.Errorf("monitor client flags = %q, expected O", flags)
+	}
+
+	monitorClientConnection.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return bufio.NewReader(monitorClientConnection)
+}
+
+// readMonitorCommands reads count lines and returns the address and quoted
+// arguments of each.
+func readMonitorCommands(t *testing.T, reader *bufio.Reader, count int) []string {
+	t.Helper()
+
+	commands := make([]string, 0, count)
+	for len(commands) < count {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading monitor output: %v (read %q)", err, commands)
+		}
+		match := monitorLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Fatalf("monitor line %q does not match %s", line, monitorLinePattern)
+		}
+		commands = append(commands, match[1]+" "+match[2])
+	}
+
+	return commands
+}
+
+func TestMonitorStreamsCommandsIncludingTransactions(t *testing.T) {
+	reader := startMonitor(t)
+	connection := testConnection(t)
+
+	for _, args := range [][]string{
+		{"SET", "key", "with \"quotes\"\n"},
+		{"MULTI"},
+		{"INCR", "counter"},
+		{"EXEC"},
+		{"AUTH", "user", "secret"},
+		{"CONFIG", "GET", "port"},
+		{"ECHO", "done"},
+	} {
+		HandleConnectionCommand(connection, parseClientCommand(t, args...))
+	}
+
+	expected := []string{
+		`pipe "SET" "key" "with \"quotes\"\n"`,
+		`pipe "MULTI"`,
+		`pipe "INCR" "counter"`,
+		`pipe "EXEC"`,
+		`pipe "AUTH" "(redacted)" "(redacted)"`,
+		`pipe "ECHO" "done"`,
+	}
+	if commands := readMonitorCommands(t, reader, len(expected)); strings.Join(commands, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("monitor output =\n%s\nexpected\n%s", strings.Join(commands, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestMonitorShowsCommandsFromMaster(t *testing.T) {
+	reader := startMonitor(t)
+	masterConnection := testConnection(t)
+	MarkMasterLinkConnection(masterConnection)
+	t.Cleanup(func() { UnmarkMasterLinkConnection(masterConnection) })
+
+	HandleConnectionCommand(masterConnection, parseClientCommand(t, "set", "replicated", "1"))
+
+	if commands := readMonitorCommands(t, reader, 1); commands[0] != `pipe "set" "replicated" "1"` {
+		t.Errorf("monitor output = %q, expected the replicated SET", commands[0])
+	}
+}
+
+func TestResetStopsMonitoring(t *testing.T) {
+	resetClientTestState(t)
+	connection := testConnection(t)
+
+	HandleMonitor(connection, &RedisCommand{Type: CmdMONITOR})
+	HandleReset(connection, &RedisCommand{Type: CmdRESET})
+	if isMonitorConnection(connection) {
+		t.Error("connection is still a monitor after RESET")
+	}
+}
+
+func TestQuoteMonitorArgument(t *testing.T) {
+	if quoted := quoteMonitorArgument("a\\b\t\x01\xff"); quoted != `"a\\b\t\x01\xff"` {
+		t.Errorf("quoteMonitorArgument() = %s", quoted)
+	}
+}
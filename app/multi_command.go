@@ -5,16 +5,24 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 )
 
-const errExecWithoutMulti = "-ERR EXEC without MULTI\r\n"
-const errDiscardWithoutMulti = "-ERR DISCARD without MULTI\r\n"
-const errExecWithoutQueuedCommands = "*0\r\n"
-const queuedCommandResponse = "+QUEUED\r\n"
+var (
+	errExecWithoutMulti          = ErrorReply("ERR EXEC without MULTI")
+	errDiscardWithoutMulti       = ErrorReply("ERR DISCARD without MULTI")
+	errNestedMulti               = ErrorReply("ERR MULTI calls can not be nested")
+	errExecWithoutQueuedCommands = ArrayReply()
+	errExecAbort                 = ErrorReply("EXECABORT Transaction discarded because of previous errors.")
+	queuedCommandResponse        = SimpleStringReply("QUEUED")
+)
 
 type connectionTransactionState struct {
 	inTransaction  bool
 	queuedCommands []*RedisCommand
+	// hasQueueErrors is set when a command was rejected while queueing, so
+	// EXEC aborts the whole transaction.
+	hasQueueErrors bool
 }
 
 var (
@@ -49,8 +57,28 @@ func RemoveConnectionTransactionState(connection net.Conn) {
 	delete(connectionTransactionStates, connection)
 }
 
+func isConnectionInTransaction(connection net.Conn) bool {
+	connectionTransactionMutex.Lock()
+	defer connectionTransactionMutex.Unlock()
+
+	state, exists := connectionTransactionStates[connection]
+	return exists && state.inTransaction
+}
+
+func queuedTransactionCommandCount(connection net.Conn) int {
+	connectionTransactionMutex.Lock()
+	defer connectionTransactionMutex.Unlock()
+
+	state, exists := connectionTransactionStates[connection]
+	if !exists {
+		return 0
+	}
+
+	return len(state.queuedCommands)
+}
+
 func ShouldQueueCommandDuringTransaction(connection net.Conn, command *RedisCommand) bool {
-	if command.Type == CmdMULTI || command.Type == CmdEXEC || command.Type == CmdDISCARD {
+	if isTransactionControlCommand(command.Type) {
 		return false
 	}
 
@@ -58,46 +86,62 @@ func ShouldQueueCommandDuringTransaction(connection net.Conn, command *RedisComm
 	return transactionState.inTransaction
 }
 
-func queueTransactionCommand(transactionState *connectionTransactionState, command *RedisCommand) string {
+// isTransactionControlCommand reports whether a command runs immediately
+// even inside MULTI instead of being queued.
+func isTransactionControlCommand(commandType CommandType) bool {
+	switch commandType {
+	case CmdMULTI, CmdEXEC, CmdDISCARD, CmdQUIT, CmdRESET:
+		return true
+	default:
+		return false
+	}
+}
+
+func queueTransactionCommand(transactionState *connectionTransactionState, command *RedisCommand) Reply {
 	transactionState.queuedCommands = append(transactionState.queuedCommands, &RedisCommand{
 		Type: command.Type,
+		Name: command.Name,
 		Args: append([]string(nil), command.Args...),
 	})
 
 	return queuedCommandResponse
 }
 
-func parseMultiCommandArguments(command *RedisCommand) (errorResponse string) {
+func parseMultiCommandArguments(command *RedisCommand) (errorResponse Reply) {
 	if len(command.Args) != 0 {
-		return "-ERR wrong number of arguments for 'multi' command\r\n"
+		return WrongNumberOfArgumentsReply("multi")
 	}
 
-	return ""
+	return Reply{}
 }
 
-func HandleMulti(connection net.Conn, command *RedisCommand) string {
+func HandleMulti(connection net.Conn, command *RedisCommand) Reply {
 	errorResponse := parseMultiCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	transactionState := getConnectionTransactionState(connection)
+	if transactionState.inTransaction {
+		return errNestedMulti
+	}
+
 	transactionState.inTransaction = true
 
-	return "+OK\r\n"
+	return OKReply()
 }
 
-func parseExecCommandArguments(command *RedisCommand) (errorResponse string) {
+func parseExecCommandArguments(command *RedisCommand) (errorResponse Reply) {
 	if len(command.Args) != 0 {
-		return "-ERR wrong number of arguments for 'exec' command\r\n"
+		return WrongNumberOfArgumentsReply("exec")
 	}
 
-	return ""
+	return Reply{}
 }
 
-func HandleExec(connection net.Conn, command *RedisCommand) string {
+func HandleExec(connection net.Conn, command *RedisCommand) Reply {
 	errorResponse := parseExecCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
@@ -106,12 +150,17 @@ func HandleExec(connection net.Conn, command *RedisCommand) string {
 		return errExecWithoutMulti
 	}
 
+	if transactionState.hasQueueErrors {
+		RemoveConnectionTransactionState(connection)
+		return errExecAbort
+	}
+
 	if len(transactionState.queuedCommands) == 0 {
 		RemoveConnectionTransactionState(connection)
 		return errExecWithoutQueuedCommands
 	}
 
-	responses := make([]string, 0, len(transactionState.queuedCommands))
+	responses := make([]Reply, 0, len(transactionState.queuedCommands))
 	for _, queuedCommand := range transactionState.queuedCommands {
 		responses = append(responses, executeConnectionCommand(connection, queuedCommand))
 	}
@@ -121,17 +170,17 @@ func HandleExec(connection net.Conn, command *RedisCommand) string {
 	return encodeExecResponse(responses)
 }
 
-func parseDiscardCommandArguments(command *RedisCommand) (errorResponse string) {
+func parseDiscardCommandArguments(command *RedisCommand) (errorResponse Reply) {
 	if len(command.Args) != 0 {
-		return "-ERR wrong number of arguments for 'discard' command\r\n"
+		return WrongNumberOfArgumentsReply("discard")
 	}
 
-	return ""
+	return Reply{}
 }
 
-func HandleDiscard(connection net.Conn, command *RedisCommand) string {
+func HandleDiscard(connection net.Conn, command *RedisCommand) Reply {
 	errorResponse := parseDiscardCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
@@ -142,108 +191,122 @@ func HandleDiscard(connection net.Conn, command *RedisCommand) string {
 
 	RemoveConnectionTransactionState(connection)
 
-	return "+OK\r\n"
+	return OKReply()
+}
+
+func encodeExecResponse(responses []Reply) Reply {
+	return ArrayReply(responses...)
 }
 
-func encodeExecResponse(responses []string) string {
+// unknownCommandReply mirrors the Redis error for unknown commands, quoting
+// the name and the first arguments the client sent.
+func unknownCommandReply(command *RedisCommand) Reply {
 	var builder strings.Builder
+	for _, argument := range command.Args {
+		if builder.Len() >= 128 {
+			break
+		}
+		builder.WriteString(fmt.Sprintf("'%s' ", argument))
+	}
 
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(responses)))
-	for _, response := range responses {
-		builder.WriteString(response)
-	}
-
-	return builder.String()
-}
-
-func executeConnectionCommand(connection net.Conn, command *RedisCommand) string {
-	switch command.Type {
-	case CmdECHO:
-		return HandleEcho(command)
-	case CmdSET:
-		return HandleSet(command)
-	case CmdGET:
-		return HandleGet(command)
-	case CmdCONFIG:
-		return HandleConfig(command)
-	case CmdKEYS:
-		return HandleKeys(command)
-	case CmdINFO:
-		return HandleInfo(command)
-	case CmdPING:
-		return HandlePing(connection, command)
-	case CmdREPLCONF:
-		return "+OK\r\n"
-	case CmdPSYNC:
-		return HandlePsync(command, connection)
-	case CmdWAIT:
-		return HandleWait(command)
-	case CmdTYPE:
-		return HandleType(command)
-	case CmdXADD:
-		return HandleXadd(command)
-	case CmdXRANGE:
-		return HandleXrange(command)
-	case CmdXREAD:
-		return HandleXread(command)
-	case CmdINCR:
-		return HandleIncr(command)
-	case CmdRPUSH:
-		return HandleRpush(command)
-	case CmdLPUSH:
-		return HandleLpush(command)
-	case CmdLRANGE:
-		return HandleLrange(command)
-	case CmdLLEN:
-		return HandleLlen(command)
-	case CmdLPOP:
-		return HandleLpop(command)
-	case CmdBLPOP:
-		return HandleBlpop(command)
-	case CmdSUBSCRIBE:
-		return HandleSubscribe(connection, command)
-	case CmdUNSUBSCRIBE:
-		return HandleUnsubscribe(connection, command)
-	case CmdPUBLISH:
-		return HandlePublish(command)
-	case CmdZADD:
-		return HandleZadd(command)
-	case CmdZRANK:
-		return HandleZrank(command)
-	case CmdZRANGE:
-		return HandleZrange(command)
-	case CmdZCARD:
-		return HandleZcard(command)
-	case CmdMULTI:
-		return HandleMulti(connection, command)
-	case CmdEXEC:
-		return HandleExec(connection, command)
-	default:
-		return "-ERR unknown command\r\n"
+	return ErrorReply(fmt.Sprintf("ERR unknown command '%s', with args beginning with: %s", command.Name, builder.String()))
+}
+
+// validateCommand checks a command against the command table before it is
+// executed or queued, returning an error reply for unknown commands and
+// arity mismatches.
+func validateCommand(command *RedisCommand) (spec *CommandSpec, errorResponse Reply) {
+	spec = LookupCommandSpec(command.Type)
+	if spec == nil {
+		return nil, unknownCommandReply(command)
 	}
+
+	if !spec.AcceptsArgumentCount(len(command.Args)) {
+		return nil, WrongNumberOfArgumentsReply(spec.Name)
+	}
+
+	return spec, Reply{}
 }
 
-func HandleConnectionCommand(connection net.Conn, command *RedisCommand) string {
-	if isConnectionInSubscribedMode(connection) && !isCommandAllowedInSubscribedMode(command.Type) {
-		return subscribedModeErrorResponse(command.Type)
+func executeConnectionCommand(connection net.Conn, command *RedisCommand) Reply {
+	spec, errorResponse := validateCommand(command)
+	if !errorResponse.IsEmpty() {
+		recordRejectedCommand(command)
+		return errorResponse
+	}
+	if denied := checkCommandPermission(connection, spec, command); !denied.IsEmpty() {
+		recordRejectedCommand(command)
+		return denied
+	}
+
+	if spec.MayBlock() {
+		// Replies to earlier commands in the batch must not wait behind a
+		// command that can block for a long time.
+		flushConnectionOutput(connection)
+	}
+	if spec.HasFlag(FlagBlocking) {
+		setClientBlocked(connection, true)
+	}
+	start := time.Now()
+	response := spec.Handler(connection, command)
+	duration := time.Since(start)
+	recordCommandCall(spec, duration, response)
+	recordSlowCommand(connection, spec, command, duration)
+	recordCommandLatency(spec, duration)
+	feedMonitors(connection, spec, command)
+	if spec.HasFlag(FlagBlocking) {
+		setClientBlocked(connection, false)
 	}
+	if spec.HasFlag(FlagWrite) && !response.IsError() {
+		changesSinceLastSave.Add(1)
+	}
+	propagateExecutedCommand(connection, spec, command, response)
+
+	return response
+}
 
-	if command.Type == CmdMULTI {
-		return HandleMulti(connection, command)
+// propagateExecutedCommand sends a successfully executed write to replicas.
+// Commands arriving over the master link are never propagated further.
+func propagateExecutedCommand(connection net.Conn, spec *CommandSpec, command *RedisCommand, response Reply) {
+	if !spec.ShouldPropagate() || response.IsError() || IsMasterLinkConnection(connection) {
+		return
 	}
 
-	if command.Type == CmdEXEC {
-		return HandleExec(connection, command)
+	propagated := command
+	if spec.PropagateAs != nil {
+		propagated = spec.PropagateAs(command, response)
+		if propagated == nil {
+			return
+		}
 	}
 
-	if command.Type == CmdDISCARD {
-		return HandleDiscard(connection, command)
+	PropagateCommand(EncodeCommandRESPArray(propagated))
+}
+
+func HandleConnectionCommand(connection net.Conn, command *RedisCommand) Reply {
+	if isAuthenticationRequired(connection, command) {
+		return errNoAuth
+	}
+
+	if isConnectionInSubscribedMode(connection) && !isCommandAllowedInSubscribedMode(command.Type) {
+		return subscribedModeErrorResponse(command.Type)
 	}
 
 	transactionState := getConnectionTransactionState(connection)
-	if transactionState.inTransaction {
-		return queueTransactionCommand(transactionState, command)
+
+	if isTransactionControlCommand(command.Type) || !transactionState.inTransaction {
+		return executeConnectionCommand(connection, command)
+	}
+
+	spec, errorResponse := validateCommand(command)
+	if errorResponse.IsEmpty() {
+		errorResponse = checkCommandPermission(connection, spec, command)
+	}
+	if !errorResponse.IsEmpty() {
+		recordRejectedCommand(command)
+		transactionState.hasQueueErrors = true
+		return errorResponse
 	}
 
-	return executeConnectionCommand(connection, command)
+	return queueTransactionCommand(transactionState, command)
 }
@@ -42,8 +42,8 @@ func TestParseMultiCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseMultiCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseMultiCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 		})
 	}
@@ -56,7 +56,7 @@ func TestHandleMultiReturnsOK(t *testing.T) {
 	result := HandleMulti(connection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{},
-	})
+	}).String()
 
 	if result != "+OK\r\n" {
 		t.Errorf("HandleMulti() = %q, expected %q", result, "+OK\r\n")
@@ -70,7 +70,7 @@ func TestHandleMultiArgumentParsing(t *testing.T) {
 	result := HandleMulti(connection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{"extra"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'multi' command\r\n" {
 		t.Errorf("HandleMulti() = %q, expected %q", result, "-ERR wrong number of arguments for 'multi' command\r\n")
@@ -101,8 +101,8 @@ func TestParseExecCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseExecCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseExecCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 		})
 	}
@@ -115,10 +115,10 @@ func TestHandleExecWithoutMultiReturnsError(t *testing.T) {
 	result := HandleExec(connection, &RedisCommand{
 		Type: CmdEXEC,
 		Args: []string{},
-	})
+	}).String()
 
-	if result != errExecWithoutMulti {
-		t.Errorf("HandleExec() = %q, expected %q", result, errExecWithoutMulti)
+	if result != errExecWithoutMulti.String() {
+		t.Errorf("HandleExec() = %q, expected %q", result, errExecWithoutMulti.String())
 	}
 }
 
@@ -129,7 +129,7 @@ func TestHandleExecEmptyTransaction(t *testing.T) {
 	multiResult := HandleMulti(connection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{},
-	})
+	}).String()
 	if multiResult != "+OK\r\n" {
 		t.Fatalf("HandleMulti() = %q, expected %q", multiResult, "+OK\r\n")
 	}
@@ -137,7 +137,7 @@ func TestHandleExecEmptyTransaction(t *testing.T) {
 	firstExecResult := HandleExec(connection, &RedisCommand{
 		Type: CmdEXEC,
 		Args: []string{},
-	})
+	}).String()
 	if firstExecResult != "*0\r\n" {
 		t.Errorf("first HandleExec() = %q, expected %q", firstExecResult, "*0\r\n")
 	}
@@ -145,15 +145,16 @@ func TestHandleExecEmptyTransaction(t *testing.T) {
 	secondExecResult := HandleExec(connection, &RedisCommand{
 		Type: CmdEXEC,
 		Args: []string{},
-	})
-	if secondExecResult != errExecWithoutMulti {
-		t.Errorf("second HandleExec() = %q, expected %q", secondExecResult, errExecWithoutMulti)
+	}).String()
+	if secondExecResult != errExecWithoutMulti.String() {
+		t.Errorf("second HandleExec() = %q, expected %q", secondExecResult, errExecWithoutMulti.String())
 	}
 }
 
 func resetTransactionTestState(t *testing.T) {
 	t.Helper()
 	GetInstance().cache = make(map[string]CacheItem)
+	GetInstance().expires = nil
 	ResetConnectionTransactionStatesForTest()
 }
 
@@ -166,7 +167,7 @@ func TestTransactionQueuesSetAndIncrWithoutExecutingThem(t *testing.T) {
 	multiResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{},
-	})
+	}).String()
 	if multiResult != "+OK\r\n" {
 		t.Fatalf("HandleConnectionCommand(MULTI) = %q, expected %q", multiResult, "+OK\r\n")
 	}
@@ -174,23 +175,23 @@ func TestTransactionQueuesSetAndIncrWithoutExecutingThem(t *testing.T) {
 	setResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdSET,
 		Args: []string{"foo", "41"},
-	})
-	if setResult != queuedCommandResponse {
-		t.Errorf("HandleConnectionCommand(SET) = %q, expected %q", setResult, queuedCommandResponse)
+	}).String()
+	if setResult != queuedCommandResponse.String() {
+		t.Errorf("HandleConnectionCommand(SET) = %q, expected %q", setResult, queuedCommandResponse.String())
 	}
 
 	incrResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdINCR,
 		Args: []string{"foo"},
-	})
-	if incrResult != queuedCommandResponse {
-		t.Errorf("HandleConnectionCommand(INCR) = %q, expected %q", incrResult, queuedCommandResponse)
+	}).String()
+	if incrResult != queuedCommandResponse.String() {
+		t.Errorf("HandleConnectionCommand(INCR) = %q, expected %q", incrResult, queuedCommandResponse.String())
 	}
 
 	getResult := HandleGet(&RedisCommand{
 		Type: CmdGET,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if getResult != "$-1\r\n" {
 		t.Errorf("HandleGet(foo) = %q, expected %q", getResult, "$-1\r\n")
 	}
@@ -213,7 +214,7 @@ func TestHandleExecExecutesQueuedCommands(t *testing.T) {
 	multiResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{},
-	})
+	}).String()
 	if multiResult != "+OK\r\n" {
 		t.Fatalf("HandleConnectionCommand(MULTI) = %q, expected %q", multiResult, "+OK\r\n")
 	}
@@ -226,16 +227,16 @@ func TestHandleExecExecutesQueuedCommands(t *testing.T) {
 	}
 
 	for _, command := range queuedCommands {
-		result := HandleConnectionCommand(transactionConnection, command)
-		if result != queuedCommandResponse {
-			t.Fatalf("HandleConnectionCommand(%s) = %q, expected %q", command.Type.String(), result, queuedCommandResponse)
+		result := HandleConnectionCommand(transactionConnection, command).String()
+		if result != queuedCommandResponse.String() {
+			t.Fatalf("HandleConnectionCommand(%s) = %q, expected %q", command.Type.String(), result, queuedCommandResponse.String())
 		}
 	}
 
 	execResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdEXEC,
 		Args: []string{},
-	})
+	}).String()
 	expectedExecResult := formatExpectedExecResponse(
 		"+OK\r\n",
 		":7\r\n",
@@ -249,7 +250,7 @@ func TestHandleExecExecutesQueuedCommands(t *testing.T) {
 	getFooResult := HandleGet(&RedisCommand{
 		Type: CmdGET,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if getFooResult != "$1\r\n7\r\n" {
 		t.Errorf("HandleGet(foo) = %q, expected %q", getFooResult, "$1\r\n7\r\n")
 	}
@@ -262,7 +263,7 @@ func TestHandleExecArgumentParsing(t *testing.T) {
 	result := HandleExec(connection, &RedisCommand{
 		Type: CmdEXEC,
 		Args: []string{"extra"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'exec' command\r\n" {
 		t.Errorf("HandleExec() = %q, expected %q", result, "-ERR wrong number of arguments for 'exec' command\r\n")
@@ -293,8 +294,8 @@ func TestParseDiscardCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseDiscardCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseDiscardCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 		})
 	}
@@ -308,7 +309,7 @@ func TestHandleDiscardAbortsQueuedTransaction(t *testing.T) {
 	multiResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdMULTI,
 		Args: []string{},
-	})
+	}).String()
 	if multiResult != "+OK\r\n" {
 		t.Fatalf("HandleConnectionCommand(MULTI) = %q, expected %q", multiResult, "+OK\r\n")
 	}
@@ -316,23 +317,23 @@ func TestHandleDiscardAbortsQueuedTransaction(t *testing.T) {
 	setResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdSET,
 		Args: []string{"foo", "41"},
-	})
-	if setResult != queuedCommandResponse {
-		t.Fatalf("HandleConnectionCommand(SET) = %q, expected %q", setResult, queuedCommandResponse)
+	}).String()
+	if setResult != queuedCommandResponse.String() {
+		t.Fatalf("HandleConnectionCommand(SET) = %q, expected %q", setResult, queuedCommandResponse.String())
 	}
 
 	incrResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdINCR,
 		Args: []string{"foo"},
-	})
-	if incrResult != queuedCommandResponse {
-		t.Fatalf("HandleConnectionCommand(INCR) = %q, expected %q", incrResult, queuedCommandResponse)
+	}).String()
+	if incrResult != queuedCommandResponse.String() {
+		t.Fatalf("HandleConnectionCommand(INCR) = %q, expected %q", incrResult, queuedCommandResponse.String())
 	}
 
 	discardResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdDISCARD,
 		Args: []string{},
-	})
+	}).String()
 	if discardResult != "+OK\r\n" {
 		t.Errorf("HandleConnectionCommand(DISCARD) = %q, expected %q", discardResult, "+OK\r\n")
 	}
@@ -340,7 +341,7 @@ func TestHandleDiscardAbortsQueuedTransaction(t *testing.T) {
 	getResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdGET,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if getResult != "$-1\r\n" {
 		t.Errorf("HandleConnectionCommand(GET foo) = %q, expected %q", getResult, "$-1\r\n")
 	}
@@ -348,9 +349,9 @@ func TestHandleDiscardAbortsQueuedTransaction(t *testing.T) {
 	secondDiscardResult := HandleConnectionCommand(transactionConnection, &RedisCommand{
 		Type: CmdDISCARD,
 		Args: []string{},
-	})
-	if secondDiscardResult != errDiscardWithoutMulti {
-		t.Errorf("second HandleConnectionCommand(DISCARD) = %q, expected %q", secondDiscardResult, errDiscardWithoutMulti)
+	}).String()
+	if secondDiscardResult != errDiscardWithoutMulti.String() {
+		t.Errorf("second HandleConnectionCommand(DISCARD) = %q, expected %q", secondDiscardResult, errDiscardWithoutMulti.String())
 	}
 }
 
@@ -361,10 +362,10 @@ func TestHandleDiscardWithoutMultiReturnsError(t *testing.T) {
 	result := HandleDiscard(connection, &RedisCommand{
 		Type: CmdDISCARD,
 		Args: []string{},
-	})
+	}).String()
 
-	if result != errDiscardWithoutMulti {
-		t.Errorf("HandleDiscard() = %q, expected %q", result, errDiscardWithoutMulti)
+	if result != errDiscardWithoutMulti.String() {
+		t.Errorf("HandleDiscard() = %q, expected %q", result, errDiscardWithoutMulti.String())
 	}
 }
 
@@ -375,7 +376,7 @@ func TestHandleDiscardArgumentParsing(t *testing.T) {
 	result := HandleDiscard(connection, &RedisCommand{
 		Type: CmdDISCARD,
 		Args: []string{"extra"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'discard' command\r\n" {
 		t.Errorf("HandleDiscard() = %q, expected %q", result, "-ERR wrong number of arguments for 'discard' command\r\n")
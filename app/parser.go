@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strings"
@@ -37,8 +38,6 @@ const (
 	CmdBLPOP
 	CmdSUBSCRIBE
 	CmdUNSUBSCRIBE
-	CmdPSUBSCRIBE
-	CmdPUNSUBSCRIBE
 	CmdQUIT
 	CmdRESET
 	CmdPUBLISH
@@ -46,185 +45,97 @@ const (
 	CmdZRANK
 	CmdZRANGE
 	CmdZCARD
+	CmdHELLO
+	CmdCOMMAND
+	CmdSHUTDOWN
+	CmdCLIENT
+	CmdAUTH
+	CmdACL
+	CmdSLOWLOG
+	CmdMONITOR
+	CmdLATENCY
 )
 
 // IsWrite returns true if the command is a write command
 func (c CommandType) IsWrite() bool {
-	switch c {
-	case CmdSET, CmdXADD, CmdRPUSH, CmdLPUSH, CmdLPOP, CmdPUBLISH, CmdZADD:
-		return true
-	default:
-		return false
-	}
+	spec := LookupCommandSpec(c)
+	return spec != nil && spec.HasFlag(FlagWrite)
 }
 
 // String returns the string representation of the command type
 func (c CommandType) String() string {
-	switch c {
-	case CmdPING:
-		return "PING"
-	case CmdECHO:
-		return "ECHO"
-	case CmdSET:
-		return "SET"
-	case CmdGET:
-		return "GET"
-	case CmdCONFIG:
-		return "CONFIG"
-	case CmdKEYS:
-		return "KEYS"
-	case CmdINFO:
-		return "INFO"
-	case CmdINCR:
-		return "INCR"
-	case CmdMULTI:
-		return "MULTI"
-	case CmdEXEC:
-		return "EXEC"
-	case CmdREPLCONF:
-		return "REPLCONF"
-	case CmdPSYNC:
-		return "PSYNC"
-	case CmdWAIT:
-		return "WAIT"
-	case CmdTYPE:
-		return "TYPE"
-	case CmdXADD:
-		return "XADD"
-	case CmdXRANGE:
-		return "XRANGE"
-	case CmdXREAD:
-		return "XREAD"
-	case CmdDISCARD:
-		return "DISCARD"
-	case CmdRPUSH:
-		return "RPUSH"
-	case CmdLRANGE:
-		return "LRANGE"
-	case CmdLPUSH:
-		return "LPUSH"
-	case CmdLLEN:
-		return "LLEN"
-	case CmdLPOP:
-		return "LPOP"
-	case CmdBLPOP:
-		return "BLPOP"
-	case CmdSUBSCRIBE:
-		return "SUBSCRIBE"
-	case CmdUNSUBSCRIBE:
-		return "UNSUBSCRIBE"
-	case CmdPSUBSCRIBE:
-		return "PSUBSCRIBE"
-	case CmdPUNSUBSCRIBE:
-		return "PUNSUBSCRIBE"
-	case CmdQUIT:
-		return "QUIT"
-	case CmdRESET:
-		return "RESET"
-	case CmdPUBLISH:
-		return "PUBLISH"
-	case CmdZADD:
-		return "ZADD"
-	case CmdZRANK:
-		return "ZRANK"
-	case CmdZRANGE:
-		return "ZRANGE"
-	case CmdZCARD:
-		return "ZCARD"
-	default:
+	spec := LookupCommandSpec(c)
+	if spec == nil {
 		return "UNKNOWN"
 	}
+
+	return strings.ToUpper(spec.Name)
 }
 
-// ParseCommandType converts a string command name to CommandType
+// ParseCommandType converts a string command name to CommandType. Commands
+// renamed or disabled by rename-command are unknown under their old names.
 func ParseCommandType(name string) CommandType {
-	switch name {
-	case "PING":
-		return CmdPING
-	case "ECHO":
-		return CmdECHO
-	case "SET":
-		return CmdSET
-	case "GET":
-		return CmdGET
-	case "CONFIG":
-		return CmdCONFIG
-	case "KEYS":
-		return CmdKEYS
-	case "INFO":
-		return CmdINFO
-	case "INCR":
-		return CmdINCR
-	case "MULTI":
-		return CmdMULTI
-	case "EXEC":
-		return CmdEXEC
-	case "DISCARD":
-		return CmdDISCARD
-	case "RPUSH":
-		return CmdRPUSH
-	case "LRANGE":
-		return CmdLRANGE
-	case "LPUSH":
-		return CmdLPUSH
-	case "LLEN":
-		return CmdLLEN
-	case "LPOP":
-		return CmdLPOP
-	case "BLPOP":
-		return CmdBLPOP
-	case "SUBSCRIBE":
-		return CmdSUBSCRIBE
-	case "UNSUBSCRIBE":
-		return CmdUNSUBSCRIBE
-	case "PSUBSCRIBE":
-		return CmdPSUBSCRIBE
-	case "PUNSUBSCRIBE":
-		return CmdPUNSUBSCRIBE
-	case "QUIT":
-		return CmdQUIT
-	case "RESET":
-		return CmdRESET
-	case "PUBLISH":
-		return CmdPUBLISH
-	case "ZADD":
-		return CmdZADD
-	case "ZRANK":
-		return CmdZRANK
-	case "ZRANGE":
-		return CmdZRANGE
-	case "ZCARD":
-		return CmdZCARD
-	case "REPLCONF":
-		return CmdREPLCONF
-	case "PSYNC":
-		return CmdPSYNC
-	case "WAIT":
-		return CmdWAIT
-	case "TYPE":
-		return CmdTYPE
-	case "XADD":
-		return CmdXADD
-	case "XRANGE":
-		return CmdXRANGE
-	case "XREAD":
-		return CmdXREAD
-	default:
+	spec := lookupClientCommandSpec(name)
+	if spec == nil {
 		return CmdUnknown
 	}
+
+	return spec.Type
 }
 
+// ErrIncompleteCommand is returned by Parse when the buffer holds only the
+// beginning of a command and more bytes must be read before it can be parsed.
+var ErrIncompleteCommand = errors.New("incomplete command")
+
 // RedisCommand represents a parsed Redis command
 type RedisCommand struct {
 	Type CommandType // Specific command type
+	Name string      // Command name as sent by the client
 	Args []string    // Command arguments
 }
 
+const (
+	// DefaultProtoMaxBulkLength is the default proto-max-bulk-len: the
+	// largest bulk string a client may send.
+	DefaultProtoMaxBulkLength = 512 * 1024 * 1024
+	// DefaultMaxMultibulkLength is the largest argument count a client may
+	// announce for one command.
+	DefaultMaxMultibulkLength = 1024 * 1024
+	// maxInlineLength bounds inline commands and length lines that have not
+	// been terminated yet, like Redis's PROTO_INLINE_MAX_SIZE.
+	maxInlineLength = 64 * 1024
+)
+
+// ProtocolError reports client input that is not valid RESP. Redis answers
+// it with an error reply and closes the connection.
+type ProtocolError struct {
+	Reason string
+}
+
+func (e *ProtocolError) Error() string {
+	return "Protocol error: " + e.Reason
+}
+
+func newProtocolError(format string, args ...any) error {
+	return &ProtocolError{Reason: fmt.Sprintf(format, args...)}
+}
+
 type RESPParser struct {
+	maxBulkLength      int64
+	maxMultibulkLength int64
 }
 
 func NewRESPParser() *RESPParser {
-	return &RESPParser{}
+	return NewRESPParserWithLimits(DefaultProtoMaxBulkLength, DefaultMaxMultibulkLength)
+}
+
+// NewRESPParserWithLimits returns a parser that rejects bulk strings longer
+// than maxBulkLength and commands with more than maxMultibulkLength arguments.
+func NewRESPParserWithLimits(maxBulkLength int64, maxMultibulkLength int64) *RESPParser {
+	return &RESPParser{
+		maxBulkLength:      maxBulkLength,
+		maxMultibulkLength: maxMultibulkLength,
+	}
 }
 
 func (p *RESPParser) isCRLF(data []byte, pos int) bool {
@@ -242,32 +153,37 @@ func (p *RESPParser) findLengthEnd(data []byte, startPos int) int {
 	return -1 // no CRLF found
 }
 
-func (p *RESPParser) getBulkStringLength(data []byte, startPos int, endPos int) (int, error) {
+// parseLength parses the decimal length between startPos and endPos. It
+// accepts -1 and values up to maxLength, and reports false for anything
+// else, including values that would overflow.
+func (p *RESPParser) parseLength(data []byte, startPos int, endPos int, maxLength int64) (int, bool) {
 	if endPos <= startPos {
-		return 0, errors.New("invalid length field")
+		return 0, false
 	}
 
 	lengthStr := string(data[startPos:endPos])
 	if lengthStr == "-1" {
-		return -1, nil
+		return -1, true
 	}
 
-	// Check if all characters are digits
+	var length int64
 	for _, char := range lengthStr {
 		if char < '0' || char > '9' {
-			return 0, errors.New("invalid length field")
+			return 0, false
 		}
-	}
 
-	// Convert to int (simple conversion since we know it's digits)
-	length := 0
-	for _, char := range lengthStr {
-		length = length*10 + int(char-'0')
+		length = length*10 + int64(char-'0')
+		if length > maxLength {
+			return 0, false
+		}
 	}
 
-	return length, nil
+	return int(length), true
 }
 
+// Parse parses the first command in data and returns it together with the number
+// of bytes it occupied. It returns ErrIncompleteCommand when data ends before the
+// command does, so callers can wait for more bytes instead of dropping the frame.
 func (p *RESPParser) Parse(data []byte) (*RedisCommand, int, error) {
 	// For now, only handle Redis command arrays (*...)
 	if len(data) == 0 {
@@ -275,91 +191,302 @@ func (p *RESPParser) Parse(data []byte) (*RedisCommand, int, error) {
 	}
 
 	if data[0] != '*' {
-		return nil, 0, errors.New("expected array for Redis command")
+		return p.parseInline(data)
 	}
 
 	return p.parseArray(data)
 }
 
-// parseArray parses a Redis command array (*...)
-func (p *RESPParser) parseArray(data []byte) (*RedisCommand, int, error) {
-	// Parse array length: *<count>\r\n
-	lengthEndPos := p.findLengthEnd(data, 1)
-	if lengthEndPos == -1 {
-		return nil, 0, errors.New("invalid array: no CRLF found after length")
+// parseInline parses an inline command: a single line of space-separated
+// arguments terminated by \n or \r\n, as typed into telnet or nc. A blank line
+// yields a nil command but still reports the bytes it consumed.
+func (p *RESPParser) parseInline(data []byte) (*RedisCommand, int, error) {
+	newlinePos := bytes.IndexByte(data, '\n')
+	if newlinePos == -1 {
+		if len(data) > maxInlineLength {
+			return nil, 0, newProtocolError("too big inline request")
+		}
+		return nil, 0, ErrIncompleteCommand
+	}
+
+	line := data[:newlinePos]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
 	}
 
-	// Parse the array length
-	arrayLength, err := p.getBulkStringLength(data, 1, lengthEndPos)
+	arguments, err := splitInlineArguments(string(line))
 	if err != nil {
-		return nil, 0, fmt.Errorf("invalid array length: %v", err)
+		return nil, 0, err
+	}
+
+	if len(arguments) == 0 {
+		return nil, newlinePos + 1, nil
+	}
+
+	return &RedisCommand{
+		Type: ParseCommandType(arguments[0]),
+		Name: arguments[0],
+		Args: arguments[1:],
+	}, newlinePos + 1, nil
+}
+
+// splitInlineArguments splits an inline command line the way Redis does:
+// arguments are separated by whitespace, double-quoted arguments understand
+// backslash escapes (\n, \r, \t, \b, \a, \xHH, \", \\), single-quoted
+// arguments only understand \', and a closing quote must be followed by
+// whitespace or the end of the line.
+func splitInlineArguments(line string) ([]string, error) {
+	arguments := []string{}
+	pos := 0
+
+	for {
+		for pos < len(line) && isInlineSpace(line[pos]) {
+			pos++
+		}
+		if pos >= len(line) {
+			return arguments, nil
+		}
+
+		var current strings.Builder
+		inDoubleQuotes := false
+		inSingleQuotes := false
+		done := false
+
+		for !done {
+			if pos >= len(line) {
+				if inDoubleQuotes || inSingleQuotes {
+					return nil, errUnbalancedQuotes
+				}
+				break
+			}
+
+			character := line[pos]
+			switch {
+			case inDoubleQuotes:
+				if character == '\\' && pos+3 < len(line) && line[pos+1] == 'x' &&
+					isHexDigit(line[pos+2]) && isHexDigit(line[pos+3]) {
+					current.WriteByte(hexDigitValue(line[pos+2])<<4 | hexDigitValue(line[pos+3]))
+					pos += 3
+				} else if character == '\\' && pos+1 < len(line) {
+					pos++
+					switch line[pos] {
+					case 'n':
+						current.WriteByte('\n')
+					case 'r':
+						current.WriteByte('\r')
+					case 't':
+						current.WriteByte('\t')
+					case 'b':
+						current.WriteByte('\b')
+					case 'a':
+						current.WriteByte('\a')
+					default:
+						current.WriteByte(line[pos])
+					}
+				} else if character == '"' {
+					if pos+1 < len(line) && !isInlineSpace(line[pos+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				} else {
+					current.WriteByte(character)
+				}
+			case inSingleQuotes:
+				if character == '\\' && pos+1 < len(line) && line[pos+1] == '\'' {
+					pos++
+					current.WriteByte('\'')
+				} else if character == '\'' {
+					if pos+1 < len(line) && !isInlineSpace(line[pos+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				} else {
+					current.WriteByte(character)
+				}
+			default:
+				switch character {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inDoubleQuotes = true
+				case '\'':
+					inSingleQuotes = true
+				default:
+					current.WriteByte(character)
+				}
+			}
+
+			if pos < len(line) {
+				pos++
+			}
+		}
+
+		arguments = append(arguments, current.String())
+	}
+}
+
+var errUnbalancedQuotes = &ProtocolError{Reason: "unbalanced quotes in request"}
+
+func isInlineSpace(character byte) bool {
+	return character == ' ' || character == '\n' || character == '\r' || character == '\t' || character == 0
+}
+
+func isHexDigit(character byte) bool {
+	return (character >= '0' && character <= '9') ||
+		(character >= 'a' && character <= 'f') ||
+		(character >= 'A' && character <= 'F')
+}
+
+func hexDigitValue(character byte) byte {
+	switch {
+	case character >= '0' && character <= '9':
+		return character - '0'
+	case character >= 'a' && character <= 'f':
+		return character - 'a' + 10
+	default:
+		return character - 'A' + 10
 	}
+}
+
+// EncodeCommandRESPArray encodes a command as a RESP array of bulk strings,
+// the form replicas expect regardless of how the client sent it.
+func EncodeCommandRESPArray(command *RedisCommand) []byte {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("*%d\r\n", len(command.Args)+1))
+	name := commandWireName(command.Type)
+	builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(name), name))
+	for _, argument := range command.Args {
+		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(argument), argument))
+	}
+
+	return []byte(builder.String())
+}
+
+// multibulkState is the progress through an array that has not fully
+// arrived: its announced length, the elements parsed so far, how many bytes
+// of the buffer those take, and how many bytes the buffer must hold before
+// the next element can complete. Keeping it between attempts makes each
+// byte of a large command parsed and copied only once.
+type multibulkState struct {
+	started  bool
+	length   int
+	elements []string
+	position int
+	needed   int
+}
+
+// parseArray parses a Redis command array (*...). An empty or null array
+// yields a nil command but still reports the bytes it consumed.
+func (p *RESPParser) parseArray(data []byte) (*RedisCommand, int, error) {
+	var state multibulkState
+	return p.parseArrayResumable(data, &state)
+}
 
-	if arrayLength <= 0 {
-		return nil, 0, errors.New("array must have at least one element")
+// parseArrayResumable is parseArray continuing from state, which it updates
+// when data holds only part of the array. state must be reset once a command
+// or an error is returned, and data must start where it did on the previous
+// attempt.
+func (p *RESPParser) parseArrayResumable(data []byte, state *multibulkState) (*RedisCommand, int, error) {
+	if len(data) < state.needed {
+		return nil, 0, ErrIncompleteCommand
 	}
 
-	// Parse each element (all should be bulk strings for Redis commands)
-	elements := make([]string, arrayLength)
-	pos := lengthEndPos + 2 // Start after the array length CRLF
+	if !state.started {
+		// Parse array length: *<count>\r\n
+		lengthEndPos := p.findLengthEnd(data, 1)
+		if lengthEndPos == -1 {
+			if len(data) > maxInlineLength {
+				return nil, 0, newProtocolError("too big mbulk count string")
+			}
+			return nil, 0, ErrIncompleteCommand
+		}
+
+		arrayLength, valid := p.parseLength(data, 1, lengthEndPos, p.maxMultibulkLength)
+		if !valid {
+			return nil, 0, newProtocolError("invalid multibulk length")
+		}
+
+		pos := lengthEndPos + 2 // Start after the array length CRLF
+		if arrayLength <= 0 {
+			return nil, pos, nil
+		}
+
+		// The announced length is not trusted for preallocation.
+		*state = multibulkState{
+			started:  true,
+			length:   arrayLength,
+			elements: make([]string, 0, min(arrayLength, 1024)),
+			position: pos,
+		}
+	}
 
-	for i := 0; i < arrayLength; i++ {
+	// Parse each element (all should be bulk strings for Redis commands).
+	for len(state.elements) < state.length {
+		pos := state.position
 		if pos >= len(data) {
-			return nil, 0, errors.New("unexpected end of array data")
+			state.needed = pos + 1
+			return nil, 0, ErrIncompleteCommand
 		}
 
 		// Each element should be a bulk string starting with $
 		if data[pos] != '$' {
-			return nil, 0, fmt.Errorf("expected bulk string at position %d, got %c", pos, data[pos])
+			return nil, 0, newProtocolError("expected '$', got '%c'", data[pos])
 		}
 
 		// Parse the bulk string element
 		element, newPos, err := p.parseBulkStringElement(data, pos)
+		if err == ErrIncompleteCommand {
+			state.needed = max(newPos, pos+1)
+			return nil, 0, err
+		}
 		if err != nil {
-			return nil, 0, fmt.Errorf("error parsing array element %d: %v", i, err)
+			return nil, 0, err
 		}
 
-		elements[i] = element
-		pos = newPos
-	}
-
-	// Create the command - first element is command name, rest are args
-	if len(elements) == 0 {
-		return nil, 0, errors.New("empty command array")
+		state.elements = append(state.elements, element)
+		state.position = newPos
 	}
 
-	cmdType := ParseCommandType(strings.ToUpper(elements[0]))
+	elements := state.elements
 	return &RedisCommand{
-		Type: cmdType,
+		Type: ParseCommandType(elements[0]),
+		Name: elements[0],
 		Args: elements[1:],
-	}, pos, nil
+	}, state.position, nil
 }
 
-// parseBulkStringElement parses a single bulk string and returns the value and new position
+// parseBulkStringElement parses a single bulk string and returns the value and
+// new position. When only the element's data is missing it returns
+// ErrIncompleteCommand with the position the element will end at.
 func (p *RESPParser) parseBulkStringElement(data []byte, startPos int) (string, int, error) {
 	// Find end of length field
 	lengthEndPos := p.findLengthEnd(data, startPos+1)
 	if lengthEndPos == -1 {
-		return "", 0, errors.New("no CRLF found after bulk string length")
-	}
-
-	// Parse length
-	length, err := p.getBulkStringLength(data, startPos+1, lengthEndPos)
-	if err != nil {
-		return "", 0, fmt.Errorf("invalid bulk string length: %v", err)
+		if len(data)-startPos > maxInlineLength {
+			return "", 0, newProtocolError("too big bulk count string")
+		}
+		return "", 0, ErrIncompleteCommand
 	}
 
-	if length == -1 {
-		// Null bulk string - return empty string and skip to end
-		return "", lengthEndPos + 2, nil
+	// Parse length. Command arguments cannot be null, so unlike the
+	// multibulk count a -1 bulk length is rejected as Redis does.
+	length, valid := p.parseLength(data, startPos+1, lengthEndPos, p.maxBulkLength)
+	if !valid || length < 0 {
+		return "", 0, newProtocolError("invalid bulk length")
 	}
 
 	// Extract the string data
 	dataStartPos := lengthEndPos + 2
 	dataEndPos := dataStartPos + length
 
+	// The caller may wait until the whole element has arrived.
 	if dataEndPos+2 > len(data) {
-		return "", 0, errors.New("insufficient data for bulk string")
+		return "", dataEndPos + 2, ErrIncompleteCommand
+	}
+
+	if !p.isCRLF(data, dataEndPos) {
+		return "", 0, newProtocolError("bulk string is not terminated by CRLF")
 	}
 
 	value := string(data[dataStartPos:dataEndPos])
@@ -98,11 +98,29 @@ func TestParse_RedisCommands(t *testing.T) {
 			hasError: false,
 		},
 		{
-			name:     "invalid - not an array",
-			input:    "$4\r\ntest\r\n",
+			name:     "invalid - inline command with unbalanced quotes",
+			input:    "SET \"key value\r\n",
 			expected: nil,
 			hasError: true,
 		},
+		{
+			name:  "inline PING command",
+			input: "PING\r\n",
+			expected: &RedisCommand{
+				Type: CmdPING,
+				Args: []string{},
+			},
+			hasError: false,
+		},
+		{
+			name:  "inline SET command terminated by newline only",
+			input: "set key value\n",
+			expected: &RedisCommand{
+				Type: CmdSET,
+				Args: []string{"key", "value"},
+			},
+			hasError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,3 +157,92 @@ func TestParseCommandType_WAITReturnsKnownCommand(t *testing.T) {
 		t.Fatalf("expected WAIT to parse as CmdWAIT, got %v", commandType)
 	}
 }
+
+func TestParse_IncompleteCommandReturnsErrIncompleteCommand(t *testing.T) {
+	parser := NewRESPParser()
+
+	inputs := []string{
+		"*2",
+		"*2\r\n",
+		"*2\r\n$4\r\nECHO\r\n",
+		"*2\r\n$4\r\nECHO\r\n$5",
+		"*2\r\n$4\r\nECHO\r\n$5\r\nhel",
+		"*2\r\n$4\r\nECHO\r\n$5\r\nhello\r",
+	}
+
+	for _, input := range inputs {
+		result, consumed, err := parser.Parse([]byte(input))
+		if err != ErrIncompleteCommand {
+			t.Errorf("Parse(%q) error = %v, expected ErrIncompleteCommand", input, err)
+		}
+		if result != nil || consumed != 0 {
+			t.Errorf("Parse(%q) = %v, %d, expected nil, 0", input, result, consumed)
+		}
+	}
+}
+
+func TestSplitInlineArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected []string
+		hasError bool
+	}{
+		{name: "plain words", line: "SET  key   value", expected: []string{"SET", "key", "value"}},
+		{name: "empty line", line: "   ", expected: []string{}},
+		{name: "double quotes keep spaces", line: `ECHO "hello world"`, expected: []string{"ECHO", "hello world"}},
+		{name: "double quote escapes", line: `ECHO "a\tb\n\x41\"c\\"`, expected: []string{"ECHO", "a\tb\nA\"c\\"}},
+		{name: "single quotes are literal", line: `ECHO 'a\nb\'c'`, expected: []string{"ECHO", "a\\nb'c"}},
+		{name: "empty quoted argument", line: `SET key ""`, expected: []string{"SET", "key", ""}},
+		{name: "unterminated double quote", line: `ECHO "abc`, hasError: true},
+		{name: "unterminated single quote", line: `ECHO 'abc`, hasError: true},
+		{name: "closing quote followed by text", line: `ECHO "abc"def`, hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments, err := splitInlineArguments(tt.line)
+			if tt.hasError {
+				if err == nil {
+					t.Fatalf("splitInlineArguments(%q) returned no error", tt.line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitInlineArguments(%q) returned error: %v", tt.line, err)
+			}
+			if len(arguments) != len(tt.expected) {
+				t.Fatalf("splitInlineArguments(%q) = %q, expected %q", tt.line, arguments, tt.expected)
+			}
+			for index := range arguments {
+				if arguments[index] != tt.expected[index] {
+					t.Errorf("argument %d = %q, expected %q", index, arguments[index], tt.expected[index])
+				}
+			}
+		})
+	}
+}
+
+func TestParse_InlineBlankLineIsConsumed(t *testing.T) {
+	parser := NewRESPParser()
+
+	result, consumed, err := parser.Parse([]byte("\r\nPING\r\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if result != nil || consumed != 2 {
+		t.Errorf("Parse() = %v, %d, expected nil, 2", result, consumed)
+	}
+}
+
+func TestEncodeCommandRESPArray(t *testing.T) {
+	encoded := EncodeCommandRESPArray(&RedisCommand{
+		Type: CmdSET,
+		Args: []string{"key", "value"},
+	})
+
+	expected := "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n"
+	if string(encoded) != expected {
+		t.Errorf("EncodeCommandRESPArray() = %q, expected %q", encoded, expected)
+	}
+}
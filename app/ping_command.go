@@ -2,11 +2,11 @@ package main
 
 import "net"
 
-const normalPingResponse = "+PONG\r\n"
+var normalPingResponse = SimpleStringReply("PONG")
 
-const subscribedModePingResponse = "*2\r\n$4\r\npong\r\n$0\r\n\r\n"
+var subscribedModePingResponse = BulkStringArrayReply([]string{"pong", ""})
 
-func HandlePing(connection net.Conn, command *RedisCommand) string {
+func HandlePing(connection net.Conn, command *RedisCommand) Reply {
 	if isConnectionInSubscribedMode(connection) {
 		return subscribedModePingResponse
 	}
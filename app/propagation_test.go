@@ -63,6 +63,20 @@ func TestCommandPropagation(t *testing.T) {
 	}
 }
 
+func TestPropagateCommandDropsReplicaAfterEnqueueError(t *testing.T) {
+	resetClientTestState(t)
+	replicaConnection, _ := testConnectionPair(t)
+	RegisterReplica(replicaConnection)
+	// Tearing down the output is what makes every later enqueue fail.
+	RemoveConnectionWriteMutex(replicaConnection)
+
+	PropagateCommand([]byte("*1\r\n$4\r\nPING\r\n"))
+
+	if count := ReplicaCount(); count != 0 {
+		t.Errorf("ReplicaCount() = %d, expected the failing replica to be dropped", count)
+	}
+}
+
 func TestIsWriteCommand(t *testing.T) {
 	tests := []struct {
 		cmdType  CommandType
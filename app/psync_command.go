@@ -4,13 +4,15 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 )
 
 // HandlePsync processes the PSYNC command and returns a FULLRESYNC response followed by an empty RDB file
-func HandlePsync(cmd *RedisCommand, conn net.Conn) string {
+func HandlePsync(cmd *RedisCommand, conn net.Conn) Reply {
 	config := GetConfig()
 	// 1. The master responds with +FULLRESYNC <REPL_ID> <OFFSET>\r\n
-	fullResyncResp := fmt.Sprintf("+FULLRESYNC %s %d\r\n", config.MasterReplId, config.MasterReplOffset)
+	fullResyncResp := SimpleStringReply(fmt.Sprintf("FULLRESYNC %s %d", config.MasterReplId, config.MasterReplOffset))
 
 	// 2. The master sends an empty RDB file
 	// Hex for a minimal empty RDB file
@@ -18,10 +20,39 @@ func HandlePsync(cmd *RedisCommand, conn net.Conn) string {
 	rdbBytes, _ := hex.DecodeString(rdbHex)
 
 	// Format: $<length>\r\n<contents> (no trailing \r\n)
-	rdbResp := fmt.Sprintf("$%d\r\n%s", len(rdbBytes), string(rdbBytes))
+	rdbResp := BulkPayloadReply(string(rdbBytes))
 
-	// Register this connection as a replica for future propagation
+	return SequenceReply(fullResyncResp, rdbResp)
+}
+
+// HandleReplconf acknowledges the replication handshake options a replica
+// sends, remembering the port it announces for INFO replication.
+func HandleReplconf(conn net.Conn, cmd *RedisCommand) Reply {
+	if len(cmd.Args) == 2 && strings.EqualFold(cmd.Args[0], "listening-port") {
+		if port, err := strconv.Atoi(cmd.Args[1]); err == nil {
+			updateClient(conn, func(client *Client) {
+				client.ReplicaListeningPort = port
+			})
+		}
+	}
+
+	return OKReply()
+}
+
+// startReplicaStream queues the PSYNC reply and then registers the connection
+// as a replica, so that propagated commands are queued only after the RDB
+// payload the replica expects first.
+func startReplicaStream(conn net.Conn, reply Reply) Reply {
+	if reply.IsError() {
+		return reply
+	}
+
+	getConnectionOutput(conn)
+	if err := EnqueueReplyToConnection(conn, reply); err != nil {
+		LogWarning("Error sending RDB payload to replica %s: %v", conn.RemoteAddr(), err)
+		return Reply{}
+	}
 	RegisterReplica(conn)
 
-	return fullResyncResp + rdbResp
+	return Reply{}
 }
@@ -1,54 +1,62 @@
 package main
 
-import "fmt"
+import "net"
 
-func parsePublishCommandArguments(command *RedisCommand) (channel string, message string, errorResponse string) {
+func parsePublishCommandArguments(command *RedisCommand) (channel string, message string, errorResponse Reply) {
 	if len(command.Args) != 2 {
-		return "", "", "-ERR wrong number of arguments for 'publish' command\r\n"
+		return "", "", WrongNumberOfArgumentsReply("publish")
 	}
 
-	return command.Args[0], command.Args[1], ""
+	return command.Args[0], command.Args[1], Reply{}
 }
 
-func encodePubSubMessageResponse(channel string, message string) string {
-	return fmt.Sprintf(
-		"*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
-		len(channel),
-		channel,
-		len(message),
-		message,
+func encodePubSubMessageResponse(channel string, message string) Reply {
+	return PushReply(
+		BulkStringReply("message"),
+		BulkStringReply(channel),
+		BulkStringReply(message),
 	)
 }
 
+// deliverPublishedMessage queues the message for every subscriber. The
+// subscriber list is copied so that no lock is held while delivering, and
+// delivery only queues output: a slow subscriber falls behind on its own
+// until its client-output-buffer-limit disconnects it.
 func deliverPublishedMessage(channel string, message string) int {
-	connectionPubSubMutex.Lock()
-	defer connectionPubSubMutex.Unlock()
-
 	encodedMessage := encodePubSubMessageResponse(channel, message)
-	subscriberCount := 0
 
-	for connection, pubSubState := range connectionPubSubStates {
-		if _, subscribed := pubSubState.subscribedChannels[channel]; !subscribed {
-			continue
+	subscribers := channelSubscribers(channel)
+	for _, connection := range subscribers {
+		writeError := EnqueueReplyToConnection(connection, encodedMessage)
+		if writeError != nil {
+			LogVerbose("Error delivering message to connection: %s", writeError.Error())
 		}
+	}
 
-		subscriberCount++
-		writeError := WriteToConnection(connection, encodedMessage)
-		if writeError != nil {
-			fmt.Printf("Error delivering message to connection: %s\n", writeError.Error())
+	return len(subscribers)
+}
+
+func channelSubscribers(channel string) []net.Conn {
+	connectionPubSubMutex.Lock()
+	defer connectionPubSubMutex.Unlock()
+
+	var subscribers []net.Conn
+	for connection, pubSubState := range connectionPubSubStates {
+		if _, subscribed := pubSubState.subscribedChannels[channel]; subscribed {
+			subscribers = append(subscribers, connection)
 		}
 	}
 
-	return subscriberCount
+	return subscribers
 }
 
-func HandlePublish(command *RedisCommand) string {
+func HandlePublish(command *RedisCommand) Reply {
 	channel, message, errorResponse := parsePublishCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	subscriberCount := deliverPublishedMessage(channel, message)
 
-	return fmt.Sprintf(":%d\r\n", subscriberCount)
+	return IntegerReply(int64(subscriberCount))
 }
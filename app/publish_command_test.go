@@ -47,7 +47,7 @@ func readAvailableBytes(connection net.Conn) ([]byte, error) {
 }
 
 func formatExpectedPubSubMessageResponse(channel string, message string) string {
-	return encodePubSubMessageResponse(channel, message)
+	return encodePubSubMessageResponse(channel, message).String()
 }
 
 func TestParsePublishCommandArguments(t *testing.T) {
@@ -83,8 +83,8 @@ func TestParsePublishCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parsePublishCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parsePublishCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if channel != testCase.expectedChannel {
@@ -104,7 +104,7 @@ func TestHandlePublishReturnsZeroWhenNoSubscribers(t *testing.T) {
 	result := HandlePublish(&RedisCommand{
 		Type: CmdPUBLISH,
 		Args: []string{"bar", "msg"},
-	})
+	}).String()
 
 	if result != ":0\r\n" {
 		t.Errorf("HandlePublish() = %q, expected %q", result, ":0\r\n")
@@ -137,7 +137,7 @@ func TestHandlePublishCodecraftersStageExample(t *testing.T) {
 	barResult := HandlePublish(&RedisCommand{
 		Type: CmdPUBLISH,
 		Args: []string{"bar", "msg"},
-	})
+	}).String()
 	if barResult != ":2\r\n" {
 		t.Errorf("HandlePublish(bar) = %q, expected %q", barResult, ":2\r\n")
 	}
@@ -145,7 +145,7 @@ func TestHandlePublishCodecraftersStageExample(t *testing.T) {
 	fooResult := HandlePublish(&RedisCommand{
 		Type: CmdPUBLISH,
 		Args: []string{"foo", "msg"},
-	})
+	}).String()
 	if fooResult != ":1\r\n" {
 		t.Errorf("HandlePublish(foo) = %q, expected %q", fooResult, ":1\r\n")
 	}
@@ -169,7 +169,7 @@ func TestHandlePublishCountsOneClientSubscribedToMultipleChannelsOncePerChannel(
 	fooResult := HandlePublish(&RedisCommand{
 		Type: CmdPUBLISH,
 		Args: []string{"foo", "msg"},
-	})
+	}).String()
 	if fooResult != ":1\r\n" {
 		t.Errorf("HandlePublish(foo) = %q, expected %q", fooResult, ":1\r\n")
 	}
@@ -177,7 +177,7 @@ func TestHandlePublishCountsOneClientSubscribedToMultipleChannelsOncePerChannel(
 	barResult := HandlePublish(&RedisCommand{
 		Type: CmdPUBLISH,
 		Args: []string{"bar", "msg"},
-	})
+	}).String()
 	if barResult != ":1\r\n" {
 		t.Errorf("HandlePublish(bar) = %q, expected %q", barResult, ":1\r\n")
 	}
@@ -210,7 +210,7 @@ func TestHandlePublishDeliversMessageToSubscribedClients(t *testing.T) {
 		resultChannel <- HandlePublish(&RedisCommand{
 			Type: CmdPUBLISH,
 			Args: []string{"foo", "hello"},
-		})
+		}).String()
 	}()
 
 	firstClientMessage, readError := readAvailableBytes(firstClientConnection)
@@ -267,7 +267,7 @@ func TestHandlePublishDeliversMessageOnlyToMatchingChannelSubscribers(t *testing
 		resultChannel <- HandlePublish(&RedisCommand{
 			Type: CmdPUBLISH,
 			Args: []string{"bar", "world"},
-		})
+		}).String()
 	}()
 
 	barClientMessage, readError := readAvailableBytes(barClientConnection)
@@ -293,3 +293,23 @@ func TestHandlePublishDeliversMessageOnlyToMatchingChannelSubscribers(t *testing
 		t.Fatal("foo client should not receive message for bar channel")
 	}
 }
+
+func TestPublishWhileSubscribersChange(t *testing.T) {
+	resetPublishTestState(t)
+	subscriberConnection, subscriberPeer := testConnectionPair(t)
+	startDrainConnection(subscriberPeer)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for index := 0; index < 200; index++ {
+			HandleSubscribe(subscriberConnection, &RedisCommand{Type: CmdSUBSCRIBE, Args: []string{"news"}})
+			HandleUnsubscribe(subscriberConnection, &RedisCommand{Type: CmdUNSUBSCRIBE, Args: []string{"news"}})
+		}
+	}()
+
+	for index := 0; index < 200; index++ {
+		HandlePublish(&RedisCommand{Type: CmdPUBLISH, Args: []string{"news", "hello"}})
+	}
+	<-done
+}
@@ -0,0 +1,9 @@
+package main
+
+import "net"
+
+// HandleQuit acknowledges QUIT. The event reactor closes the connection once
+// the reply has been written.
+func HandleQuit(connection net.Conn, command *RedisCommand) Reply {
+	return OKReply()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
 )
@@ -151,18 +152,9 @@ func (p *RDBParser) Parse() error {
 			if err != nil {
 				return err
 			}
-			if valueType != 0 {
-				return fmt.Errorf("unsupported value type: %d", valueType)
-			}
-			key, err := p.ReadString()
-			if err != nil {
-				return err
-			}
-			val, err := p.ReadString()
-			if err != nil {
+			if err := p.readEntry(valueType, int64(expiryMs)); err != nil {
 				return err
 			}
-			GetInstance().SetWithExpiry(key, val, int64(expiryMs))
 		case 0xFD: // Expiry in seconds
 			var expirySec uint32
 			err := binary.Read(p.reader, binary.LittleEndian, &expirySec)
@@ -173,33 +165,80 @@ func (p *RDBParser) Parse() error {
 			if err != nil {
 				return err
 			}
-			if valueType != 0 {
-				return fmt.Errorf("unsupported value type: %d", valueType)
-			}
-			key, err := p.ReadString()
-			if err != nil {
+			if err := p.readEntry(valueType, int64(expirySec)*1000); err != nil {
 				return err
 			}
-			val, err := p.ReadString()
-			if err != nil {
+		case 0xFF: // End of file
+			return nil
+		default: // Value type with no expiry
+			if err := p.readEntry(b, 0); err != nil {
 				return err
 			}
-			GetInstance().SetWithExpiry(key, val, int64(expirySec)*1000)
-		case 0x00: // Value type String (no expiry)
-			key, err := p.ReadString()
+		}
+	}
+	return nil
+}
+
+// readEntry reads the key and value of an entry whose type byte has already
+// been consumed and stores it in the cache.
+func (p *RDBParser) readEntry(valueType byte, expirationMs int64) error {
+	key, err := p.ReadString()
+	if err != nil {
+		return err
+	}
+
+	value, err := p.readValue(valueType)
+	if err != nil {
+		return err
+	}
+
+	GetInstance().SetWithExpiry(key, value, expirationMs)
+	return nil
+}
+
+func (p *RDBParser) readValue(valueType byte) (interface{}, error) {
+	switch valueType {
+	case rdbTypeString:
+		return p.ReadString()
+	case rdbTypeList:
+		length, _, err := p.ReadSize()
+		if err != nil {
+			return nil, err
+		}
+		list := &List{Elements: make([]string, 0, length)}
+		for i := uint32(0); i < length; i++ {
+			element, err := p.ReadString()
 			if err != nil {
-				return err
+				return nil, err
 			}
-			val, err := p.ReadString()
+			list.Elements = append(list.Elements, element)
+		}
+		return list, nil
+	case rdbTypeSortedSet:
+		length, _, err := p.ReadSize()
+		if err != nil {
+			return nil, err
+		}
+		sortedSet := newSortedSet()
+		for i := uint32(0); i < length; i++ {
+			member, err := p.ReadString()
 			if err != nil {
-				return err
+				return nil, err
 			}
-			GetInstance().Set(key, val, nil)
-		case 0xFF: // End of file
-			return nil
+			var scoreBits uint64
+			if err := binary.Read(p.reader, binary.LittleEndian, &scoreBits); err != nil {
+				return nil, err
+			}
+			score := math.Float64frombits(scoreBits)
+			sortedSet.memberScores[member] = score
+			sortedSet.orderedIndex.Insert(score, member)
 		}
+		return sortedSet, nil
+	case rdbTypeStreamListpacks, rdbTypeStreamListpacks2, rdbTypeStreamListpacks3:
+		return p.readStream(valueType)
+	default:
+		return nil, fmt.Errorf("unsupported value type: %d", valueType)
 	}
-	return nil
 }
 
 func LoadRDB(path string) error {
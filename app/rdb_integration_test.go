@@ -40,7 +40,7 @@ func TestRDBLoading(t *testing.T) {
 		Type: CmdKEYS,
 		Args: []string{"*"},
 	}
-	response := HandleKeys(cmd)
+	response := HandleKeys(cmd).String()
 	expectedResponse := "*1\r\n$3\r\nfoo\r\n"
 	if response != expectedResponse {
 		t.Errorf("Expected response %q, got %q", expectedResponse, response)
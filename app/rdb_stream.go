@@ -0,0 +1,467 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Streams are stored the way Redis stores them: a radix tree of listpacks,
+// each keyed by the ID of its first ("master") entry, followed by the
+// stream's metadata. Version 11 files use RDB_TYPE_STREAM_LISTPACKS_3; the
+// two older stream types are accepted when loading.
+const (
+	rdbTypeStreamListpacks  = 15
+	rdbTypeStreamListpacks2 = 19
+	rdbTypeStreamListpacks3 = 21
+
+	// streamNodeMaxEntries matches Redis's default stream-node-max-entries.
+	streamNodeMaxEntries = 100
+
+	streamItemFlagDeleted    = 1
+	streamItemFlagSameFields = 2
+
+	listpackHeaderSize = 6
+	listpackEnd        = 0xFF
+)
+
+// streamEntryID is a parsed stream entry ID.
+type streamEntryID struct {
+	milliseconds int64
+	sequence     int64
+}
+
+// WriteLength64 writes a length that may not fit in 32 bits, such as a
+// stream entry ID part.
+func (w *RDBWriter) WriteLength64(length uint64) error {
+	if length <= math.MaxUint32 {
+		return w.WriteSize(uint32(length))
+	}
+
+	if err := w.writer.WriteByte(0x81); err != nil {
+		return err
+	}
+	return binary.Write(w.writer, binary.BigEndian, length)
+}
+
+// writeStream writes the value of a stream key: its listpack nodes, its
+// length, first and last IDs and an empty list of consumer groups.
+func (w *RDBWriter) writeStream(stream *Stream) error {
+	ids := make([]streamEntryID, len(stream.Entries))
+	for index, entry := range stream.Entries {
+		milliseconds, sequence, err := parseEntryID(entry.ID)
+		if err != nil {
+			return err
+		}
+		ids[index] = streamEntryID{milliseconds: milliseconds, sequence: sequence}
+	}
+
+	nodeCount := (len(stream.Entries) + streamNodeMaxEntries - 1) / streamNodeMaxEntries
+	if err := w.WriteSize(uint32(nodeCount)); err != nil {
+		return err
+	}
+	for start := 0; start < len(stream.Entries); start += streamNodeMaxEntries {
+		end := start + streamNodeMaxEntries
+		if end > len(stream.Entries) {
+			end = len(stream.Entries)
+		}
+
+		master := ids[start]
+		nodeKey := make([]byte, 16)
+		binary.BigEndian.PutUint64(nodeKey[:8], uint64(master.milliseconds))
+		binary.BigEndian.PutUint64(nodeKey[8:], uint64(master.sequence))
+		if err := w.WriteString(string(nodeKey)); err != nil {
+			return err
+		}
+		if err := w.WriteString(string(encodeStreamNode(master, stream.Entries[start:end], ids[start:end]))); err != nil {
+			return err
+		}
+	}
+
+	var firstID, lastID streamEntryID
+	if len(ids) > 0 {
+		firstID, lastID = ids[0], ids[len(ids)-1]
+	}
+	for _, length := range []uint64{
+		uint64(len(stream.Entries)),
+		uint64(lastID.milliseconds), uint64(lastID.sequence),
+		uint64(firstID.milliseconds), uint64(firstID.sequence),
+		0, 0, // the largest deleted entry ID; entries are never deleted
+		uint64(len(stream.Entries)), // entries ever added
+		0,                           // consumer groups
+	} {
+		if err := w.WriteLength64(length); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeStreamNode builds the listpack of one node. The master entry has no
+// fields of its own, so every entry carries its field names.
+func encodeStreamNode(master streamEntryID, entries []StreamEntry, ids []streamEntryID) []byte {
+	listpack := &listpackBuilder{}
+	listpack.appendInteger(int64(len(entries))) // count
+	listpack.appendInteger(0)                   // deleted
+	listpack.appendInteger(0)                   // master fields
+	listpack.appendInteger(0)                   // master entry terminator
+
+	for index, entry := range entries {
+		fieldCount := len(entry.FieldValues) / 2
+		listpack.appendInteger(0) // flags
+		listpack.appendInteger(ids[index].milliseconds - master.milliseconds)
+		listpack.appendInteger(ids[index].sequence - master.sequence)
+		listpack.appendInteger(int64(fieldCount))
+		for _, fieldOrValue := range entry.FieldValues[:fieldCount*2] {
+			listpack.appendString(fieldOrValue)
+		}
+		listpack.appendInteger(int64(fieldCount*2 + 4)) // lp-count
+	}
+
+	return listpack.bytes()
+}
+
+// readStream reads a stream value of the given stream type.
+func (p *RDBParser) readStream(valueType byte) (*Stream, error) {
+	nodeCount, _, err := p.ReadSize()
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &Stream{Entries: []StreamEntry{}}
+	for node := uint32(0); node < nodeCount; node++ {
+		nodeKey, err := p.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeKey) != 16 {
+			return nil, fmt.Errorf("stream node key has %d bytes, expected 16", len(nodeKey))
+		}
+		listpack, err := p.ReadString()
+		if err != nil {
+			return nil, err
+		}
+
+		master := streamEntryID{
+			milliseconds: int64(binary.BigEndian.Uint64([]byte(nodeKey[:8]))),
+			sequence:     int64(binary.BigEndian.Uint64([]byte(nodeKey[8:]))),
+		}
+		entries, err := decodeStreamNode(master, []byte(listpack))
+		if err != nil {
+			return nil, err
+		}
+		stream.Entries = append(stream.Entries, entries...)
+	}
+
+	// Length and last ID, then for the newer types the first ID, the largest
+	// deleted ID and the number of entries ever added. The entries say it
+	// all, so the metadata is only skipped.
+	metadataFields := 3
+	if valueType != rdbTypeStreamListpacks {
+		metadataFields += 5
+	}
+	for field := 0; field < metadataFields; field++ {
+		if _, err := p.ReadLength64(); err != nil {
+			return nil, err
+		}
+	}
+
+	groupCount, err := p.ReadLength64()
+	if err != nil {
+		return nil, err
+	}
+	if groupCount > 0 {
+		return nil, fmt.Errorf("stream consumer groups are not supported")
+	}
+
+	return stream, nil
+}
+
+// ReadLength64 reads a length written by WriteLength64.
+func (p *RDBParser) ReadLength64() (uint64, error) {
+	first, err := p.reader.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	if first[0] != 0x81 {
+		length, _, err := p.ReadSize()
+		return uint64(length), err
+	}
+
+	p.reader.ReadByte()
+	var length uint64
+	err = binary.Read(p.reader, binary.BigEndian, &length)
+	return length, err
+}
+
+// decodeStreamNode returns the live entries of one stream listpack.
+func decodeStreamNode(master streamEntryID, listpack []byte) ([]StreamEntry, error) {
+	elements, err := decodeListpack(listpack)
+	if err != nil {
+		return nil, err
+	}
+
+	position := 0
+	next := func() (string, error) {
+		if position >= len(elements) {
+			return "", fmt.Errorf("stream listpack ends in the middle of an entry")
+		}
+		position++
+		return elements[position-1], nil
+	}
+	nextInteger := func() (int64, error) {
+		element, err := next()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(element, 10, 64)
+	}
+
+	// count, deleted, master fields, the master field names and a 0.
+	if _, err := nextInteger(); err != nil {
+		return nil, err
+	}
+	if _, err := nextInteger(); err != nil {
+		return nil, err
+	}
+	masterFieldCount, err := nextInteger()
+	if err != nil {
+		return nil, err
+	}
+	masterFields := make([]string, masterFieldCount)
+	for index := range masterFields {
+		if masterFields[index], err = next(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := next(); err != nil {
+		return nil, err
+	}
+
+	entries := []StreamEntry{}
+	for position < len(elements) {
+		var header [3]int64
+		for index := range header {
+			if header[index], err = nextInteger(); err != nil {
+				return nil, err
+			}
+		}
+		flags := header[0]
+
+		var fieldValues []string
+		if flags&streamItemFlagSameFields != 0 {
+			for _, field := range masterFields {
+				value, err := next()
+				if err != nil {
+					return nil, err
+				}
+				fieldValues = append(fieldValues, field, value)
+			}
+		} else {
+			fieldCount, err := nextInteger()
+			if err != nil {
+				return nil, err
+			}
+			for index := int64(0); index < fieldCount*2; index++ {
+				fieldOrValue, err := next()
+				if err != nil {
+					return nil, err
+				}
+				fieldValues = append(fieldValues, fieldOrValue)
+			}
+		}
+		if _, err := next(); err != nil { // lp-count
+			return nil, err
+		}
+
+		if flags&streamItemFlagDeleted != 0 {
+			continue
+		}
+		fields := make(map[string]string, len(fieldValues)/2)
+		for index := 0; index+1 < len(fieldValues); index += 2 {
+			fields[fieldValues[index]] = fieldValues[index+1]
+		}
+		entries = append(entries, StreamEntry{
+			ID:          fmt.Sprintf("%d-%d", master.milliseconds+header[1], master.sequence+header[2]),
+			Fields:      fields,
+			FieldValues: fieldValues,
+		})
+	}
+
+	return entries, nil
+}
+
+// listpackBuilder encodes a listpack: a 6-byte header, elements each
+// followed by their encoded length so the list can be walked backwards, and
+// a terminating 0xFF.
+type listpackBuilder struct {
+	elements []byte
+	count    int
+}
+
+func (builder *listpackBuilder) appendInteger(value int64) {
+	var encoded []byte
+	switch {
+	case value >= 0 && value <= 127:
+		encoded = []byte{byte(value)}
+	case value >= -4096 && value <= 4095:
+		encoded = []byte{0xC0 | byte(uint16(value)>>8&0x1F), byte(value)}
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		encoded = binary.LittleEndian.AppendUint16([]byte{0xF1}, uint16(value))
+	case value >= math.MinInt32 && value <= math.MaxInt32:
+		encoded = binary.LittleEndian.AppendUint32([]byte{0xF3}, uint32(value))
+	default:
+		encoded = binary.LittleEndian.AppendUint64([]byte{0xF4}, uint64(value))
+	}
+	builder.appendEncoded(encoded)
+}
+
+func (builder *listpackBuilder) appendString(value string) {
+	var encoded []byte
+	switch {
+	case len(value) < 64:
+		encoded = []byte{0x80 | byte(len(value))}
+	case len(value) < 4096:
+		encoded = []byte{0xE0 | byte(len(value)>>8), byte(len(value))}
+	default:
+		encoded = binary.LittleEndian.AppendUint32([]byte{0xF0}, uint32(len(value)))
+	}
+	builder.appendEncoded(append(encoded, value...))
+}
+
+func (builder *listpackBuilder) appendEncoded(encoded []byte) {
+	builder.elements = append(builder.elements, encoded...)
+	builder.elements = append(builder.elements, encodeListpackBacklen(len(encoded))...)
+	builder.count++
+}
+
+func (builder *listpackBuilder) bytes() []byte {
+	totalBytes := listpackHeaderSize + len(builder.elements) + 1
+	count := builder.count
+	if count > math.MaxUint16-1 {
+		count = math.MaxUint16 // unknown; readers count the elements
+	}
+
+	listpack := binary.LittleEndian.AppendUint32(make([]byte, 0, totalBytes), uint32(totalBytes))
+	listpack = binary.LittleEndian.AppendUint16(listpack, uint16(count))
+	listpack = append(listpack, builder.elements...)
+	return append(listpack, listpackEnd)
+}
+
+// encodeListpackBacklen encodes an element length in 7-bit groups, most
+// significant first, with the high bit set on all but the first byte.
+func encodeListpackBacklen(length int) []byte {
+	groups := []byte{byte(length & 127)}
+	for length >>= 7; length > 0; length >>= 7 {
+		groups = append([]byte{byte(length & 127)}, groups...)
+	}
+	for index := 1; index < len(groups); index++ {
+		groups[index] |= 128
+	}
+	return groups
+}
+
+// decodeListpack returns every element of a listpack as a string; integer
+// elements are formatted in decimal.
+func decodeListpack(listpack []byte) ([]string, error) {
+	if len(listpack) < listpackHeaderSize+1 || int(binary.LittleEndian.Uint32(listpack)) != len(listpack) {
+		return nil, fmt.Errorf("invalid listpack header")
+	}
+
+	elements := []string{}
+	position := listpackHeaderSize
+	for {
+		if position >= len(listpack) {
+			return nil, fmt.Errorf("listpack is not terminated")
+		}
+		encoding := listpack[position]
+		if encoding == listpackEnd {
+			return elements, nil
+		}
+
+		element, size, err := decodeListpackElement(listpack[position:])
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+		position += size + len(encodeListpackBacklen(size))
+	}
+}
+
+// decodeListpackElement decodes the element at the start of data and
+// returns it with the size of its encoding, not counting its backlen.
+func decodeListpackElement(data []byte) (string, int, error) {
+	need := func(size int) error {
+		if len(data) < size {
+			return fmt.Errorf("listpack element is truncated")
+		}
+		return nil
+	}
+
+	encoding := data[0]
+	switch {
+	case encoding&0x80 == 0:
+		return strconv.Itoa(int(encoding)), 1, nil
+	case encoding&0xC0 == 0x80:
+		length := int(encoding & 0x3F)
+		if err := need(1 + length); err != nil {
+			return "", 0, err
+		}
+		return string(data[1 : 1+length]), 1 + length, nil
+	case encoding&0xE0 == 0xC0:
+		if err := need(2); err != nil {
+			return "", 0, err
+		}
+		value := int(encoding&0x1F)<<8 | int(data[1])
+		if value >= 1<<12 {
+			value -= 1 << 13
+		}
+		return strconv.Itoa(value), 2, nil
+	case encoding&0xF0 == 0xE0:
+		if err := need(2); err != nil {
+			return "", 0, err
+		}
+		length := int(encoding&0x0F)<<8 | int(data[1])
+		if err := need(2 + length); err != nil {
+			return "", 0, err
+		}
+		return string(data[2 : 2+length]), 2 + length, nil
+	}
+
+	switch encoding {
+	case 0xF0:
+		if err := need(5); err != nil {
+			return "", 0, err
+		}
+		length := int(binary.LittleEndian.Uint32(data[1:]))
+		if err := need(5 + length); err != nil {
+			return "", 0, err
+		}
+		return string(data[5 : 5+length]), 5 + length, nil
+	case 0xF1:
+		if err := need(3); err != nil {
+			return "", 0, err
+		}
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(data[1:])))), 3, nil
+	case 0xF2:
+		if err := need(4); err != nil {
+			return "", 0, err
+		}
+		value := int32(uint32(data[1])<<8|uint32(data[2])<<16|uint32(data[3])<<24) >> 8
+		return strconv.Itoa(int(value)), 4, nil
+	case 0xF3:
+		if err := need(5); err != nil {
+			return "", 0, err
+		}
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(data[1:])))), 5, nil
+	case 0xF4:
+		if err := need(9); err != nil {
+			return "", 0, err
+		}
+		return strconv.FormatInt(int64(binary.LittleEndian.Uint64(data[1:])), 10), 9, nil
+	}
+
+	return "", 0, fmt.Errorf("unknown listpack encoding 0x%02x", encoding)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+const (
+	rdbTypeString    = 0
+	rdbTypeList      = 1
+	rdbTypeSortedSet = 5
+
+	rdbOpcodeAux          = 0xFA
+	rdbOpcodeResizeDB     = 0xFB
+	rdbOpcodeExpireTimeMs = 0xFC
+	rdbOpcodeSelectDB     = 0xFE
+	rdbOpcodeEOF          = 0xFF
+)
+
+type RDBWriter struct {
+	writer *bufio.Writer
+}
+
+func NewRDBWriter(w io.Writer) *RDBWriter {
+	return &RDBWriter{
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// WriteSize writes a size using the RDB length encoding.
+func (w *RDBWriter) WriteSize(size uint32) error {
+	switch {
+	case size < 1<<6:
+		return w.writer.WriteByte(byte(size))
+	case size < 1<<14:
+		_, err := w.writer.Write([]byte{byte(size>>8) | 0x40, byte(size)})
+		return err
+	default:
+		if err := w.writer.WriteByte(0x80); err != nil {
+			return err
+		}
+		return binary.Write(w.writer, binary.BigEndian, size)
+	}
+}
+
+// WriteString writes a length-prefixed string.
+func (w *RDBWriter) WriteString(value string) error {
+	if err := w.WriteSize(uint32(len(value))); err != nil {
+		return err
+	}
+
+	_, err := w.writer.WriteString(value)
+	return err
+}
+
+func (w *RDBWriter) writeAux(key string, value string) error {
+	if err := w.writer.WriteByte(rdbOpcodeAux); err != nil {
+		return err
+	}
+	if err := w.WriteString(key); err != nil {
+		return err
+	}
+	return w.WriteString(value)
+}
+
+// writeObject writes the type byte, key and value of one entry. Values the
+// RDB format written here cannot represent are an error.
+func (w *RDBWriter) writeObject(key string, value interface{}) error {
+	switch typedValue := value.(type) {
+	case string:
+		if err := w.writer.WriteByte(rdbTypeString); err != nil {
+			return err
+		}
+		if err := w.WriteString(key); err != nil {
+			return err
+		}
+		return w.WriteString(typedValue)
+	case *List:
+		if err := w.writer.WriteByte(rdbTypeList); err != nil {
+			return err
+		}
+		if err := w.WriteString(key); err != nil {
+			return err
+		}
+		if err := w.WriteSize(uint32(len(typedValue.Elements))); err != nil {
+			return err
+		}
+		for _, element := range typedValue.Elements {
+			if err := w.WriteString(element); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *SortedSet:
+		if err := w.writer.WriteByte(rdbTypeSortedSet); err != nil {
+			return err
+		}
+		if err := w.WriteString(key); err != nil {
+			return err
+		}
+		if err := w.WriteSize(uint32(typedValue.MemberCount())); err != nil {
+			return err
+		}
+		for member, score := range typedValue.memberScores {
+			if err := w.WriteString(member); err != nil {
+				return err
+			}
+			if err := binary.Write(w.writer, binary.LittleEndian, math.Float64bits(score)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Stream:
+		if err := w.writer.WriteByte(rdbTypeStreamListpacks3); err != nil {
+			return err
+		}
+		if err := w.WriteString(key); err != nil {
+			return err
+		}
+		return w.writeStream(typedValue)
+	default:
+		return fmt.Errorf("can't save key '%s': %s values are not supported in the RDB file yet", key, valueTypeName(value))
+	}
+}
+
+// WriteCache serializes every live key of cache as a version 11 RDB file.
+// The trailing checksum is written as zero, which Redis treats as "not
+// computed".
+func (w *RDBWriter) WriteCache(cache *Cache) error {
+	if _, err := w.writer.WriteString("REDIS0011"); err != nil {
+		return err
+	}
+	if err := w.writeAux("redis-ver", serverVersion); err != nil {
+		return err
+	}
+	if err := w.writeAux("redis-bits", "64"); err != nil {
+		return err
+	}
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	now := CurrentTimeMilliseconds()
+	liveKeys, expiringKeys := 0, 0
+	for _, item := range cache.cache {
+		if item.Expiration > 0 && now >= item.Expiration {
+			continue
+		}
+		liveKeys++
+		if item.Expiration > 0 {
+			expiringKeys++
+		}
+	}
+
+	if _, err := w.writer.Write([]byte{rdbOpcodeSelectDB, 0, rdbOpcodeResizeDB}); err != nil {
+		return err
+	}
+	if err := w.WriteSize(uint32(liveKeys)); err != nil {
+		return err
+	}
+	if err := w.WriteSize(uint32(expiringKeys)); err != nil {
+		return err
+	}
+
+	for key, item := range cache.cache {
+		if item.Expiration > 0 && now >= item.Expiration {
+			continue
+		}
+
+		if item.Expiration > 0 {
+			if err := w.writer.WriteByte(rdbOpcodeExpireTimeMs); err != nil {
+				return err
+			}
+			if err := binary.Write(w.writer, binary.LittleEndian, uint64(item.Expiration)); err != nil {
+				return err
+			}
+		}
+
+		if err := w.writeObject(key, item.Value); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writer.WriteByte(rdbOpcodeEOF); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(make([]byte, 8)); err != nil {
+		return err
+	}
+
+	return w.writer.Flush()
+}
+
+// SaveRDB writes the dataset to path, replacing the previous file only once
+// the new one is completely written and synced.
+func SaveRDB(path string) error {
+	start := time.Now()
+	defer func() { recordLatencyEvent(latencyEventRDBSave, time.Since(start)) }()
+
+	err := replaceFile(path, "temp-*.rdb", 0644, func(file *os.File) error {
+		if err := NewRDBWriter(file).WriteCache(GetInstance()); err != nil {
+			return err
+		}
+
+		fsyncStart := time.Now()
+		err := file.Sync()
+		recordLatencyEvent(latencyEventRDBFsync, time.Since(fsyncStart))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("write error saving DB on disk: %w", err)
+	}
+
+	recordSuccessfulSave()
+	return nil
+}
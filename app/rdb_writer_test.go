@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveRDBRoundTrip(t *testing.T) {
+	cache := GetInstance()
+	cache.cache = make(map[string]CacheItem)
+	defer func() { cache.cache = make(map[string]CacheItem) }()
+
+	expiration := time.Now().Add(time.Hour).UnixMilli()
+	cache.Set("greeting", "hello", nil)
+	cache.SetWithExpiry("session", "token", expiration)
+	cache.SetWithExpiry("expired", "gone", time.Now().Add(-time.Hour).UnixMilli())
+	cache.PushListRight("queue", "a", "b", "c")
+	cache.Zadd("scores", 1.5, "alice")
+	cache.Zadd("scores", -2, "bob")
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := SaveRDB(path); err != nil {
+		t.Fatalf("SaveRDB() error = %v", err)
+	}
+
+	cache.cache = make(map[string]CacheItem)
+	if err := LoadRDB(path); err != nil {
+		t.Fatalf("LoadRDB() error = %v", err)
+	}
+
+	if value := cache.Get("greeting"); value != "hello" {
+		t.Errorf("greeting = %v, expected hello", value)
+	}
+	if item := cache.cache["session"]; item.Value != "token" || item.Expiration != expiration {
+		t.Errorf("session = %+v, expected token expiring at %d", item, expiration)
+	}
+	if _, exists := cache.cache["expired"]; exists {
+		t.Error("expired key was saved")
+	}
+	if list := cache.GetList("queue"); list == nil || !reflect.DeepEqual(list.Elements, []string{"a", "b", "c"}) {
+		t.Errorf("queue = %+v, expected [a b c]", list)
+	}
+
+	sortedSet := cache.GetSortedSet("scores")
+	if sortedSet == nil || sortedSet.MemberCount() != 2 {
+		t.Fatalf("scores = %+v, expected two members", sortedSet)
+	}
+	if rank, _ := sortedSet.GetMemberRank("bob"); rank != 0 {
+		t.Errorf("rank of bob = %d, expected 0", rank)
+	}
+	if score, _ := sortedSet.GetMemberScore("alice"); score != 1.5 {
+		t.Errorf("score of alice = %v, expected 1.5", score)
+	}
+}
+
+func TestSaveRDBKeepsPreviousFileOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "dump.rdb")
+
+	if err := SaveRDB(path); err == nil {
+		t.Fatal("SaveRDB() error = nil, expected an error for a missing directory")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat(%s) error = %v, expected the file not to exist", path, err)
+	}
+}
+
+func TestSaveRDBRoundTripsStreams(t *testing.T) {
+	cache := GetInstance()
+	cache.cache = make(map[string]CacheItem)
+	defer func() { cache.cache = make(map[string]CacheItem) }()
+
+	cache.AddStreamEntry("events", "1-1", []string{"field", "value"})
+	cache.AddStreamEntry("events", "1-2", []string{"a", strings.Repeat("x", 100), "b", "-5000"})
+	for sequence := 0; sequence < 150; sequence++ {
+		cache.AddStreamEntry("events", formatEntryID(1700000000000, int64(sequence)), []string{"n", strconv.Itoa(sequence)})
+	}
+	want := append([]StreamEntry(nil), cache.GetStream("events").Entries...)
+
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	if err := SaveRDB(path); err != nil {
+		t.Fatalf("SaveRDB() error = %v", err)
+	}
+
+	cache.cache = make(map[string]CacheItem)
+	if err := LoadRDB(path); err != nil {
+		t.Fatalf("LoadRDB() error = %v", err)
+	}
+
+	stream := cache.GetStream("events")
+	if stream == nil || !reflect.DeepEqual(stream.Entries, want) {
+		t.Errorf("events = %+v, expected %+v", stream, want)
+	}
+}
+
+func TestEncodeListpack(t *testing.T) {
+	listpack := &listpackBuilder{}
+	listpack.appendInteger(1)
+	listpack.appendString("a")
+
+	want := []byte{0x0C, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x01, 0x81, 'a', 0x02, 0xFF}
+	if got := listpack.bytes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("listpack = % X, expected % X", got, want)
+	}
+
+	elements, err := decodeListpack(want)
+	if err != nil || !reflect.DeepEqual(elements, []string{"1", "a"}) {
+		t.Errorf("decodeListpack() = %q, %v, expected [1 a]", elements, err)
+	}
+}
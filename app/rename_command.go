@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	commandRenamesMutex sync.RWMutex
+	// renamedCommandsByNewName maps a configured new name to the command it
+	// now reaches.
+	renamedCommandsByNewName = make(map[string]*CommandSpec)
+	// renamedCommandNames maps each renamed command to its new name; an empty
+	// name means the command is disabled.
+	renamedCommandNames = make(map[CommandType]string)
+)
+
+// ApplyCommandRenames installs the rename-command directives. Each key is a
+// command name and each value its new name, or "" to disable the command.
+// Renamed commands are unknown under their old names.
+func ApplyCommandRenames(renames map[string]string) error {
+	byNewName := make(map[string]*CommandSpec)
+	names := make(map[CommandType]string)
+
+	oldNames := make([]string, 0, len(renames))
+	for oldName := range renames {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+
+	for _, oldName := range oldNames {
+		newName := strings.ToLower(renames[oldName])
+		spec := LookupCommandSpecByName(oldName)
+		if spec == nil {
+			return fmt.Errorf("no such command '%s' in rename-command", oldName)
+		}
+		if newName != "" {
+			if _, taken := byNewName[newName]; taken {
+				return fmt.Errorf("target command name '%s' already exists", newName)
+			}
+			if existing := LookupCommandSpecByName(newName); existing != nil {
+				if _, alsoRenamed := renames[existing.Name]; !alsoRenamed {
+					return fmt.Errorf("target command name '%s' already exists", newName)
+				}
+			}
+			byNewName[newName] = spec
+		}
+		names[spec.Type] = newName
+	}
+
+	commandRenamesMutex.Lock()
+	renamedCommandsByNewName = byNewName
+	renamedCommandNames = names
+	commandRenamesMutex.Unlock()
+
+	return nil
+}
+
+// lookupClientCommandSpec resolves a command name as a client sends it,
+// honouring rename-command. It returns nil for unknown or disabled commands.
+func lookupClientCommandSpec(name string) *CommandSpec {
+	commandRenamesMutex.RLock()
+	defer commandRenamesMutex.RUnlock()
+
+	lowered := strings.ToLower(name)
+	if spec, renamed := renamedCommandsByNewName[lowered]; renamed {
+		return spec
+	}
+
+	spec := LookupCommandSpecByName(lowered)
+	if spec == nil {
+		return nil
+	}
+	if _, renamed := renamedCommandNames[spec.Type]; renamed {
+		return nil
+	}
+
+	return spec
+}
+
+// commandWireName returns the name a command is sent under, so commands
+// propagated to replicas reach them under the configured rename.
+func commandWireName(commandType CommandType) string {
+	commandRenamesMutex.RLock()
+	defer commandRenamesMutex.RUnlock()
+
+	if newName, renamed := renamedCommandNames[commandType]; renamed && newName != "" {
+		return newName
+	}
+
+	return commandType.String()
+}
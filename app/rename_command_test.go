@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func renameCommandsForTest(t *testing.T, renames map[string]string) {
+	t.Helper()
+	if err := ApplyCommandRenames(renames); err != nil {
+		t.Fatalf("ApplyCommandRenames() error = %v", err)
+	}
+	t.Cleanup(func() { ApplyCommandRenames(nil) })
+}
+
+func parseClientCommand(t *testing.T, args ...string) *RedisCommand {
+	t.Helper()
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+
+	command, _, err := NewRESPParser().Parse([]byte(builder.String()))
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", args, err)
+	}
+	return command
+}
+
+func TestRenamedCommandsResolveOnlyUnderNewName(t *testing.T) {
+	renameCommandsForTest(t, map[string]string{"keys": "", "config": "hidden-config"})
+
+	if commandType := ParseCommandType("KEYS"); commandType != CmdUnknown {
+		t.Errorf("ParseCommandType(KEYS) = %v, expected unknown", commandType)
+	}
+	if commandType := ParseCommandType("CONFIG"); commandType != CmdUnknown {
+		t.Errorf("ParseCommandType(CONFIG) = %v, expected unknown", commandType)
+	}
+	if commandType := ParseCommandType("Hidden-Config"); commandType != CmdCONFIG {
+		t.Errorf("ParseCommandType(Hidden-Config) = %v, expected CONFIG", commandType)
+	}
+	if commandType := ParseCommandType("GET"); commandType != CmdGET {
+		t.Errorf("ParseCommandType(GET) = %v, expected GET", commandType)
+	}
+}
+
+func TestRenamedCommandsInsideMulti(t *testing.T) {
+	resetTransactionTestState(t)
+	renameCommandsForTest(t, map[string]string{"keys": "", "set": "store"})
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdMULTI})
+	if result := HandleConnectionCommand(connection, parseClientCommand(t, "store", "k", "v")).String(); result != "+QUEUED\r\n" {
+		t.Errorf("renamed command in MULTI = %q, expected +QUEUED", result)
+	}
+	if result := HandleConnectionCommand(connection, parseClientCommand(t, "KEYS", "*")).String(); !strings.HasPrefix(result, "-ERR unknown command 'KEYS'") {
+		t.Errorf("disabled command in MULTI = %q, expected unknown command", result)
+	}
+	if result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdEXEC}).String(); result != errExecAbort.String() {
+		t.Errorf("EXEC = %q, expected %q", result, errExecAbort.String())
+	}
+}
+
+func TestRenamedCommandsPropagateUnderNewName(t *testing.T) {
+	renameCommandsForTest(t, map[string]string{"set": "store"})
+
+	encoded := string(EncodeCommandRESPArray(&RedisCommand{Type: CmdSET, Args: []string{"k", "v"}}))
+	if encoded != "*3\r\n$5\r\nstore\r\n$1\r\nk\r\n$1\r\nv\r\n" {
+		t.Errorf("EncodeCommandRESPArray() = %q, expected the renamed command", encoded)
+	}
+}
+
+func TestApplyCommandRenamesRejectsInvalidRenames(t *testing.T) {
+	t.Cleanup(func() { ApplyCommandRenames(nil) })
+
+	for _, renames := range []map[string]string{
+		{"nosuchcommand": "x"},
+		{"keys": "get"},
+		{"keys": "same", "config": "same"},
+	} {
+		if err := ApplyCommandRenames(renames); err == nil {
+			t.Errorf("ApplyCommandRenames(%v) returned no error", renames)
+		}
+	}
+
+	if err := ApplyCommandRenames(map[string]string{"get": "set", "set": "get"}); err != nil {
+		t.Errorf("swapping two commands returned %v", err)
+	}
+}
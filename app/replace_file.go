@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// replaceFile writes a new version of path through write and renames it
+// into place, so a failed or interrupted write never truncates the previous
+// file. The temporary file is created next to path from pattern with
+// os.CreateTemp, so concurrent writers never share one, and is given mode
+// perm before the rename.
+func replaceFile(path string, pattern string, perm os.FileMode, write func(file *os.File) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), pattern)
+	if err != nil {
+		return err
+	}
+	temporaryPath := file.Name()
+
+	err = write(file)
+	if err == nil {
+		err = file.Chmod(perm)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, path)
+	}
+	if err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+
+	return nil
+}
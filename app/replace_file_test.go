@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReplaceFileConcurrentWritersNeverMixContents(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "redis.conf")
+
+	var waitGroup sync.WaitGroup
+	for writer := 0; writer < 8; writer++ {
+		waitGroup.Add(1)
+		go func(contents string) {
+			defer waitGroup.Done()
+			err := replaceFile(path, "temp-config-*", 0640, func(file *os.File) error {
+				for index := 0; index < 100; index++ {
+					if _, err := file.WriteString(contents); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Errorf("replaceFile() error = %v", err)
+			}
+		}(strings.Repeat(string(rune('a'+writer)), 1000))
+	}
+	waitGroup.Wait()
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if len(contents) != 100000 || strings.Count(string(contents), string(contents[:1])) != len(contents) {
+		t.Errorf("file holds %d bytes mixing several writers, expected one writer's 100000 bytes", len(contents))
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, expected 0640", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, expected no temporary files left", len(entries))
+	}
+}
+
+func TestReplaceFileKeepsOriginalWhenWriteFails(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "users.acl")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	err := replaceFile(path, "temp-acl-*", 0644, func(file *os.File) error {
+		file.WriteString("partial")
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatal("replaceFile() returned no error for a failed write")
+	}
+
+	if contents, _ := os.ReadFile(path); string(contents) != "original" {
+		t.Errorf("file = %q after a failed write, expected the original", contents)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, expected the temporary file removed", len(entries))
+	}
+}
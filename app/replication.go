@@ -17,9 +17,15 @@ var (
 	masterReplicationOffset int
 )
 
+var (
+	masterLinkConnectionsMutex sync.Mutex
+	masterLinkConnections      = make(map[net.Conn]bool)
+)
+
 type ReplicaState struct {
 	connection             net.Conn
 	lastAcknowledgedOffset int
+	lastAcknowledgedAt     time.Time
 }
 
 // RegisterReplica adds a new replica connection to the list of replicas
@@ -27,15 +33,32 @@ func RegisterReplica(conn net.Conn) {
 	if conn == nil {
 		return
 	}
+	getConnectionOutput(conn)
 	replicasMutex.Lock()
 	defer replicasMutex.Unlock()
 
 	replicas = append(replicas, &ReplicaState{
 		connection:             conn,
 		lastAcknowledgedOffset: 0,
+		lastAcknowledgedAt:     time.Now(),
 	})
 
-	fmt.Printf("Registered new replica: %s. Total replicas: %d\n", conn.RemoteAddr(), len(replicas))
+	LogNotice("Registered new replica: %s. Total replicas: %d", conn.RemoteAddr(), len(replicas))
+}
+
+// UnregisterReplica removes conn from the list of replicas. It is a no-op
+// for connections that never completed PSYNC.
+func UnregisterReplica(conn net.Conn) {
+	replicasMutex.Lock()
+	defer replicasMutex.Unlock()
+
+	for index, replica := range replicas {
+		if replica.connection == conn {
+			replicas = append(replicas[:index], replicas[index+1:]...)
+			LogNotice("Unregistered replica: %s. Total replicas: %d", conn.RemoteAddr(), len(replicas))
+			return
+		}
+	}
 }
 
 // ReplicaCount returns the number of registered replicas.
@@ -46,6 +69,42 @@ func ReplicaCount() int {
 	return len(replicas)
 }
 
+// MarkMasterLinkConnection records that conn carries the replication stream
+// from our master, so commands read from it are applied but not propagated.
+func MarkMasterLinkConnection(conn net.Conn) {
+	masterLinkConnectionsMutex.Lock()
+	defer masterLinkConnectionsMutex.Unlock()
+
+	masterLinkConnections[conn] = true
+}
+
+func UnmarkMasterLinkConnection(conn net.Conn) {
+	masterLinkConnectionsMutex.Lock()
+	defer masterLinkConnectionsMutex.Unlock()
+
+	delete(masterLinkConnections, conn)
+}
+
+func IsMasterLinkConnection(conn net.Conn) bool {
+	masterLinkConnectionsMutex.Lock()
+	defer masterLinkConnectionsMutex.Unlock()
+
+	return masterLinkConnections[conn]
+}
+
+func isReplicaConnection(conn net.Conn) bool {
+	replicasMutex.Lock()
+	defer replicasMutex.Unlock()
+
+	for _, replica := range replicas {
+		if replica.connection == conn {
+			return true
+		}
+	}
+
+	return false
+}
+
 func RecordPropagatedReplicationBytes(commandByteLength int) {
 	replicasMutex.Lock()
 	defer replicasMutex.Unlock()
@@ -67,6 +126,7 @@ func UpdateReplicaAcknowledgementOffset(conn net.Conn, acknowledgedOffset int) {
 	for _, replica := range replicas {
 		if replica.connection == conn {
 			replica.lastAcknowledgedOffset = acknowledgedOffset
+			replica.lastAcknowledgedAt = time.Now()
 			return
 		}
 	}
@@ -103,18 +163,73 @@ func PropagateCommand(command []byte) {
 	replicaConnections := ReplicaConnectionsSnapshot()
 
 	for _, replicaConnection := range replicaConnections {
-		_, err := replicaConnection.Write(command)
+		err := EnqueueToConnection(replicaConnection, command)
 		if err != nil {
-			fmt.Printf("Error propagating command to replica %s: %v\n", replicaConnection.RemoteAddr(), err)
-			// In a real implementation, we might want to remove the failed replica
+			// The link is gone or was closed for exceeding its output
+			// limit; stop propagating to it rather than failing every write.
+			LogWarning("Dropping replica %s after propagation error: %v", replicaConnection.RemoteAddr(), err)
+			UnregisterReplica(replicaConnection)
 		} else {
-			fmt.Printf("Propagated command to replica %s: %q\n", replicaConnection.RemoteAddr(), string(command))
+			LogDebug("Propagated command to replica %s: %q", replicaConnection.RemoteAddr(), string(command))
 		}
 	}
 
 	RecordPropagatedReplicationBytes(len(command))
 }
 
+var (
+	masterLinkMutex           sync.Mutex
+	masterLinkUp              bool
+	masterLinkLastInteraction time.Time
+	masterLinkProcessedOffset int
+)
+
+// setMasterLinkUp records whether a replica's link to its master is
+// established; INFO replication reports it as master_link_status.
+func setMasterLinkUp(up bool) {
+	masterLinkMutex.Lock()
+	defer masterLinkMutex.Unlock()
+
+	masterLinkUp = up
+	masterLinkLastInteraction = time.Now()
+}
+
+// recordMasterLinkOffset notes the replication offset a replica has
+// processed from its master.
+func recordMasterLinkOffset(offset int) {
+	masterLinkMutex.Lock()
+	defer masterLinkMutex.Unlock()
+
+	masterLinkProcessedOffset = offset
+	masterLinkLastInteraction = time.Now()
+}
+
+type masterLinkState struct {
+	up              bool
+	lastInteraction time.Time
+	processedOffset int
+}
+
+func masterLinkSnapshot() masterLinkState {
+	masterLinkMutex.Lock()
+	defer masterLinkMutex.Unlock()
+
+	return masterLinkState{up: masterLinkUp, lastInteraction: masterLinkLastInteraction, processedOffset: masterLinkProcessedOffset}
+}
+
+// ReplicasSnapshot returns a copy of every registered replica's state.
+func ReplicasSnapshot() []ReplicaState {
+	replicasMutex.Lock()
+	defer replicasMutex.Unlock()
+
+	snapshot := make([]ReplicaState, 0, len(replicas))
+	for _, replica := range replicas {
+		snapshot = append(snapshot, *replica)
+	}
+
+	return snapshot
+}
+
 // InitiateHandshake handles the replication handshake with the master server
 func InitiateHandshake(config Config) error {
 	if !config.IsReplica {
@@ -122,20 +237,35 @@ func InitiateHandshake(config Config) error {
 	}
 
 	masterAddress := net.JoinHostPort(config.MasterHost, config.MasterPort)
-	fmt.Printf("Connecting to master at %s...\n", masterAddress)
+	LogNotice("Connecting to master at %s...", masterAddress)
 
-	conn, err := net.DialTimeout("tcp", masterAddress, 5*time.Second)
+	conn, err := dialMaster(config)
 	if err != nil {
 		return fmt.Errorf("failed to connect to master: %w", err)
 	}
 
 	reader := bufio.NewReader(conn)
 
+	// A protected master refuses everything else until the replica has
+	// authenticated.
+	if config.MasterAuth != "" {
+		authArgs := []string{config.MasterAuth}
+		if config.MasterUser != "" {
+			authArgs = []string{config.MasterUser, config.MasterAuth}
+		}
+		authCommand := EncodeCommandRESPArray(&RedisCommand{Type: CmdAUTH, Args: authArgs})
+		if err := sendCommand(conn, reader, string(authCommand)); err != nil {
+			conn.Close()
+			return fmt.Errorf("AUTH failed: %w", err)
+		}
+		LogNotice("Handshake: Authenticated with master")
+	}
+
 	// Step 1: Send PING
 	if err := sendCommand(conn, reader, "*1\r\n$4\r\nPING\r\n"); err != nil {
 		return fmt.Errorf("PING failed: %w", err)
 	}
-	fmt.Println("Handshake: Received PONG")
+	LogNotice("Handshake: Received PONG")
 
 	// Step 2: Send REPLCONF listening-port <PORT>
 	portStr := fmt.Sprintf("%d", config.Port)
@@ -143,21 +273,21 @@ func InitiateHandshake(config Config) error {
 	if err := sendCommand(conn, reader, replConfPort); err != nil {
 		return fmt.Errorf("REPLCONF listening-port failed: %w", err)
 	}
-	fmt.Println("Handshake: Received OK for listening-port")
+	LogNotice("Handshake: Received OK for listening-port")
 
 	// Step 3: Send REPLCONF capa psync2
 	replConfCapa := "*3\r\n$8\r\nREPLCONF\r\n$4\r\ncapa\r\n$6\r\npsync2\r\n"
 	if err := sendCommand(conn, reader, replConfCapa); err != nil {
 		return fmt.Errorf("REPLCONF capa failed: %w", err)
 	}
-	fmt.Println("Handshake: Received OK for capa")
+	LogNotice("Handshake: Received OK for capa")
 
 	// Step 4: Send PSYNC ? -1
 	psyncCmd := "*3\r\n$5\r\nPSYNC\r\n$1\r\n?\r\n$2\r\n-1\r\n"
 	if err := sendCommand(conn, reader, psyncCmd); err != nil {
 		return fmt.Errorf("PSYNC failed: %w", err)
 	}
-	fmt.Println("Handshake: Received FULLRESYNC (ignored for now)")
+	LogNotice("Handshake: Received FULLRESYNC (ignored for now)")
 
 	if err := readReplicationHandshakeRDBSnapshot(reader); err != nil {
 		return fmt.Errorf("read RDB snapshot from master: %w", err)
@@ -170,6 +300,7 @@ func InitiateHandshake(config Config) error {
 	var masterWg sync.WaitGroup
 	masterWg.Add(1)
 
+	setMasterLinkUp(true)
 	go listenMasterReplicationConnection(conn, reader, masterChannel)
 	processedReplicationCommandBytes := 0
 	go eventReactor(masterChannel, conn, &masterWg, true, &processedReplicationCommandBytes)
@@ -212,14 +343,14 @@ func listenMasterReplicationConnection(connection net.Conn, bufferedReader *bufi
 	defer connection.Close()
 	defer close(channel)
 
-	readBuffer := make([]byte, 1024)
+	readBuffer := make([]byte, readBufferSize)
 	for {
 		bytesRead, readError := bufferedReader.Read(readBuffer)
 		if readError != nil {
 			if readError == io.EOF {
-				fmt.Printf("Client %s closed the connection.\n", connection.RemoteAddr())
+				LogVerbose("Client %s closed the connection.", connection.RemoteAddr())
 			} else {
-				fmt.Printf("Error reading from connection %s: %s\n", connection.RemoteAddr(), readError.Error())
+				LogVerbose("Error reading from connection %s: %s", connection.RemoteAddr(), readError.Error())
 			}
 			break
 		}
@@ -1,5 +1,12 @@
 package main
 
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
 func resetReplicationStateForTest() {
 	replicasMutex.Lock()
 	defer replicasMutex.Unlock()
@@ -7,3 +14,27 @@ func resetReplicationStateForTest() {
 	replicas = nil
 	masterReplicationOffset = 0
 }
+
+// disconnectThroughEventReactor runs the connection's eventReactor and
+// closes its read channel, as the listen goroutine does when the peer goes
+// away, then waits for the reactor to tear the connection down.
+func disconnectThroughEventReactor(t *testing.T, connection net.Conn) {
+	t.Helper()
+
+	commandChannel := make(chan []byte)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+	go eventReactor(commandChannel, connection, &waitGroup, false, nil)
+	close(commandChannel)
+
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("eventReactor did not exit")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ReplyKind identifies the RESP type of a Reply.
+type ReplyKind int
+
+const (
+	ReplyNone ReplyKind = iota
+	ReplySimpleString
+	ReplyError
+	ReplyInteger
+	ReplyBulkString
+	ReplyNull
+	ReplyNullArray
+	ReplyArray
+	ReplyMap
+	ReplySet
+	ReplyDouble
+	ReplyBoolean
+	ReplyPush
+	ReplySequence
+	ReplyBulkPayload
+)
+
+var (
+	errNotIntegerReply = ErrorReply("ERR value is not an integer or out of range")
+	errNotFloatReply   = ErrorReply("ERR value is not a valid float")
+)
+
+// Reply is a typed RESP reply. Handlers build replies with the constructors
+// below instead of formatting wire strings, so callers such as EXEC can inspect
+// and nest them, and the connection layer can encode them for whichever
+// protocol version the client negotiated. The zero Reply means "no reply".
+type Reply struct {
+	Kind     ReplyKind
+	Text     string
+	Integer  int64
+	Double   float64
+	Boolean  bool
+	Elements []Reply
+}
+
+func SimpleStringReply(value string) Reply {
+	return Reply{Kind: ReplySimpleString, Text: value}
+}
+
+// OKReply is the +OK status reply.
+func OKReply() Reply {
+	return SimpleStringReply("OK")
+}
+
+// ErrorReply builds an error reply. The message starts with the error code,
+// for example "ERR syntax error" or "WRONGTYPE ...".
+func ErrorReply(message string) Reply {
+	return Reply{Kind: ReplyError, Text: message}
+}
+
+// WrongNumberOfArgumentsReply is the standard arity error for a command.
+func WrongNumberOfArgumentsReply(commandName string) Reply {
+	return ErrorReply("ERR wrong number of arguments for '" + strings.ToLower(commandName) + "' command")
+}
+
+func IntegerReply(value int64) Reply {
+	return Reply{Kind: ReplyInteger, Integer: value}
+}
+
+func BulkStringReply(value string) Reply {
+	return Reply{Kind: ReplyBulkString, Text: value}
+}
+
+// NullReply is the missing-value reply: a null bulk string in RESP2.
+func NullReply() Reply {
+	return Reply{Kind: ReplyNull}
+}
+
+// NullArrayReply is the null multi-bulk reply used by timed-out blocking commands.
+func NullArrayReply() Reply {
+	return Reply{Kind: ReplyNullArray}
+}
+
+func ArrayReply(elements ...Reply) Reply {
+	return Reply{Kind: ReplyArray, Elements: elements}
+}
+
+// BulkStringArrayReply builds an array of bulk strings.
+func BulkStringArrayReply(values []string) Reply {
+	elements := make([]Reply, len(values))
+	for index, value := range values {
+		elements[index] = BulkStringReply(value)
+	}
+
+	return ArrayReply(elements...)
+}
+
+// MapReply builds a map from alternating keys and values. RESP2 clients
+// receive it as a flat array.
+func MapReply(keysAndValues ...Reply) Reply {
+	return Reply{Kind: ReplyMap, Elements: keysAndValues}
+}
+
+// SetReply builds a set; RESP2 clients receive it as an array.
+func SetReply(elements ...Reply) Reply {
+	return Reply{Kind: ReplySet, Elements: elements}
+}
+
+// DoubleReply builds a floating point reply; RESP2 clients receive it as a bulk string.
+func DoubleReply(value float64) Reply {
+	return Reply{Kind: ReplyDouble, Double: value}
+}
+
+// BooleanReply builds a boolean; RESP2 clients receive it as the integer 1 or 0.
+func BooleanReply(value bool) Reply {
+	return Reply{Kind: ReplyBoolean, Boolean: value}
+}
+
+// PushReply builds an out-of-band push frame such as a pub/sub message;
+// RESP2 clients receive it as an array.
+func PushReply(elements ...Reply) Reply {
+	return Reply{Kind: ReplyPush, Elements: elements}
+}
+
+// SequenceReply sends several top-level replies back to back.
+func SequenceReply(replies ...Reply) Reply {
+	return Reply{Kind: ReplySequence, Elements: replies}
+}
+
+// BulkPayloadReply sends $<length>\r\n<payload> without the trailing CRLF,
+// which is how a master transfers its RDB snapshot to a replica.
+func BulkPayloadReply(payload string) Reply {
+	return Reply{Kind: ReplyBulkPayload, Text: payload}
+}
+
+// IsEmpty reports whether the reply is the zero "no reply" value.
+func (reply Reply) IsEmpty() bool {
+	return reply.Kind == ReplyNone
+}
+
+// IsError reports whether the reply is an error reply.
+func (reply Reply) IsError() bool {
+	return reply.Kind == ReplyError
+}
+
+// String encodes the reply as RESP2.
+func (reply Reply) String() string {
+	return reply.Encode(protocolVersionRESP2)
+}
+
+// Encode encodes the reply for the given protocol version.
+func (reply Reply) Encode(protocolVersion int) string {
+	var builder strings.Builder
+	reply.appendEncoded(&builder, protocolVersion)
+
+	return builder.String()
+}
+
+func (reply Reply) appendEncoded(builder *strings.Builder, protocolVersion int) {
+	switch reply.Kind {
+	case ReplyNone:
+	case ReplySimpleString:
+		builder.WriteString("+" + reply.Text + "\r\n")
+	case ReplyError:
+		builder.WriteString("-" + reply.Text + "\r\n")
+	case ReplyInteger:
+		builder.WriteString(encodeInteger(reply.Integer))
+	case ReplyBulkString:
+		builder.WriteString(encodeBulkString(reply.Text))
+	case ReplyNull:
+		builder.WriteString(encodeNull(protocolVersion))
+	case ReplyNullArray:
+		builder.WriteString(encodeNullArray(protocolVersion))
+	case ReplyDouble:
+		builder.WriteString(encodeDouble(protocolVersion, reply.Double))
+	case ReplyBoolean:
+		builder.WriteString(encodeBoolean(protocolVersion, reply.Boolean))
+	case ReplyBulkPayload:
+		builder.WriteString("$" + strconv.Itoa(len(reply.Text)) + "\r\n" + reply.Text)
+	case ReplyArray, ReplyMap, ReplySet, ReplyPush, ReplySequence:
+		switch reply.Kind {
+		case ReplyArray:
+			builder.WriteString(encodeArrayHeader(len(reply.Elements)))
+		case ReplyMap:
+			builder.WriteString(encodeMapHeader(protocolVersion, len(reply.Elements)/2))
+		case ReplySet:
+			builder.WriteString(encodeSetHeader(protocolVersion, len(reply.Elements)))
+		case ReplyPush:
+			builder.WriteString(encodePushHeader(protocolVersion, len(reply.Elements)))
+		}
+		for _, element := range reply.Elements {
+			element.appendEncoded(builder, protocolVersion)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestReplyEncodeRESP2(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    Reply
+		expected string
+	}{
+		{"empty reply", Reply{}, ""},
+		{"simple string", OKReply(), "+OK\r\n"},
+		{"error", ErrorReply("ERR boom"), "-ERR boom\r\n"},
+		{"wrong number of arguments", WrongNumberOfArgumentsReply("GET"), "-ERR wrong number of arguments for 'get' command\r\n"},
+		{"integer", IntegerReply(-42), ":-42\r\n"},
+		{"bulk string", BulkStringReply("hello"), "$5\r\nhello\r\n"},
+		{"null", NullReply(), "$-1\r\n"},
+		{"null array", NullArrayReply(), "*-1\r\n"},
+		{"empty array", ArrayReply(), "*0\r\n"},
+		{"nested array", ArrayReply(IntegerReply(1), ArrayReply(BulkStringReply("a"), NullReply())), "*2\r\n:1\r\n*2\r\n$1\r\na\r\n$-1\r\n"},
+		{"map", MapReply(BulkStringReply("k"), IntegerReply(1)), "*2\r\n$1\r\nk\r\n:1\r\n"},
+		{"set", SetReply(BulkStringReply("a")), "*1\r\n$1\r\na\r\n"},
+		{"double", DoubleReply(2.5), "$3\r\n2.5\r\n"},
+		{"boolean", BooleanReply(true), ":1\r\n"},
+		{"push", PushReply(BulkStringReply("message")), "*1\r\n$7\r\nmessage\r\n"},
+		{"sequence", SequenceReply(OKReply(), IntegerReply(1)), "+OK\r\n:1\r\n"},
+		{"bulk payload", BulkPayloadReply("REDIS"), "$5\r\nREDIS"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.reply.String(); actual != testCase.expected {
+				t.Errorf("String() = %q, expected %q", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestReplyEncodeRESP3(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    Reply
+		expected string
+	}{
+		{"null", NullReply(), "_\r\n"},
+		{"null array", NullArrayReply(), "_\r\n"},
+		{"nested null", ArrayReply(NullReply()), "*1\r\n_\r\n"},
+		{"map", MapReply(BulkStringReply("k"), IntegerReply(1)), "%1\r\n$1\r\nk\r\n:1\r\n"},
+		{"set", SetReply(BulkStringReply("a")), "~1\r\n$1\r\na\r\n"},
+		{"double", DoubleReply(2.5), ",2.5\r\n"},
+		{"boolean", BooleanReply(false), "#f\r\n"},
+		{"push", PushReply(BulkStringReply("message")), ">1\r\n$7\r\nmessage\r\n"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.reply.Encode(protocolVersionRESP3); actual != testCase.expected {
+				t.Errorf("Encode(3) = %q, expected %q", actual, testCase.expected)
+			}
+		})
+	}
+}
+
+func TestHandleExecRepliesAreInspectable(t *testing.T) {
+	ResetConnectionTransactionStatesForTest()
+	GetInstance().cache = make(map[string]CacheItem)
+	connection := testConnection(t)
+
+	HandleMulti(connection, &RedisCommand{Type: CmdMULTI, Args: []string{}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdGET, Args: []string{"missing"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdINCR, Args: []string{"counter"}})
+
+	result := HandleExec(connection, &RedisCommand{Type: CmdEXEC, Args: []string{}})
+
+	if result.Kind != ReplyArray || len(result.Elements) != 2 {
+		t.Fatalf("HandleExec() = %q, expected a two element array", result.String())
+	}
+	if result.Elements[0].Kind != ReplyNull {
+		t.Errorf("first EXEC reply kind = %v, expected null", result.Elements[0].Kind)
+	}
+	if result.Elements[1].Kind != ReplyInteger || result.Elements[1].Integer != 1 {
+		t.Errorf("second EXEC reply = %q, expected :1", result.Elements[1].String())
+	}
+	if encoded := result.Encode(protocolVersionRESP3); encoded != "*2\r\n_\r\n:1\r\n" {
+		t.Errorf("HandleExec() encoded for RESP3 = %q", encoded)
+	}
+}
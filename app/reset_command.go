@@ -0,0 +1,25 @@
+package main
+
+import "net"
+
+var resetCommandResponse = SimpleStringReply("RESET")
+
+// HandleReset returns the connection to its initial state: any transaction is
+// discarded, all channel subscriptions are dropped, MONITOR mode ends, the
+// protocol goes back to RESP2, the client name is cleared and the client is
+// authenticated as the default user again, which requires AUTH when
+// requirepass is set.
+func HandleReset(connection net.Conn, command *RedisCommand) Reply {
+	RemoveConnectionTransactionState(connection)
+	RemoveConnectionPubSubState(connection)
+	RemoveMonitorConnection(connection)
+
+	updateClient(connection, func(client *Client) {
+		client.ProtocolVersion = protocolVersionRESP2
+		client.Name = ""
+		client.User = defaultUserName
+		client.Authenticated = isDefaultUserPasswordless()
+	})
+
+	return resetCommandResponse
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestHandleResetRestoresConnectionDefaults(t *testing.T) {
+	resetTransactionTestState(t)
+	ResetConnectionPubSubStatesForTest()
+	ResetClientRegistryForTest()
+	connection := testConnection(t)
+
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdHELLO, Args: []string{"3", "SETNAME", "worker"}})
+	HandleConnectionCommand(connection, &RedisCommand{Type: CmdMULTI})
+
+	result := HandleConnectionCommand(connection, &RedisCommand{Type: CmdRESET}).String()
+	if result != "+RESET\r\n" {
+		t.Fatalf("HandleConnectionCommand(RESET) = %q, expected %q", result, "+RESET\r\n")
+	}
+
+	if getConnectionTransactionState(connection).inTransaction {
+		t.Error("RESET left the connection inside MULTI")
+	}
+
+	client := snapshotClient(connection)
+	if client.ProtocolVersion != protocolVersionRESP2 || client.Name != "" {
+		t.Errorf("RESET left protocol %d and name %q, expected RESP2 and no name", client.ProtocolVersion, client.Name)
+	}
+}
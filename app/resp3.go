@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// The encoders below pick the RESP3 type when the client negotiated protocol 3
+// and fall back to the closest RESP2 shape otherwise.
+
+func encodeBulkString(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func encodeInteger(value int64) string {
+	return fmt.Sprintf(":%d\r\n", value)
+}
+
+func encodeArrayHeader(length int) string {
+	return fmt.Sprintf("*%d\r\n", length)
+}
+
+func encodeNull(protocolVersion int) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return "_\r\n"
+	}
+
+	return "$-1\r\n"
+}
+
+func encodeNullArray(protocolVersion int) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return "_\r\n"
+	}
+
+	return "*-1\r\n"
+}
+
+func encodeBoolean(protocolVersion int, value bool) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		if value {
+			return "#t\r\n"
+		}
+		return "#f\r\n"
+	}
+
+	if value {
+		return ":1\r\n"
+	}
+	return ":0\r\n"
+}
+
+func formatDouble(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.IsNaN(value):
+		return "nan"
+	}
+
+	return strconv.FormatFloat(value, 'g', 17, 64)
+}
+
+func encodeDouble(protocolVersion int, value float64) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return "," + formatDouble(value) + "\r\n"
+	}
+
+	return encodeBulkString(formatDouble(value))
+}
+
+// encodeMapHeader starts a map of pairCount key/value pairs; RESP2 clients get
+// a flat array of alternating keys and values.
+func encodeMapHeader(protocolVersion int, pairCount int) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return fmt.Sprintf("%%%d\r\n", pairCount)
+	}
+
+	return encodeArrayHeader(pairCount * 2)
+}
+
+func encodeSetHeader(protocolVersion int, length int) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return fmt.Sprintf("~%d\r\n", length)
+	}
+
+	return encodeArrayHeader(length)
+}
+
+// encodePushHeader starts an out-of-band push frame such as a pub/sub message.
+func encodePushHeader(protocolVersion int, length int) string {
+	if protocolVersion >= protocolVersionRESP3 {
+		return fmt.Sprintf(">%d\r\n", length)
+	}
+
+	return encodeArrayHeader(length)
+}
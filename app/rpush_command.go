@@ -1,23 +1,21 @@
 package main
 
-import "fmt"
-
-func parseRpushCommandArguments(command *RedisCommand) (listKey string, elements []string, errorResponse string) {
+func parseRpushCommandArguments(command *RedisCommand) (listKey string, elements []string, errorResponse Reply) {
 	if len(command.Args) < 2 {
-		return "", nil, "-ERR wrong number of arguments for 'rpush' command\r\n"
+		return "", nil, WrongNumberOfArgumentsReply("rpush")
 	}
 
-	return command.Args[0], command.Args[1:], ""
+	return command.Args[0], command.Args[1:], Reply{}
 }
 
-func HandleRpush(command *RedisCommand) string {
+func HandleRpush(command *RedisCommand) Reply {
 	listKey, elements, errorResponse := parseRpushCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	listLength := GetInstance().PushListRight(listKey, elements...)
 	notifyBlockingBlpopWaiters(listKey)
 
-	return fmt.Sprintf(":%d\r\n", listLength)
+	return IntegerReply(int64(listLength))
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	serverStartTime = time.Now()
+	// serverRunID identifies this process in INFO, like Redis's run_id.
+	serverRunID = newServerRunID()
+
+	totalCommandsProcessed atomic.Int64
+	totalErrorReplies      atomic.Int64
+	keyspaceHits           atomic.Int64
+	keyspaceMisses         atomic.Int64
+
+	// changesSinceLastSave counts writes not yet in an RDB file.
+	changesSinceLastSave atomic.Int64
+	lastSaveTime         atomic.Int64
+)
+
+func init() {
+	lastSaveTime.Store(serverStartTime.Unix())
+}
+
+func newServerRunID() string {
+	random := make([]byte, 20)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(random)
+}
+
+// commandStats are the per-command counters reported by INFO commandstats,
+// with the latency histogram behind INFO latencystats and LATENCY HISTOGRAM.
+type commandStats struct {
+	calls         int64
+	microseconds  int64
+	rejectedCalls int64
+	failedCalls   int64
+	latency       latencyHistogram
+}
+
+var (
+	commandStatsMutex  sync.Mutex
+	commandStatsByType = make(map[CommandType]*commandStats)
+)
+
+// commandStatsFor must be called with commandStatsMutex held.
+func commandStatsFor(commandType CommandType) *commandStats {
+	stats, exists := commandStatsByType[commandType]
+	if !exists {
+		stats = &commandStats{}
+		commandStatsByType[commandType] = stats
+	}
+	return stats
+}
+
+// recordCommandCall counts an executed command, its duration and whether
+// it replied with an error. The duration of a command that may block is
+// left out, since it is mostly time spent waiting.
+func recordCommandCall(spec *CommandSpec, duration time.Duration, response Reply) {
+	totalCommandsProcessed.Add(1)
+
+	commandStatsMutex.Lock()
+	defer commandStatsMutex.Unlock()
+
+	stats := commandStatsFor(spec.Type)
+	stats.calls++
+	if !spec.MayBlock() {
+		stats.microseconds += duration.Microseconds()
+		stats.latency.record(duration)
+	}
+	if response.IsError() {
+		stats.failedCalls++
+		totalErrorReplies.Add(1)
+	}
+}
+
+// recordRejectedCommand counts a command refused before it ran, because of
+// its arity or the client's permissions. Unknown commands have no entry.
+func recordRejectedCommand(command *RedisCommand) {
+	totalErrorReplies.Add(1)
+
+	spec := LookupCommandSpec(command.Type)
+	if spec == nil {
+		return
+	}
+
+	commandStatsMutex.Lock()
+	defer commandStatsMutex.Unlock()
+
+	commandStatsFor(spec.Type).rejectedCalls++
+}
+
+// recordKeyspaceLookup counts a key read as a keyspace hit or miss.
+func recordKeyspaceLookup(found bool) {
+	if found {
+		keyspaceHits.Add(1)
+	} else {
+		keyspaceMisses.Add(1)
+	}
+}
+
+// recordSuccessfulSave restarts the count of unsaved changes.
+func recordSuccessfulSave() {
+	changesSinceLastSave.Store(0)
+	lastSaveTime.Store(time.Now().Unix())
+}
+
+// commandStatsSnapshot returns a copy of the counters of every command that
+// has been called or rejected.
+func commandStatsSnapshot() map[CommandType]commandStats {
+	commandStatsMutex.Lock()
+	defer commandStatsMutex.Unlock()
+
+	snapshot := make(map[CommandType]commandStats, len(commandStatsByType))
+	for commandType, stats := range commandStatsByType {
+		snapshot[commandType] = *stats
+	}
+	return snapshot
+}
+
+// ResetServerStats clears the counters reported by INFO stats, INFO
+// commandstats and INFO latencystats, as CONFIG RESETSTAT does.
+func ResetServerStats() {
+	totalConnectionsReceived.Store(0)
+	rejectedConnections.Store(0)
+	outputBufferLimitDisconnections.Store(0)
+	totalCommandsProcessed.Store(0)
+	totalErrorReplies.Store(0)
+	keyspaceHits.Store(0)
+	keyspaceMisses.Store(0)
+
+	commandStatsMutex.Lock()
+	commandStatsByType = make(map[CommandType]*commandStats)
+	commandStatsMutex.Unlock()
+}
@@ -1,18 +1,13 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-)
+import "strconv"
 
 func secondsToMilliseconds(seconds int) int {
 	return seconds * 1000
 }
 
 func convertStringToInt(str string) (int, error) {
-	fmt.Println("str before conversion", str)
 	val, err := strconv.Atoi(str)
-	fmt.Println("val after conversion", val, err)
 	if err != nil {
 		return 0, err
 	}
@@ -20,35 +15,25 @@ func convertStringToInt(str string) (int, error) {
 }
 
 func handleOptionalArguments(cmd *RedisCommand) map[string]interface{} {
-	fmt.Println("cmd.Args are: ", cmd.Args)
 	options := make(map[string]interface{})
 	for i := 2; i < len(cmd.Args); i++ {
-
-		fmt.Println("cmd.Args[i] is: ", cmd.Args[i])
-
 		switch cmd.Args[i] {
 		case "EX":
-			fmt.Println("EX is: ", cmd.Args[i+1])
 			if val, err := convertStringToInt(cmd.Args[i+1]); err == nil {
 				options["EX"] = val
 			}
 		case "PX":
-			fmt.Println("PX is: ", cmd.Args[i+1])
 			if val, err := convertStringToInt(cmd.Args[i+1]); err == nil {
 				options["PX"] = val
 			}
-		default:
-			fmt.Println("default is: ", cmd.Args[i])
 		}
 	}
-	fmt.Println("options are after conversion: ", options)
 	return options
 }
 
-func HandleSet(cmd *RedisCommand) string {
+func HandleSet(cmd *RedisCommand) Reply {
 	cache := GetInstance()
 	options := handleOptionalArguments(cmd)
-	fmt.Println("options are before set: ", options)
 	cache.Set(cmd.Args[0], cmd.Args[1], options)
-	return "+OK\r\n"
+	return OKReply()
 }
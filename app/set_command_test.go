@@ -20,7 +20,7 @@ func TestHandleSet(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := HandleSet(tt.cmd)
+			result := HandleSet(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleSet() = %q, expected %q", result, tt.expected)
 			}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout bounds how long a shutdown waits for replicas to catch up
+// and for in-flight commands to finish, like Redis's shutdown-timeout.
+var shutdownTimeout = 10 * time.Second
+
+// exitProcess terminates the server; tests replace it to observe the status.
+var exitProcess = os.Exit
+
+var (
+	errShutdownAborted      = errors.New("shutdown manually aborted")
+	errNoShutdownInProgress = errors.New("no shutdown in progress")
+)
+
+// ShutdownOptions mirror the SHUTDOWN modifiers.
+type ShutdownOptions struct {
+	Save   bool
+	NoSave bool
+	Now    bool
+	Force  bool
+}
+
+var (
+	serverStateMutex sync.Mutex
+	serverListeners  []net.Listener
+	shutdownAbort    chan struct{}
+	shutdownStarted  bool
+
+	inFlightCommands atomic.Int64
+)
+
+func ResetServerStateForTest() {
+	serverStateMutex.Lock()
+	defer serverStateMutex.Unlock()
+
+	serverListeners = nil
+	shutdownAbort = nil
+	shutdownStarted = false
+	inFlightCommands.Store(0)
+}
+
+// RegisterServerListener records a listener so shutdown can stop accepting.
+func RegisterServerListener(listener net.Listener) {
+	serverStateMutex.Lock()
+	defer serverStateMutex.Unlock()
+
+	serverListeners = append(serverListeners, listener)
+}
+
+// beginCommand and endCommand bracket command execution so shutdown can let
+// in-flight commands finish before closing connections.
+func beginCommand() {
+	inFlightCommands.Add(1)
+}
+
+func endCommand() {
+	inFlightCommands.Add(-1)
+}
+
+// IsShutdownInProgress reports whether a shutdown is waiting on replicas or
+// finishing.
+func IsShutdownInProgress() bool {
+	serverStateMutex.Lock()
+	defer serverStateMutex.Unlock()
+
+	return shutdownAbort != nil || shutdownStarted
+}
+
+// AbortShutdown cancels a shutdown that is still waiting for replicas.
+func AbortShutdown() error {
+	serverStateMutex.Lock()
+	defer serverStateMutex.Unlock()
+
+	if shutdownAbort == nil || shutdownStarted {
+		return errNoShutdownInProgress
+	}
+
+	close(shutdownAbort)
+	shutdownAbort = nil
+	return nil
+}
+
+// PrepareShutdown runs the steps that may still fail and leave the server
+// running: waiting for replicas to catch up and persisting the dataset. On
+// success the caller must call FinishShutdown with the returned exit code,
+// which is non-zero when FORCE skipped past a failed save.
+func PrepareShutdown(options ShutdownOptions) (exitCode int, err error) {
+	abort := make(chan struct{})
+
+	serverStateMutex.Lock()
+	if shutdownAbort != nil || shutdownStarted {
+		serverStateMutex.Unlock()
+		return 0, errors.New("shutdown already in progress")
+	}
+	shutdownAbort = abort
+	serverStateMutex.Unlock()
+
+	LogWarning("User requested shutdown...")
+
+	if !options.Now && ReplicaCount() > 0 {
+		if err := waitForReplicasToCatchUp(abort); err != nil {
+			return 0, err
+		}
+	}
+
+	serverStateMutex.Lock()
+	if shutdownAbort != abort {
+		serverStateMutex.Unlock()
+		return 0, errShutdownAborted
+	}
+	shutdownAbort = nil
+	shutdownStarted = true
+	serverStateMutex.Unlock()
+
+	if err := persistOnShutdown(options); err != nil {
+		if !options.Force {
+			serverStateMutex.Lock()
+			shutdownStarted = false
+			serverStateMutex.Unlock()
+			return 0, err
+		}
+		LogWarning("Error trying to save the DB, exiting anyway because of FORCE.")
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// waitForReplicasToCatchUp asks replicas for their offsets and waits until
+// every still connected replica acknowledged everything propagated so far,
+// the timeout expires, or the shutdown is aborted. Replicas leave the list
+// when their connection closes, so a departed one does not hold it up.
+func waitForReplicasToCatchUp(abort chan struct{}) error {
+	targetReplicationOffset := CurrentMasterReplicationOffset()
+	if targetReplicationOffset == 0 {
+		return nil
+	}
+
+	LogNotice("Waiting for replicas before shutting down.")
+	requestReplicaAcknowledgements()
+
+	deadline := time.Now().Add(shutdownTimeout)
+	for CountReplicasAcknowledgingOffset(targetReplicationOffset) < ReplicaCount() {
+		if !time.Now().Before(deadline) {
+			LogWarning("Lagging replica(s) after shutdown timeout, shutting down anyway.")
+			return nil
+		}
+
+		select {
+		case <-abort:
+			LogWarning("Shutdown manually aborted.")
+			return errShutdownAborted
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	LogNotice("All replicas are in sync.")
+	return nil
+}
+
+// persistOnShutdown saves the dataset unless NOSAVE was given. Without
+// SAVE, the dataset is only saved when an RDB file is configured.
+func persistOnShutdown(options ShutdownOptions) error {
+	if options.NoSave {
+		return nil
+	}
+
+	config := GetConfig()
+	if !options.Save && (config.Dir == "" || config.DbFilename == "") {
+		return nil
+	}
+
+	dbFilename := config.DbFilename
+	if dbFilename == "" {
+		dbFilename = "dump.rdb"
+	}
+	rdbPath := filepath.Join(config.Dir, dbFilename)
+
+	LogNotice("Saving the final RDB snapshot before exiting.")
+	if err := SaveRDB(rdbPath); err != nil {
+		LogWarning("Error trying to save the DB: %s", err.Error())
+		return err
+	}
+	LogNotice("DB saved on disk")
+
+	return nil
+}
+
+// FinishShutdown stops accepting connections, lets in-flight commands
+// finish, closes every client and exits with exitCode. ownCommands is the
+// number of in-flight commands belonging to the caller, such as the
+// SHUTDOWN command itself.
+func FinishShutdown(exitCode int, ownCommands int64) {
+	serverStateMutex.Lock()
+	listeners := serverListeners
+	serverListeners = nil
+	serverStateMutex.Unlock()
+
+	for _, listener := range listeners {
+		listener.Close()
+	}
+
+	deadline := time.Now().Add(shutdownTimeout)
+	for inFlightCommands.Load() > ownCommands && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, client := range ClientsSnapshot() {
+		client.Connection.Close()
+	}
+
+	LogWarning("Redis is now ready to exit, bye bye...")
+	exitProcess(exitCode)
+}
+
+// HandleShutdownSignals shuts the server down on SIGTERM or SIGINT. A second
+// signal while a shutdown is already under way exits immediately.
+func HandleShutdownSignals(signals <-chan os.Signal) {
+	for received := range signals {
+		signalName := "SIGINT"
+		if received == syscall.SIGTERM {
+			signalName = "SIGTERM"
+		}
+		if IsShutdownInProgress() {
+			LogWarning("You insist... exiting now.")
+			exitProcess(1)
+			return
+		}
+
+		LogWarning("Received %s scheduling shutdown...", signalName)
+		go func() {
+			exitCode, err := PrepareShutdown(ShutdownOptions{})
+			if err != nil {
+				LogWarning("%s received but errors trying to shut down the server, check the logs for more information", signalName)
+				return
+			}
+			FinishShutdown(exitCode, 0)
+		}()
+	}
+}
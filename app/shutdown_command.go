@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+var (
+	errShutdownFailed       = ErrorReply("ERR Errors trying to SHUTDOWN. Check logs.")
+	errShutdownNotInProcess = ErrorReply("ERR No shutdown in progress.")
+	errShutdownSyntax       = ErrorReply("ERR syntax error")
+)
+
+type shutdownCommandArguments struct {
+	options ShutdownOptions
+	abort   bool
+}
+
+func parseShutdownCommandArguments(command *RedisCommand) (arguments shutdownCommandArguments, errorResponse Reply) {
+	for _, argument := range command.Args {
+		switch strings.ToUpper(argument) {
+		case "SAVE":
+			arguments.options.Save = true
+		case "NOSAVE":
+			arguments.options.NoSave = true
+		case "NOW":
+			arguments.options.Now = true
+		case "FORCE":
+			arguments.options.Force = true
+		case "ABORT":
+			arguments.abort = true
+		default:
+			return shutdownCommandArguments{}, errShutdownSyntax
+		}
+	}
+
+	if arguments.options.Save && arguments.options.NoSave {
+		return shutdownCommandArguments{}, errShutdownSyntax
+	}
+
+	if arguments.abort && len(command.Args) > 1 {
+		return shutdownCommandArguments{}, errShutdownSyntax
+	}
+
+	return arguments, Reply{}
+}
+
+// HandleShutdown processes SHUTDOWN. A successful shutdown never replies:
+// the connection is closed as the server exits.
+func HandleShutdown(connection net.Conn, command *RedisCommand) Reply {
+	arguments, errorResponse := parseShutdownCommandArguments(command)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	if arguments.abort {
+		if err := AbortShutdown(); err != nil {
+			return errShutdownNotInProcess
+		}
+		return OKReply()
+	}
+
+	exitCode, err := PrepareShutdown(arguments.options)
+	if err != nil {
+		return errShutdownFailed
+	}
+
+	FinishShutdown(exitCode, 1)
+
+	return Reply{}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseShutdownCommandArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      shutdownCommandArguments
+		expectedError string
+	}{
+		{
+			name: "no modifiers",
+			args: []string{},
+		},
+		{
+			name:     "all compatible modifiers",
+			args:     []string{"save", "NOW", "force"},
+			expected: shutdownCommandArguments{options: ShutdownOptions{Save: true, Now: true, Force: true}},
+		},
+		{
+			name:     "abort",
+			args:     []string{"ABORT"},
+			expected: shutdownCommandArguments{abort: true},
+		},
+		{
+			name:          "save and nosave conflict",
+			args:          []string{"SAVE", "NOSAVE"},
+			expectedError: errShutdownSyntax.String(),
+		},
+		{
+			name:          "abort with other modifiers",
+			args:          []string{"ABORT", "NOW"},
+			expectedError: errShutdownSyntax.String(),
+		},
+		{
+			name:          "unknown modifier",
+			args:          []string{"LATER"},
+			expectedError: errShutdownSyntax.String(),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			arguments, errorResponse := parseShutdownCommandArguments(&RedisCommand{Type: CmdSHUTDOWN, Args: testCase.args})
+
+			if errorResponse.String() != testCase.expectedError {
+				t.Fatalf("parseShutdownCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
+			}
+			if arguments != testCase.expected {
+				t.Errorf("parseShutdownCommandArguments() = %+v, expected %+v", arguments, testCase.expected)
+			}
+		})
+	}
+}
+
+func stubExitProcess(t *testing.T) <-chan int {
+	t.Helper()
+
+	exitCodes := make(chan int, 1)
+	originalExitProcess := exitProcess
+	exitProcess = func(code int) { exitCodes <- code }
+	t.Cleanup(func() { exitProcess = originalExitProcess })
+
+	return exitCodes
+}
+
+func TestHandleShutdownAbortWithoutShutdown(t *testing.T) {
+	ResetServerStateForTest()
+	connection := testConnection(t)
+
+	result := HandleShutdown(connection, &RedisCommand{Type: CmdSHUTDOWN, Args: []string{"ABORT"}}).String()
+
+	if result != errShutdownNotInProcess.String() {
+		t.Errorf("HandleShutdown(ABORT) = %q, expected %q", result, errShutdownNotInProcess.String())
+	}
+}
+
+func TestHandleShutdownSavesClosesClientsAndExits(t *testing.T) {
+	ResetServerStateForTest()
+	resetReplicationStateForTest()
+	exitCodes := stubExitProcess(t)
+
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.Dir = t.TempDir()
+	serverConfig.DbFilename = "dump.rdb"
+
+	GetInstance().cache = make(map[string]CacheItem)
+	GetInstance().Set("persisted", "value", nil)
+
+	serverConnection, clientConnection := testConnectionPair(t)
+	RegisterClient(serverConnection)
+
+	beginCommand()
+	HandleShutdown(serverConnection, &RedisCommand{Type: CmdSHUTDOWN})
+	endCommand()
+
+	select {
+	case code := <-exitCodes:
+		if code != 0 {
+			t.Errorf("exit code = %d, expected 0", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SHUTDOWN did not exit")
+	}
+
+	if _, err := os.Stat(filepath.Join(serverConfig.Dir, "dump.rdb")); err != nil {
+		t.Errorf("Stat(dump.rdb) error = %v, expected the dataset to be saved", err)
+	}
+
+	clientConnection.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := clientConnection.Read(make([]byte, 1)); err == nil {
+		t.Error("client connection is still open after SHUTDOWN")
+	}
+}
+
+func TestHandleShutdownFailsWhenSaveFails(t *testing.T) {
+	ResetServerStateForTest()
+	resetReplicationStateForTest()
+	exitCodes := stubExitProcess(t)
+
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.Dir = filepath.Join(t.TempDir(), "missing")
+	serverConfig.DbFilename = "dump.rdb"
+
+	connection := testConnection(t)
+	result := HandleShutdown(connection, &RedisCommand{Type: CmdSHUTDOWN}).String()
+
+	if result != errShutdownFailed.String() {
+		t.Errorf("HandleShutdown() = %q, expected %q", result, errShutdownFailed.String())
+	}
+	if IsShutdownInProgress() {
+		t.Error("failed shutdown left a shutdown in progress")
+	}
+
+	select {
+	case code := <-exitCodes:
+		t.Errorf("server exited with %d after a failed save", code)
+	default:
+	}
+
+	HandleShutdown(connection, &RedisCommand{Type: CmdSHUTDOWN, Args: []string{"FORCE"}})
+	select {
+	case code := <-exitCodes:
+		if code != 1 {
+			t.Errorf("exit code = %d, expected 1 after forcing past a failed save", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SHUTDOWN FORCE did not exit")
+	}
+}
+
+func TestHandleShutdownSavesStreams(t *testing.T) {
+	ResetServerStateForTest()
+	resetReplicationStateForTest()
+	exitCodes := stubExitProcess(t)
+
+	originalConfig := serverConfig
+	defer func() { serverConfig = originalConfig }()
+	serverConfig.Dir = t.TempDir()
+	serverConfig.DbFilename = "dump.rdb"
+
+	GetInstance().cache = make(map[string]CacheItem)
+	defer func() { GetInstance().cache = make(map[string]CacheItem) }()
+	GetInstance().AddStreamEntry("events", "1-1", []string{"field", "value"})
+
+	HandleShutdown(testConnection(t), &RedisCommand{Type: CmdSHUTDOWN, Args: []string{"SAVE"}})
+
+	select {
+	case code := <-exitCodes:
+		if code != 0 {
+			t.Errorf("exit code = %d, expected 0", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SHUTDOWN SAVE did not exit with a stream in the dataset")
+	}
+}
+
+func TestShutdownAbortCancelsReplicaWait(t *testing.T) {
+	ResetServerStateForTest()
+	resetReplicationStateForTest()
+	defer resetReplicationStateForTest()
+	stubExitProcess(t)
+
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	RegisterReplica(replicaConnection)
+	RecordPropagatedReplicationBytes(10)
+
+	shutdownResults := make(chan string, 1)
+	go func() {
+		shutdownResults <- HandleShutdown(testConnection(t), &RedisCommand{Type: CmdSHUTDOWN, Args: []string{"NOSAVE"}}).String()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !IsShutdownInProgress() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	abortResult := HandleShutdown(testConnection(t), &RedisCommand{Type: CmdSHUTDOWN, Args: []string{"ABORT"}}).String()
+	if abortResult != "+OK\r\n" {
+		t.Fatalf("HandleShutdown(ABORT) = %q, expected +OK", abortResult)
+	}
+
+	select {
+	case result := <-shutdownResults:
+		if result != errShutdownFailed.String() {
+			t.Errorf("aborted HandleShutdown() = %q, expected %q", result, errShutdownFailed.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("aborted SHUTDOWN did not return")
+	}
+}
+
+func TestShutdownDoesNotWaitForDisconnectedReplicas(t *testing.T) {
+	resetClientTestState(t)
+	defer resetReplicationStateForTest()
+
+	replicaConnection, replicaPeer := testConnectionPair(t)
+	startDrainConnection(replicaPeer)
+	RegisterReplica(replicaConnection)
+	RecordPropagatedReplicationBytes(10)
+	disconnectThroughEventReactor(t, replicaConnection)
+
+	started := time.Now()
+	if err := waitForReplicasToCatchUp(make(chan struct{})); err != nil {
+		t.Fatalf("waitForReplicasToCatchUp() error = %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("waitForReplicasToCatchUp() took %v, expected it not to wait for a departed replica", elapsed)
+	}
+}
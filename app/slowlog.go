@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSlowlogLogSlowerThan is the default slowlog-log-slower-than in
+	// microseconds.
+	DefaultSlowlogLogSlowerThan = 10000
+	// DefaultSlowlogMaxLen is the default slowlog-max-len.
+	DefaultSlowlogMaxLen = 128
+
+	// slowlogMaxArguments and slowlogMaxArgumentLength bound what an entry
+	// keeps of a command, like Redis's SLOWLOG_ENTRY_MAX_ARGC and
+	// SLOWLOG_ENTRY_MAX_STRING.
+	slowlogMaxArguments      = 32
+	slowlogMaxArgumentLength = 128
+)
+
+// SlowlogEntry is one command that ran longer than slowlog-log-slower-than.
+type SlowlogEntry struct {
+	ID            int64
+	Timestamp     int64
+	Duration      time.Duration
+	Arguments     []string
+	ClientAddress string
+	ClientName    string
+}
+
+var (
+	slowlogMutex sync.Mutex
+	// slowlogEntries is a ring of at most slowlogCapacity entries. It grows
+	// by appending until full; from then on slowlogHead indexes the oldest
+	// entry, which the next one overwrites.
+	slowlogEntries  []SlowlogEntry
+	slowlogHead     int
+	slowlogCapacity = DefaultSlowlogMaxLen
+	slowlogNextID   int64
+)
+
+// recordSlowCommand adds the command to the slow log when it ran for at
+// least slowlog-log-slower-than microseconds. A negative threshold disables
+// the slow log. Commands that may block are skipped.
+func recordSlowCommand(connection net.Conn, spec *CommandSpec, command *RedisCommand, duration time.Duration) {
+	if spec.MayBlock() {
+		return
+	}
+
+	config := GetConfig()
+	if config.SlowlogLogSlowerThan < 0 || duration.Microseconds() < config.SlowlogLogSlowerThan {
+		return
+	}
+
+	entry := SlowlogEntry{
+		Timestamp:     time.Now().Unix(),
+		Duration:      duration,
+		Arguments:     slowlogArguments(spec, command),
+		ClientAddress: clientAddress(connection),
+		ClientName:    snapshotClient(connection).Name,
+	}
+
+	slowlogMutex.Lock()
+	defer slowlogMutex.Unlock()
+
+	entry.ID = slowlogNextID
+	slowlogNextID++
+	if slowlogCapacity != config.SlowlogMaxLen {
+		resizeSlowlogLocked(config.SlowlogMaxLen)
+	}
+	switch {
+	case slowlogCapacity == 0:
+	case len(slowlogEntries) < slowlogCapacity:
+		slowlogEntries = append(slowlogEntries, entry)
+	default:
+		slowlogEntries[slowlogHead] = entry
+		slowlogHead = (slowlogHead + 1) % len(slowlogEntries)
+	}
+}
+
+// newestSlowlogEntriesLocked returns up to count entries, newest first.
+func newestSlowlogEntriesLocked(count int) []SlowlogEntry {
+	if count < 0 || count > len(slowlogEntries) {
+		count = len(slowlogEntries)
+	}
+
+	entries := make([]SlowlogEntry, count)
+	for index := range entries {
+		entries[index] = slowlogEntries[(slowlogHead+len(slowlogEntries)-1-index)%len(slowlogEntries)]
+	}
+
+	return entries
+}
+
+// resizeSlowlogLocked keeps the newest capacity entries, oldest first, so
+// the ring can grow by appending again.
+func resizeSlowlogLocked(capacity int) {
+	newest := newestSlowlogEntriesLocked(capacity)
+	resized := make([]SlowlogEntry, len(newest))
+	for index, entry := range newest {
+		resized[len(newest)-1-index] = entry
+	}
+
+	slowlogEntries = resized
+	slowlogHead = 0
+	slowlogCapacity = capacity
+}
+
+// slowlogArguments returns the command as an entry stores it: at most
+// slowlogMaxArguments arguments, each cut to slowlogMaxArgumentLength bytes,
+// with secrets redacted.
+func slowlogArguments(spec *CommandSpec, command *RedisCommand) []string {
+	argv := append([]string{command.Name}, redactedCommandArguments(spec, command.Args)...)
+	if command.Name == "" {
+		argv[0] = spec.Name
+	}
+
+	count := len(argv)
+	if count > slowlogMaxArguments {
+		count = slowlogMaxArguments
+	}
+
+	arguments := make([]string, 0, count)
+	for index := 0; index < count; index++ {
+		if index == slowlogMaxArguments-1 && len(argv) > slowlogMaxArguments {
+			arguments = append(arguments, fmt.Sprintf("... (%d more arguments)", len(argv)-slowlogMaxArguments+1))
+			break
+		}
+
+		argument := argv[index]
+		if len(argument) > slowlogMaxArgumentLength {
+			argument = fmt.Sprintf("%s... (%d more bytes)", argument[:slowlogMaxArgumentLength], len(argument)-slowlogMaxArgumentLength)
+		}
+		arguments = append(arguments, argument)
+	}
+
+	return arguments
+}
+
+// redactedCommandArguments returns the arguments with passwords replaced by
+// "(redacted)", so they never reach the slow log or MONITOR output.
+func redactedCommandArguments(spec *CommandSpec, args []string) []string {
+	redacted := append([]string(nil), args...)
+
+	switch spec.Type {
+	case CmdAUTH:
+		for index := range redacted {
+			redacted[index] = "(redacted)"
+		}
+	case CmdHELLO:
+		for index := 0; index < len(redacted); index++ {
+			if strings.EqualFold(redacted[index], "AUTH") && index+2 < len(redacted) {
+				redacted[index+1], redacted[index+2] = "(redacted)", "(redacted)"
+				index += 2
+			}
+		}
+	}
+
+	return redacted
+}
+
+// SlowlogEntries returns up to count entries, newest first; a negative
+// count returns all of them.
+func SlowlogEntries(count int) []SlowlogEntry {
+	slowlogMutex.Lock()
+	defer slowlogMutex.Unlock()
+
+	return newestSlowlogEntriesLocked(count)
+}
+
+// SlowlogLen returns the number of entries in the slow log.
+func SlowlogLen() int {
+	slowlogMutex.Lock()
+	defer slowlogMutex.Unlock()
+
+	return len(slowlogEntries)
+}
+
+// ResetSlowlog empties the slow log; entry ids keep increasing.
+func ResetSlowlog() {
+	slowlogMutex.Lock()
+	defer slowlogMutex.Unlock()
+
+	slowlogEntries = nil
+	slowlogHead = 0
+}
+
+// applySlowlogMaxLen resizes the slow log after slowlog-max-len changes,
+// dropping the oldest entries when it shrinks.
+func applySlowlogMaxLen(config Config) error {
+	slowlogMutex.Lock()
+	defer slowlogMutex.Unlock()
+
+	resizeSlowlogLocked(config.SlowlogMaxLen)
+	return nil
+}
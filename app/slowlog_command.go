@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const defaultSlowlogGetCount = 10
+
+var errSlowlogCountOutOfRange = ErrorReply("ERR count should be greater than or equal to -1")
+
+func parseSlowlogCommandArguments(command *RedisCommand) (subcommand string, count int, errorResponse Reply) {
+	if len(command.Args) == 0 {
+		return "", 0, WrongNumberOfArgumentsReply("slowlog")
+	}
+
+	subcommand = strings.ToUpper(command.Args[0])
+	args := command.Args[1:]
+	switch subcommand {
+	case "GET":
+		count = defaultSlowlogGetCount
+		if len(args) > 1 {
+			return "", 0, WrongNumberOfArgumentsReply("slowlog|get")
+		}
+		if len(args) == 1 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil {
+				return "", 0, ErrorReply("ERR value is not an integer or out of range")
+			}
+			if parsed < -1 {
+				return "", 0, errSlowlogCountOutOfRange
+			}
+			count = parsed
+		}
+	case "LEN", "RESET":
+		if len(args) != 0 {
+			return "", 0, WrongNumberOfArgumentsReply("slowlog|" + strings.ToLower(subcommand))
+		}
+	default:
+		return "", 0, ErrorReply(fmt.Sprintf("ERR unknown subcommand '%s'. Try SLOWLOG HELP.", command.Args[0]))
+	}
+
+	return subcommand, count, Reply{}
+}
+
+// HandleSlowlog implements SLOWLOG GET [count], SLOWLOG LEN and SLOWLOG
+// RESET.
+func HandleSlowlog(connection net.Conn, command *RedisCommand) Reply {
+	subcommand, count, errorResponse := parseSlowlogCommandArguments(command)
+	if !errorResponse.IsEmpty() {
+		return errorResponse
+	}
+
+	switch subcommand {
+	case "LEN":
+		return IntegerReply(int64(SlowlogLen()))
+	case "RESET":
+		ResetSlowlog()
+		return OKReply()
+	}
+
+	entries := SlowlogEntries(count)
+	replies := make([]Reply, 0, len(entries))
+	for _, entry := range entries {
+		replies = append(replies, ArrayReply(
+			IntegerReply(entry.ID),
+			IntegerReply(entry.Timestamp),
+			IntegerReply(entry.Duration.Microseconds()),
+			BulkStringArrayReply(entry.Arguments),
+			BulkStringReply(entry.ClientAddress),
+			BulkStringReply(entry.ClientName),
+		))
+	}
+
+	return ArrayReply(replies...)
+}
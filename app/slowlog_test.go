@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetSlowlogTestState(t *testing.T) {
+	t.Helper()
+	resetConfigTestState(t)
+	resetClientTestState(t)
+	ResetSlowlog()
+	t.Cleanup(ResetSlowlog)
+}
+
+func slowlogCommand(args ...string) string {
+	return HandleSlowlog(nil, &RedisCommand{Type: CmdSLOWLOG, Args: args}).String()
+}
+
+func TestSlowlogRecordsCommandsOverThreshold(t *testing.T) {
+	resetSlowlogTestState(t)
+	serverConnection, _ := testConnectionPair(t)
+	updateClient(serverConnection, func(client *Client) { client.Name = "worker" })
+
+	if err := SetConfigParameters([][2]string{{"slowlog-log-slower-than", "0"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+	executeConnectionCommand(serverConnection, parseClientCommand(t, "SET", "key", "value"))
+
+	entries := SlowlogEntries(-1)
+	if len(entries) != 1 {
+		t.Fatalf("slow log has %d entries, expected 1", len(entries))
+	}
+	entry := entries[0]
+	if strings.Join(entry.Arguments, " ") != "SET key value" {
+		t.Errorf("arguments = %q, expected SET key value", entry.Arguments)
+	}
+	if entry.ClientAddress != clientAddress(serverConnection) || entry.ClientName != "worker" {
+		t.Errorf("client = %q %q, expected %q worker", entry.ClientAddress, entry.ClientName, clientAddress(serverConnection))
+	}
+
+	if err := SetConfigParameters([][2]string{{"slowlog-log-slower-than", "-1"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+	executeConnectionCommand(serverConnection, parseClientCommand(t, "GET", "key"))
+	if length := slowlogCommand("LEN"); length != ":1\r\n" {
+		t.Errorf("SLOWLOG LEN after disabling = %q, expected :1", length)
+	}
+}
+
+func TestSlowlogGetReturnsNewestFirstAndRespectsMaxLen(t *testing.T) {
+	resetSlowlogTestState(t)
+	serverConnection, _ := testConnectionPair(t)
+	if err := SetConfigParameters([][2]string{{"slowlog-log-slower-than", "0"}, {"slowlog-max-len", "3"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+
+	for index := 0; index < 5; index++ {
+		executeConnectionCommand(serverConnection, parseClientCommand(t, "ECHO", fmt.Sprint(index)))
+	}
+
+	entries := SlowlogEntries(-1)
+	if len(entries) != 3 || entries[0].Arguments[1] != "4" || entries[2].Arguments[1] != "2" || entries[0].ID != entries[2].ID+2 {
+		t.Fatalf("slow log = %+v, expected the last three commands newest first", entries)
+	}
+
+	reply := slowlogCommand("GET", "1")
+	if !strings.HasPrefix(reply, fmt.Sprintf("*1\r\n*6\r\n:%d\r\n", entries[0].ID)) || !strings.Contains(reply, "$4\r\nECHO\r\n$1\r\n4\r\n") {
+		t.Errorf("SLOWLOG GET 1 = %q, expected the newest entry", reply)
+	}
+
+	if err := SetConfigParameters([][2]string{{"slowlog-max-len", "4"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+	executeConnectionCommand(serverConnection, parseClientCommand(t, "ECHO", "5"))
+	entries = SlowlogEntries(-1)
+	if len(entries) != 4 || entries[0].Arguments[1] != "5" || entries[3].Arguments[1] != "2" {
+		t.Fatalf("slow log after growing = %+v, expected commands 5 down to 2", entries)
+	}
+
+	if err := SetConfigParameters([][2]string{{"slowlog-max-len", "1"}}); err != nil {
+		t.Fatalf("SetConfigParameters() error = %v", err)
+	}
+	if entries := SlowlogEntries(-1); len(entries) != 1 || entries[0].Arguments[1] != "5" {
+		t.Errorf("slow log after shrinking = %+v, expected only the newest command", entries)
+	}
+
+	if reset := slowlogCommand("RESET"); reset != "+OK\r\n" {
+		t.Errorf("SLOWLOG RESET = %q, expected +OK", reset)
+	}
+	if reply := slowlogCommand("GET"); reply != "*0\r\n" {
+		t.Errorf("SLOWLOG GET after RESET = %q, expected an empty array", reply)
+	}
+}
+
+func TestSlowlogArgumentsAreTruncatedAndRedacted(t *testing.T) {
+	long := strings.Repeat("x", slowlogMaxArgumentLength+10)
+	arguments := slowlogArguments(LookupCommandSpec(CmdECHO), &RedisCommand{Name: "echo", Args: []string{long}})
+	if expected := strings.Repeat("x", slowlogMaxArgumentLength) + "... (10 more bytes)"; arguments[1] != expected {
+		t.Errorf("long argument = %q, expected %q", arguments[1], expected)
+	}
+
+	many := make([]string, 40)
+	arguments = slowlogArguments(LookupCommandSpec(CmdRPUSH), &RedisCommand{Name: "rpush", Args: many})
+	if len(arguments) != slowlogMaxArguments || arguments[slowlogMaxArguments-1] != "... (10 more arguments)" {
+		t.Errorf("arguments = %d, last %q; expected %d ending with ... (10 more arguments)", len(arguments), arguments[len(arguments)-1], slowlogMaxArguments)
+	}
+
+	arguments = slowlogArguments(LookupCommandSpec(CmdAUTH), &RedisCommand{Name: "auth", Args: []string{"user", "secret"}})
+	if strings.Join(arguments, " ") != "auth (redacted) (redacted)" {
+		t.Errorf("AUTH arguments = %q, expected redacted", arguments)
+	}
+	arguments = slowlogArguments(LookupCommandSpec(CmdHELLO), &RedisCommand{Name: "hello", Args: []string{"3", "AUTH", "user", "secret", "SETNAME", "n"}})
+	if strings.Join(arguments, " ") != "hello 3 AUTH (redacted) (redacted) SETNAME n" {
+		t.Errorf("HELLO arguments = %q, expected redacted credentials", arguments)
+	}
+}
+
+func TestSlowlogSkipsBlockingCommands(t *testing.T) {
+	resetSlowlogTestState(t)
+	serverConnection, _ := testConnectionPair(t)
+	serverConfig.SlowlogLogSlowerThan = 0
+
+	recordSlowCommand(serverConnection, LookupCommandSpec(CmdBLPOP), &RedisCommand{Type: CmdBLPOP, Args: []string{"list", "1"}}, time.Second)
+	if SlowlogLen() != 0 {
+		t.Errorf("BLPOP was recorded in the slow log")
+	}
+}
+
+func TestSlowlogRejectsInvalidArguments(t *testing.T) {
+	resetSlowlogTestState(t)
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"GET", "-2"}, "-ERR count should be greater than or equal to -1\r\n"},
+		{[]string{"GET", "x"}, "-ERR value is not an integer or out of range\r\n"},
+		{[]string{"LEN", "x"}, "-ERR wrong number of arguments for 'slowlog|len' command\r\n"},
+		{[]string{"FOO"}, "-ERR unknown subcommand 'FOO'. Try SLOWLOG HELP.\r\n"},
+	} {
+		if result := slowlogCommand(test.args...); result != test.expected {
+			t.Errorf("SLOWLOG %v = %q, expected %q", test.args, result, test.expected)
+		}
+	}
+}
@@ -34,8 +34,10 @@ func (sortedSet *SortedSet) MemberCount() int {
 	return len(sortedSet.memberScores)
 }
 
+// GetSortedSet returns the sorted set stored at key for a command that reads
+// it.
 func (cache *Cache) GetSortedSet(key string) *SortedSet {
-	value := cache.Get(key)
+	value := cache.LookupRead(key)
 	if value == nil {
 		return nil
 	}
@@ -65,10 +67,10 @@ func (cache *Cache) Zadd(key string, score float64, member string) int {
 	sortedSet.memberScores[member] = score
 	sortedSet.orderedIndex.Insert(score, member)
 
-	cache.cache[key] = CacheItem{
+	cache.storeLocked(key, CacheItem{
 		Value:      sortedSet,
 		Expiration: 0,
-	}
+	})
 
 	return 1
 }
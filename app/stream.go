@@ -6,15 +6,18 @@ import (
 	"strings"
 )
 
+var (
+	errXaddIDMustBeGreaterThanZeroZero = ErrorReply("ERR The ID specified in XADD must be greater than 0-0")
+	errXaddIDEqualOrSmallerThanTop     = ErrorReply("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+)
+
 const (
-	errXaddIDMustBeGreaterThanZeroZero       = "-ERR The ID specified in XADD must be greater than 0-0\r\n"
-	errXaddIDEqualOrSmallerThanTop           = "-ERR The ID specified in XADD is equal or smaller than the target stream top item\r\n"
-	zeroEntryID                              = "0-0"
-	autoGeneratedSequenceWildcard            = "*"
-	xrangeEndOfStreamSentinel                = "+"
-	xreadNewEntriesSentinel                  = "$"
-	startSequenceNumberDefault         int64 = 0
-	endSequenceNumberDefault           int64 = 9223372036854775807
+	zeroEntryID                         = "0-0"
+	autoGeneratedSequenceWildcard       = "*"
+	xrangeEndOfStreamSentinel           = "+"
+	xreadNewEntriesSentinel             = "$"
+	startSequenceNumberDefault    int64 = 0
+	endSequenceNumberDefault      int64 = 9223372036854775807
 )
 
 type StreamEntry struct {
@@ -81,10 +84,10 @@ func (c *Cache) AddStreamEntry(streamKey string, entryID string, fieldValues []s
 		FieldValues: append([]string(nil), fieldValues...),
 	})
 
-	c.cache[streamKey] = CacheItem{
+	c.storeLocked(streamKey, CacheItem{
 		Value:      stream,
 		Expiration: 0,
-	}
+	})
 }
 
 func (c *Cache) GetStream(streamKey string) *Stream {
@@ -104,6 +107,13 @@ func (c *Cache) GetStream(streamKey string) *Stream {
 	return stream
 }
 
+// ReadStream returns the stream stored at streamKey for a command that reads
+// it, counting the lookup like LookupRead. Other types read as no stream.
+func (c *Cache) ReadStream(streamKey string) *Stream {
+	stream, _ := c.LookupRead(streamKey).(*Stream)
+	return stream
+}
+
 func parseEntryID(entryID string) (milliseconds int64, sequence int64, err error) {
 	parts := strings.Split(entryID, "-")
 	if len(parts) != 2 {
@@ -23,10 +23,9 @@ func ResetConnectionPubSubStatesForTest() {
 	connectionPubSubStates = make(map[net.Conn]*connectionPubSubState)
 }
 
-func getConnectionPubSubState(connection net.Conn) *connectionPubSubState {
-	connectionPubSubMutex.Lock()
-	defer connectionPubSubMutex.Unlock()
-
+// getConnectionPubSubStateLocked must be called with connectionPubSubMutex
+// held.
+func getConnectionPubSubStateLocked(connection net.Conn) *connectionPubSubState {
 	state, exists := connectionPubSubStates[connection]
 	if !exists {
 		state = &connectionPubSubState{
@@ -45,66 +44,118 @@ func RemoveConnectionPubSubState(connection net.Conn) {
 	delete(connectionPubSubStates, connection)
 }
 
-func getConnectionPubSubStateIfExists(connection net.Conn) (*connectionPubSubState, bool) {
+// subscribeConnection adds channel to the connection's subscriptions and
+// returns how many it now has. PUBLISH, INFO and the idle reaper read other
+// connections' subscriptions, so they only change under the lock.
+func subscribeConnection(connection net.Conn, channel string) int {
+	connectionPubSubMutex.Lock()
+	defer connectionPubSubMutex.Unlock()
+
+	state := getConnectionPubSubStateLocked(connection)
+	state.subscribedChannels[channel] = struct{}{}
+
+	return len(state.subscribedChannels)
+}
+
+// unsubscribeConnection removes channel from the connection's subscriptions
+// and returns how many remain.
+func unsubscribeConnection(connection net.Conn, channel string) int {
 	connectionPubSubMutex.Lock()
 	defer connectionPubSubMutex.Unlock()
 
 	state, exists := connectionPubSubStates[connection]
-	return state, exists
+	if !exists {
+		return 0
+	}
+	delete(state.subscribedChannels, channel)
+
+	return len(state.subscribedChannels)
 }
 
-func isConnectionInSubscribedMode(connection net.Conn) bool {
-	state, exists := getConnectionPubSubStateIfExists(connection)
+// connectionSubscriptionCount returns how many channels the connection is
+// subscribed to.
+func connectionSubscriptionCount(connection net.Conn) int {
+	connectionPubSubMutex.Lock()
+	defer connectionPubSubMutex.Unlock()
+
+	state, exists := connectionPubSubStates[connection]
 	if !exists {
+		return 0
+	}
+
+	return len(state.subscribedChannels)
+}
+
+// isConnectionInSubscribedMode reports whether the connection is limited to
+// pub/sub commands. RESP3 clients receive messages as push frames and so can
+// keep issuing regular commands while subscribed.
+func isConnectionInSubscribedMode(connection net.Conn) bool {
+	if ConnectionProtocolVersion(connection) >= protocolVersionRESP3 {
 		return false
 	}
 
-	return len(state.subscribedChannels) > 0
+	return connectionSubscriptionCount(connection) > 0
 }
 
 func isCommandAllowedInSubscribedMode(commandType CommandType) bool {
 	switch commandType {
-	case CmdSUBSCRIBE, CmdUNSUBSCRIBE, CmdPSUBSCRIBE, CmdPUNSUBSCRIBE, CmdPING, CmdQUIT, CmdRESET:
+	case CmdSUBSCRIBE, CmdUNSUBSCRIBE, CmdPING, CmdQUIT, CmdRESET:
 		return true
 	default:
 		return false
 	}
 }
 
-func subscribedModeErrorResponse(commandType CommandType) string {
+func subscribedModeErrorResponse(commandType CommandType) Reply {
 	commandName := strings.ToLower(commandType.String())
 
-	return fmt.Sprintf(
-		"-ERR Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context\r\n",
+	return ErrorReply(fmt.Sprintf(
+		"ERR Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context",
 		commandName,
-	)
+	))
 }
 
-func parseSubscribeCommandArguments(command *RedisCommand) (channel string, errorResponse string) {
+func parseSubscribeCommandArguments(command *RedisCommand) (channel string, errorResponse Reply) {
 	if len(command.Args) != 1 {
-		return "", "-ERR wrong number of arguments for 'subscribe' command\r\n"
+		return "", WrongNumberOfArgumentsReply("subscribe")
 	}
 
-	return command.Args[0], ""
+	return command.Args[0], Reply{}
 }
 
-func encodeSubscribeResponse(channel string, subscriptionCount int) string {
-	return fmt.Sprintf(
-		"*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n",
-		len(channel),
-		channel,
-		subscriptionCount,
+// encodeSubscribeResponse builds a subscribe confirmation. It is a push frame,
+// so RESP3 clients can tell it apart from regular command replies.
+func encodeSubscribeResponse(channel string, subscriptionCount int) Reply {
+	return PushReply(
+		BulkStringReply("subscribe"),
+		BulkStringReply(channel),
+		IntegerReply(int64(subscriptionCount)),
 	)
 }
 
-func HandleSubscribe(connection net.Conn, command *RedisCommand) string {
+func HandleSubscribe(connection net.Conn, command *RedisCommand) Reply {
 	channel, errorResponse := parseSubscribeCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
-	pubSubState := getConnectionPubSubState(connection)
-	pubSubState.subscribedChannels[channel] = struct{}{}
+	getConnectionOutput(connection)
+	subscriptionCount := subscribeConnection(connection, channel)
+
+	return encodeSubscribeResponse(channel, subscriptionCount)
+}
+
+// activeChannelCount returns how many distinct channels have subscribers.
+func activeChannelCount() int {
+	connectionPubSubMutex.Lock()
+	defer connectionPubSubMutex.Unlock()
+
+	channels := make(map[string]struct{})
+	for _, pubSubState := range connectionPubSubStates {
+		for channel := range pubSubState.subscribedChannels {
+			channels[channel] = struct{}{}
+		}
+	}
 
-	return encodeSubscribeResponse(channel, len(pubSubState.subscribedChannels))
+	return len(channels)
 }
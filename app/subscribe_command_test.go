@@ -8,7 +8,7 @@ func resetSubscribeTestState(t *testing.T) {
 }
 
 func formatExpectedSubscribeResponse(channel string, subscriptionCount int) string {
-	return encodeSubscribeResponse(channel, subscriptionCount)
+	return encodeSubscribeResponse(channel, subscriptionCount).String()
 }
 
 func TestParseSubscribeCommandArguments(t *testing.T) {
@@ -42,8 +42,8 @@ func TestParseSubscribeCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseSubscribeCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseSubscribeCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if channel != testCase.expectedChannel {
@@ -61,7 +61,7 @@ func TestHandleSubscribeReturnsSubscribeConfirmation(t *testing.T) {
 	result := HandleSubscribe(connection, &RedisCommand{
 		Type: CmdSUBSCRIBE,
 		Args: []string{"foo"},
-	})
+	}).String()
 
 	expected := formatExpectedSubscribeResponse("foo", 1)
 	if result != expected {
@@ -77,7 +77,7 @@ func TestHandleSubscribeCodecraftersStageExample(t *testing.T) {
 	result := HandleSubscribe(connection, &RedisCommand{
 		Type: CmdSUBSCRIBE,
 		Args: []string{"mychan"},
-	})
+	}).String()
 
 	expected := "*3\r\n$9\r\nsubscribe\r\n$6\r\nmychan\r\n:1\r\n"
 	if result != expected {
@@ -93,7 +93,7 @@ func TestHandleSubscribeArgumentParsing(t *testing.T) {
 	result := HandleSubscribe(connection, &RedisCommand{
 		Type: CmdSUBSCRIBE,
 		Args: []string{},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'subscribe' command\r\n" {
 		t.Errorf("HandleSubscribe() = %q, expected %q", result, "-ERR wrong number of arguments for 'subscribe' command\r\n")
@@ -135,7 +135,7 @@ func TestHandleConnectionCommandRejectsDisallowedCommandsInSubscribedMode(t *tes
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-			result := HandleConnectionCommand(connection, testCase.command)
+			result := HandleConnectionCommand(connection, testCase.command).String()
 
 			expectedPrefix := "-ERR Can't execute '" + testCase.commandName + "'"
 			if len(result) < len(expectedPrefix) || result[:len(expectedPrefix)] != expectedPrefix {
@@ -159,7 +159,7 @@ func TestHandleConnectionCommandAllowsSubscribeAndPingInSubscribedMode(t *testin
 	secondSubscribeResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdSUBSCRIBE,
 		Args: []string{"bar"},
-	})
+	}).String()
 	expectedSecondSubscribe := formatExpectedSubscribeResponse("bar", 2)
 	if secondSubscribeResult != expectedSecondSubscribe {
 		t.Errorf("second SUBSCRIBE = %q, expected %q", secondSubscribeResult, expectedSecondSubscribe)
@@ -168,9 +168,9 @@ func TestHandleConnectionCommandAllowsSubscribeAndPingInSubscribedMode(t *testin
 	pingResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdPING,
 		Args: []string{},
-	})
-	if pingResult != subscribedModePingResponse {
-		t.Errorf("PING in subscribed mode = %q, expected %q", pingResult, subscribedModePingResponse)
+	}).String()
+	if pingResult != subscribedModePingResponse.String() {
+		t.Errorf("PING in subscribed mode = %q, expected %q", pingResult, subscribedModePingResponse.String())
 	}
 }
 
@@ -182,9 +182,9 @@ func TestHandlePingReturnsNormalResponseWhenNotSubscribed(t *testing.T) {
 	pingResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdPING,
 		Args: []string{},
-	})
-	if pingResult != normalPingResponse {
-		t.Errorf("PING before subscribe = %q, expected %q", pingResult, normalPingResponse)
+	}).String()
+	if pingResult != normalPingResponse.String() {
+		t.Errorf("PING before subscribe = %q, expected %q", pingResult, normalPingResponse.String())
 	}
 }
 
@@ -201,9 +201,9 @@ func TestHandlePingReturnsSubscribedModeResponseAfterSubscribe(t *testing.T) {
 	pingResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdPING,
 		Args: []string{},
-	})
-	if pingResult != subscribedModePingResponse {
-		t.Errorf("PING in subscribed mode = %q, expected %q", pingResult, subscribedModePingResponse)
+	}).String()
+	if pingResult != subscribedModePingResponse.String() {
+		t.Errorf("PING in subscribed mode = %q, expected %q", pingResult, subscribedModePingResponse.String())
 	}
 }
 
@@ -216,7 +216,7 @@ func TestHandleConnectionCommandAllowsCommandsBeforeSubscribe(t *testing.T) {
 	echoResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdECHO,
 		Args: []string{"hey"},
-	})
+	}).String()
 	if echoResult != "$3\r\nhey\r\n" {
 		t.Errorf("ECHO before subscribe = %q, expected %q", echoResult, "$3\r\nhey\r\n")
 	}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Values of tls-auth-clients.
+const (
+	tlsAuthClientsYes      = "yes"
+	tlsAuthClientsNo       = "no"
+	tlsAuthClientsOptional = "optional"
+)
+
+// masterDialTimeout bounds how long a replica waits to connect to its master.
+const masterDialTimeout = 5 * time.Second
+
+// ServerTLSConfig builds the configuration for the tls-port listener from
+// tls-cert-file, tls-key-file, tls-ca-cert-file and tls-auth-clients.
+func ServerTLSConfig(config Config) (*tls.Config, error) {
+	certificate, err := loadTLSCertificate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	authClients := strings.ToLower(config.TLSAuthClients)
+	if authClients == "" {
+		authClients = tlsAuthClientsYes
+	}
+
+	switch authClients {
+	case tlsAuthClientsNo:
+		tlsConfig.ClientAuth = tls.NoClientCert
+		return tlsConfig, nil
+	case tlsAuthClientsYes:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case tlsAuthClientsOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("invalid tls-auth-clients '%s', expected yes, no or optional", config.TLSAuthClients)
+	}
+
+	if config.TLSCACertFile == "" {
+		return nil, errors.New("tls-ca-cert-file is required to verify client certificates")
+	}
+	tlsConfig.ClientCAs, err = loadTLSCertificatePool(config.TLSCACertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsConfig, nil
+}
+
+// ReplicationTLSConfig builds the configuration a replica uses to dial its
+// master when tls-replication is enabled. The master is verified against
+// tls-ca-cert-file, and the replica presents its own certificate so that a
+// master requiring client certificates accepts it.
+func ReplicationTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: config.MasterHost,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		certificate, err := loadTLSCertificate(config)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.TLSCACertFile != "" {
+		pool, err := loadTLSCertificatePool(config.TLSCACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// dialMaster opens the replication link, over TLS when tls-replication is
+// enabled.
+func dialMaster(config Config) (net.Conn, error) {
+	masterAddress := net.JoinHostPort(config.MasterHost, config.MasterPort)
+	if !config.TLSReplication {
+		return net.DialTimeout("tcp", masterAddress, masterDialTimeout)
+	}
+
+	tlsConfig, err := ReplicationTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: masterDialTimeout}
+	return tls.DialWithDialer(dialer, "tcp", masterAddress, tlsConfig)
+}
+
+func loadTLSCertificate(config Config) (tls.Certificate, error) {
+	if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+		return tls.Certificate{}, errors.New("both tls-cert-file and tls-key-file must be set")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load certificate %s: %w", config.TLSCertFile, err)
+	}
+
+	return certificate, nil
+}
+
+func loadTLSCertificatePool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate %s: %w", path, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// underlyingConnection returns the transport beneath a TLS connection so
+// socket options can be applied to it.
+func underlyingConnection(connection net.Conn) net.Conn {
+	if tlsConnection, isTLS := connection.(*tls.Conn); isTLS {
+		return tlsConnection.NetConn()
+	}
+	return connection
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificates are PEM files for a throwaway CA, a server certificate for
+// 127.0.0.1/localhost and a client certificate, all signed by that CA.
+type testCertificates struct {
+	caFile         string
+	serverCertFile string
+	serverKeyFile  string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+func generateTestCertificates(t *testing.T) testCertificates {
+	t.Helper()
+	directory := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA certificate: %v", err)
+	}
+	caCertificate, _ := x509.ParseCertificate(caDER)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("generate %s key: %v", name, err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCertificate, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("create %s certificate: %v", name, err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("marshal %s key: %v", name, err)
+		}
+
+		certFile := filepath.Join(directory, name+".crt")
+		keyFile := filepath.Join(directory, name+".key")
+		writePEMFile(t, certFile, "CERTIFICATE", der)
+		writePEMFile(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+
+	certificates := testCertificates{caFile: filepath.Join(directory, "ca.crt")}
+	writePEMFile(t, certificates.caFile, "CERTIFICATE", caDER)
+	certificates.serverCertFile, certificates.serverKeyFile = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	certificates.clientCertFile, certificates.clientKeyFile = issue("client", 3, x509.ExtKeyUsageClientAuth)
+
+	return certificates
+}
+
+func writePEMFile(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func startTLSServer(t *testing.T, config Config) string {
+	t.Helper()
+	resetClientTestState(t)
+
+	tlsConfig, err := ServerTLSConfig(config)
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatalf("tls.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go serveConnections(listener)
+
+	return listener.Addr().String()
+}
+
+func pingOverTLS(address string, clientConfig *tls.Config) (string, error) {
+	connection, err := tls.DialWithDialer(&net.Dialer{Timeout: 2 * time.Second}, "tcp", address, clientConfig)
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+	connection.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err := connection.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		return "", err
+	}
+	return bufio.NewReader(connection).ReadString('\n')
+}
+
+func TestServerTLSConfigValidatesSettings(t *testing.T) {
+	certificates := generateTestCertificates(t)
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"missing key", Config{TLSCertFile: certificates.serverCertFile}},
+		{"client verification without CA", Config{TLSCertFile: certificates.serverCertFile, TLSKeyFile: certificates.serverKeyFile, TLSAuthClients: "yes"}},
+		{"unknown tls-auth-clients", Config{TLSCertFile: certificates.serverCertFile, TLSKeyFile: certificates.serverKeyFile, TLSCACertFile: certificates.caFile, TLSAuthClients: "maybe"}},
+		{"CA file without certificates", Config{TLSCertFile: certificates.serverCertFile, TLSKeyFile: certificates.serverKeyFile, TLSCACertFile: certificates.serverKeyFile}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ServerTLSConfig(test.config); err == nil {
+				t.Error("ServerTLSConfig() returned no error")
+			}
+		})
+	}
+}
+
+func TestTLSListenerRequiresClientCertificates(t *testing.T) {
+	certificates := generateTestCertificates(t)
+	address := startTLSServer(t, Config{
+		TLSCertFile:   certificates.serverCertFile,
+		TLSKeyFile:    certificates.serverKeyFile,
+		TLSCACertFile: certificates.caFile,
+	})
+
+	replicationConfig := Config{
+		MasterHost:    "127.0.0.1",
+		TLSCertFile:   certificates.clientCertFile,
+		TLSKeyFile:    certificates.clientKeyFile,
+		TLSCACertFile: certificates.caFile,
+	}
+	withCertificate, err := ReplicationTLSConfig(replicationConfig)
+	if err != nil {
+		t.Fatalf("ReplicationTLSConfig() error = %v", err)
+	}
+	if reply, err := pingOverTLS(address, withCertificate); err != nil || reply != "+PONG\r\n" {
+		t.Fatalf("PING with a client certificate = %q, %v", reply, err)
+	}
+
+	withoutCertificate := withCertificate.Clone()
+	withoutCertificate.Certificates = nil
+	if reply, err := pingOverTLS(address, withoutCertificate); err == nil {
+		t.Fatalf("PING without a client certificate = %q, expected the handshake to fail", reply)
+	}
+}
+
+func TestTLSListenerWithOptionalClientCertificates(t *testing.T) {
+	certificates := generateTestCertificates(t)
+	address := startTLSServer(t, Config{
+		TLSCertFile:    certificates.serverCertFile,
+		TLSKeyFile:     certificates.serverKeyFile,
+		TLSCACertFile:  certificates.caFile,
+		TLSAuthClients: "optional",
+	})
+
+	clientConfig, err := ReplicationTLSConfig(Config{MasterHost: "127.0.0.1", TLSCACertFile: certificates.caFile})
+	if err != nil {
+		t.Fatalf("ReplicationTLSConfig() error = %v", err)
+	}
+	if reply, err := pingOverTLS(address, clientConfig); err != nil || reply != "+PONG\r\n" {
+		t.Fatalf("PING without a client certificate = %q, %v", reply, err)
+	}
+}
+
+func TestInitiateHandshakeOverTLS(t *testing.T) {
+	certificates := generateTestCertificates(t)
+	masterTLSConfig, err := ServerTLSConfig(Config{
+		TLSCertFile:   certificates.serverCertFile,
+		TLSKeyFile:    certificates.serverKeyFile,
+		TLSCACertFile: certificates.caFile,
+	})
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	masterListener, err := tls.Listen("tcp", "127.0.0.1:0", masterTLSConfig)
+	if err != nil {
+		t.Fatalf("tls.Listen() error = %v", err)
+	}
+	defer masterListener.Close()
+	host, port, _ := net.SplitHostPort(masterListener.Addr().String())
+
+	go InitiateHandshake(Config{
+		IsReplica:      true,
+		MasterHost:     host,
+		MasterPort:     port,
+		TLSReplication: true,
+		TLSCertFile:    certificates.clientCertFile,
+		TLSKeyFile:     certificates.clientKeyFile,
+		TLSCACertFile:  certificates.caFile,
+	})
+
+	masterConnection, err := masterListener.Accept()
+	if err != nil {
+		t.Fatalf("accept replica: %v", err)
+	}
+	defer masterConnection.Close()
+	masterConnection.SetDeadline(time.Now().Add(2 * time.Second))
+
+	line, err := bufio.NewReader(masterConnection).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read PING over TLS: %v", err)
+	}
+	if line != "*1\r\n" {
+		t.Errorf("first line from replica = %q, expected the PING array header", line)
+	}
+}
+
+func TestSilentTLSClientBeyondMaxClientsDoesNotBlockAccept(t *testing.T) {
+	certificates := generateTestCertificates(t)
+	address := startTLSServer(t, Config{
+		TLSCertFile:    certificates.serverCertFile,
+		TLSKeyFile:     certificates.serverKeyFile,
+		TLSCACertFile:  certificates.caFile,
+		TLSAuthClients: "no",
+	})
+	// The server goroutines read the config concurrently, so change it
+	// under the config lock.
+	setMaxClients := func(maxClients int) {
+		configMutex.Lock()
+		serverConfig.MaxClients = maxClients
+		configMutex.Unlock()
+	}
+	originalMaxClients := GetConfig().MaxClients
+	setMaxClients(1)
+	t.Cleanup(func() { setMaxClients(originalMaxClients) })
+
+	occupant := testConnection(t)
+	RegisterClient(occupant)
+
+	// Connects past maxclients and never sends a ClientHello.
+	rejectedBefore := rejectedConnections.Load()
+	silent, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { silent.Close() })
+	for deadline := time.Now().Add(2 * time.Second); rejectedConnections.Load() == rejectedBefore; {
+		if time.Now().After(deadline) {
+			t.Fatal("the silent client was never rejected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	UnregisterClient(occupant)
+	clientConfig, err := ReplicationTLSConfig(Config{MasterHost: "127.0.0.1", TLSCACertFile: certificates.caFile})
+	if err != nil {
+		t.Fatalf("ReplicationTLSConfig() error = %v", err)
+	}
+	if reply, err := pingOverTLS(address, clientConfig); err != nil || reply != "+PONG\r\n" {
+		t.Fatalf("PING after a silent rejected client = %q, %v; expected the server to keep accepting", reply, err)
+	}
+}
@@ -1,19 +1,19 @@
 package main
 
-func HandleType(command *RedisCommand) string {
+func HandleType(command *RedisCommand) Reply {
 	if len(command.Args) != 1 {
-		return "-ERR wrong number of arguments for 'type' command\r\n"
+		return WrongNumberOfArgumentsReply("type")
 	}
 
 	cache := GetInstance()
-	value := cache.Get(command.Args[0])
+	value := cache.LookupRead(command.Args[0])
 	if value == nil {
-		return "+none\r\n"
+		return SimpleStringReply("none")
 	}
 
 	if _, isStream := value.(*Stream); isStream {
-		return "+stream\r\n"
+		return SimpleStringReply("stream")
 	}
 
-	return "+string\r\n"
+	return SimpleStringReply("string")
 }
@@ -71,7 +71,7 @@ func TestHandleType(t *testing.T) {
 				tt.setup()
 			}
 
-			result := HandleType(tt.cmd)
+			result := HandleType(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleType() = %q, expected %q", result, tt.expected)
 			}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// listenUnixSocket listens on a Unix domain socket at path. A stale socket
+// left behind by a previous run is removed first, and when perm is non-zero
+// the socket file is created with those permissions so that filesystem
+// access control decides who may connect.
+func listenUnixSocket(path string, perm os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket %s: %w", path, err)
+		}
+	}
+
+	if perm == 0 {
+		return net.Listen("unix", path)
+	}
+
+	// bind creates the socket file as 0777 less the umask. Setting the umask
+	// to the complement of perm creates it with exactly perm, so it is never
+	// reachable with wider permissions, as a chmod after Listen would allow.
+	previousUmask := syscall.Umask(int(^perm & os.ModePerm))
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(previousUmask)
+
+	return listener, err
+}
+
+// isUnixSocketConnection reports whether a client connected over the Unix
+// socket rather than TCP.
+func isUnixSocketConnection(connection net.Conn) bool {
+	address := connection.LocalAddr()
+	return address != nil && address.Network() == "unix"
+}
+
+// clientAddress is the addr field of CLIENT LIST. Unix socket peers have no
+// address of their own, so like Redis they are reported as "<socket>:0".
+func clientAddress(connection net.Conn) string {
+	if isUnixSocketConnection(connection) {
+		return connection.LocalAddr().String() + ":0"
+	}
+	return connectionAddress(connection.RemoteAddr())
+}
+
+// clientLocalAddress is the laddr field of CLIENT LIST.
+func clientLocalAddress(connection net.Conn) string {
+	if isUnixSocketConnection(connection) {
+		return connection.LocalAddr().String() + ":0"
+	}
+	return connectionAddress(connection.LocalAddr())
+}
+
+// fileModeValue is a flag.Value for permissions written in octal, e.g. 700.
+type fileModeValue os.FileMode
+
+func (value *fileModeValue) String() string {
+	return strconv.FormatUint(uint64(*value), 8)
+}
+
+func (value *fileModeValue) Set(text string) error {
+	mode, err := strconv.ParseUint(text, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("invalid permissions '%s'", text)
+	}
+
+	*value = fileModeValue(mode)
+	return nil
+}
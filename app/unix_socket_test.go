@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func startUnixSocketServer(t *testing.T, perm os.FileMode) string {
+	t.Helper()
+	resetClientTestState(t)
+
+	// Socket paths are limited to about 100 bytes, so avoid t.TempDir's long
+	// names.
+	directory, err := os.MkdirTemp("", "redis-sock")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(directory) })
+	path := filepath.Join(directory, "redis.sock")
+
+	listener, err := listenUnixSocket(path, perm)
+	if err != nil {
+		t.Fatalf("listenUnixSocket() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go serveConnections(listener)
+
+	return path
+}
+
+func TestListenUnixSocketAppliesPermissions(t *testing.T) {
+	path := startUnixSocketServer(t, 0700)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		t.Errorf("%s is not a socket", path)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("socket permissions = %o, expected 700", info.Mode().Perm())
+	}
+}
+
+func TestListenUnixSocketCreatesSocketWithPermissions(t *testing.T) {
+	previousUmask := syscall.Umask(0)
+	defer syscall.Umask(previousUmask)
+
+	path := filepath.Join(t.TempDir(), "redis.sock")
+	listener, err := listenUnixSocket(path, 0700)
+	if err != nil {
+		t.Fatalf("listenUnixSocket() error = %v", err)
+	}
+	defer listener.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("socket mode = %v, %v; expected it to be created with 700 under an open umask", info.Mode(), err)
+	}
+	if umask := syscall.Umask(0); umask != 0 {
+		t.Errorf("umask after listening = %o, expected it to be restored to 0", umask)
+	}
+}
+
+func TestListenUnixSocketReplacesStaleSocket(t *testing.T) {
+	directory, err := os.MkdirTemp("", "redis-sock")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "redis.sock")
+
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenUnixSocket(path, 0)
+	if err != nil {
+		t.Fatalf("listenUnixSocket() over a stale socket error = %v", err)
+	}
+	listener.Close()
+}
+
+func TestUnixSocketClientsAreServedAndListed(t *testing.T) {
+	path := startUnixSocketServer(t, 0)
+
+	connection, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial unix socket: %v", err)
+	}
+	defer connection.Close()
+	connection.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(connection)
+
+	connection.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	if reply, err := reader.ReadString('\n'); err != nil || reply != "+PONG\r\n" {
+		t.Fatalf("PING over unix socket = %q, %v", reply, err)
+	}
+
+	connection.Write([]byte("*2\r\n$6\r\nCLIENT\r\n$4\r\nINFO\r\n"))
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("read CLIENT INFO header: %v", err)
+	}
+	info, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read CLIENT INFO: %v", err)
+	}
+	if !strings.Contains(info, "addr="+path+":0 laddr="+path+":0 ") {
+		t.Errorf("CLIENT INFO = %q, expected the socket path as addr and laddr", info)
+	}
+	if !strings.Contains(info, " flags=U ") {
+		t.Errorf("CLIENT INFO = %q, expected the U flag", info)
+	}
+}
+
+func TestFileModeValue(t *testing.T) {
+	var mode fileModeValue
+	if err := mode.Set("755"); err != nil || os.FileMode(mode) != 0755 {
+		t.Errorf("Set(755) = %o, %v", mode, err)
+	}
+	for _, input := range []string{"", "800", "1777", "rw"} {
+		if err := mode.Set(input); err == nil {
+			t.Errorf("Set(%q) returned no error", input)
+		}
+	}
+}
@@ -1,37 +1,28 @@
 package main
 
-import (
-	"fmt"
-	"net"
-)
+import "net"
 
-func parseUnsubscribeCommandArguments(command *RedisCommand) (channel string, errorResponse string) {
+func parseUnsubscribeCommandArguments(command *RedisCommand) (channel string, errorResponse Reply) {
 	if len(command.Args) != 1 {
-		return "", "-ERR wrong number of arguments for 'unsubscribe' command\r\n"
+		return "", WrongNumberOfArgumentsReply("unsubscribe")
 	}
 
-	return command.Args[0], ""
+	return command.Args[0], Reply{}
 }
 
-func encodeUnsubscribeResponse(channel string, remainingSubscriptionCount int) string {
-	return fmt.Sprintf(
-		"*3\r\n$11\r\nunsubscribe\r\n$%d\r\n%s\r\n:%d\r\n",
-		len(channel),
-		channel,
-		remainingSubscriptionCount,
+func encodeUnsubscribeResponse(channel string, remainingSubscriptionCount int) Reply {
+	return PushReply(
+		BulkStringReply("unsubscribe"),
+		BulkStringReply(channel),
+		IntegerReply(int64(remainingSubscriptionCount)),
 	)
 }
 
-func HandleUnsubscribe(connection net.Conn, command *RedisCommand) string {
+func HandleUnsubscribe(connection net.Conn, command *RedisCommand) Reply {
 	channel, errorResponse := parseUnsubscribeCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
-	pubSubState := getConnectionPubSubState(connection)
-	if _, subscribed := pubSubState.subscribedChannels[channel]; subscribed {
-		delete(pubSubState.subscribedChannels, channel)
-	}
-
-	return encodeUnsubscribeResponse(channel, len(pubSubState.subscribedChannels))
+	return encodeUnsubscribeResponse(channel, unsubscribeConnection(connection, channel))
 }
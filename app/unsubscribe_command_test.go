@@ -12,7 +12,7 @@ func resetUnsubscribeTestState(t *testing.T) {
 }
 
 func formatExpectedUnsubscribeResponse(channel string, remainingSubscriptionCount int) string {
-	return encodeUnsubscribeResponse(channel, remainingSubscriptionCount)
+	return encodeUnsubscribeResponse(channel, remainingSubscriptionCount).String()
 }
 
 func TestParseUnsubscribeCommandArguments(t *testing.T) {
@@ -46,8 +46,8 @@ func TestParseUnsubscribeCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseUnsubscribeCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseUnsubscribeCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if channel != testCase.expectedChannel {
@@ -74,7 +74,7 @@ func TestHandleUnsubscribeCodecraftersStageExample(t *testing.T) {
 	unsubscribeFooResult := HandleUnsubscribe(connection, &RedisCommand{
 		Type: CmdUNSUBSCRIBE,
 		Args: []string{"foo"},
-	})
+	}).String()
 	if unsubscribeFooResult != formatExpectedUnsubscribeResponse("foo", 1) {
 		t.Errorf("HandleUnsubscribe(foo) = %q, expected %q", unsubscribeFooResult, formatExpectedUnsubscribeResponse("foo", 1))
 	}
@@ -82,7 +82,7 @@ func TestHandleUnsubscribeCodecraftersStageExample(t *testing.T) {
 	unsubscribeBarResult := HandleUnsubscribe(connection, &RedisCommand{
 		Type: CmdUNSUBSCRIBE,
 		Args: []string{"bar"},
-	})
+	}).String()
 	if unsubscribeBarResult != formatExpectedUnsubscribeResponse("bar", 0) {
 		t.Errorf("HandleUnsubscribe(bar) = %q, expected %q", unsubscribeBarResult, formatExpectedUnsubscribeResponse("bar", 0))
 	}
@@ -101,7 +101,7 @@ func TestHandleUnsubscribeFromUnsubscribedChannelDoesNotChangeCount(t *testing.T
 	result := HandleUnsubscribe(connection, &RedisCommand{
 		Type: CmdUNSUBSCRIBE,
 		Args: []string{"bar"},
-	})
+	}).String()
 	if result != formatExpectedUnsubscribeResponse("bar", 1) {
 		t.Errorf("HandleUnsubscribe(bar) = %q, expected %q", result, formatExpectedUnsubscribeResponse("bar", 1))
 	}
@@ -126,7 +126,7 @@ func TestHandleUnsubscribeLeavesSubscribedModeWhenLastChannelRemoved(t *testing.
 	echoResult := HandleConnectionCommand(connection, &RedisCommand{
 		Type: CmdECHO,
 		Args: []string{"hey"},
-	})
+	}).String()
 	if echoResult != "$3\r\nhey\r\n" {
 		t.Errorf("ECHO after last unsubscribe = %q, expected %q", echoResult, "$3\r\nhey\r\n")
 	}
@@ -152,7 +152,7 @@ func TestHandleUnsubscribeStopsMessageDeliveryForChannel(t *testing.T) {
 		resultChannel <- HandlePublish(&RedisCommand{
 			Type: CmdPUBLISH,
 			Args: []string{"foo", "after-unsubscribe"},
-		})
+		}).String()
 	}()
 
 	select {
@@ -179,7 +179,7 @@ func TestHandleUnsubscribeArgumentParsing(t *testing.T) {
 	result := HandleUnsubscribe(connection, &RedisCommand{
 		Type: CmdUNSUBSCRIBE,
 		Args: []string{},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'unsubscribe' command\r\n" {
 		t.Errorf("HandleUnsubscribe() = %q, expected %q", result, "-ERR wrong number of arguments for 'unsubscribe' command\r\n")
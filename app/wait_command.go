@@ -7,19 +7,19 @@ import (
 )
 
 // HandleWait processes a WAIT command and returns a RESP integer response.
-func HandleWait(command *RedisCommand) string {
+func HandleWait(command *RedisCommand) Reply {
 	if len(command.Args) != 2 {
-		return "-ERR wrong number of arguments for 'wait' command\r\n"
+		return WrongNumberOfArgumentsReply("wait")
 	}
 
 	requiredReplicaCount, parseError := strconv.Atoi(command.Args[0])
 	if parseError != nil {
-		return fmt.Sprintf("-ERR invalid numreplicas value %q\r\n", command.Args[0])
+		return ErrorReply(fmt.Sprintf("ERR invalid numreplicas value %q", command.Args[0]))
 	}
 
 	timeoutMilliseconds, parseError := strconv.Atoi(command.Args[1])
 	if parseError != nil {
-		return fmt.Sprintf("-ERR invalid timeout value %q\r\n", command.Args[1])
+		return ErrorReply(fmt.Sprintf("ERR invalid timeout value %q", command.Args[1]))
 	}
 
 	targetReplicationOffset := CurrentMasterReplicationOffset()
@@ -34,14 +34,14 @@ func HandleWait(command *RedisCommand) string {
 		acknowledgedReplicaCount = CountReplicasAcknowledgingOffset(targetReplicationOffset)
 	}
 
-	return fmt.Sprintf(":%d\r\n", acknowledgedReplicaCount)
+	return IntegerReply(int64(acknowledgedReplicaCount))
 }
 
 func requestReplicaAcknowledgements() {
 	getAcknowledgementCommand := []byte("*3\r\n$8\r\nREPLCONF\r\n$6\r\nGETACK\r\n$1\r\n*\r\n")
 	for _, replicaConnection := range ReplicaConnectionsSnapshot() {
-		if _, writeError := replicaConnection.Write(getAcknowledgementCommand); writeError != nil {
-			fmt.Printf("Error requesting acknowledgement from replica %s: %v\n", replicaConnection.RemoteAddr(), writeError)
+		if writeError := EnqueueToConnection(replicaConnection, getAcknowledgementCommand); writeError != nil {
+			LogWarning("Error requesting acknowledgement from replica %s: %v", replicaConnection.RemoteAddr(), writeError)
 		}
 	}
 }
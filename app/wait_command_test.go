@@ -30,7 +30,7 @@ func TestHandleWait_WithConnectedReplicasReturnsReplicaCount(t *testing.T) {
 		Args: []string{"9", "500"},
 	}
 
-	response := HandleWait(command)
+	response := HandleWait(command).String()
 
 	if response != ":2\r\n" {
 		t.Fatalf("expected WAIT response %q, got %q", ":2\r\n", response)
@@ -80,7 +80,7 @@ func TestHandleWait_WithPendingWriteCommandsReturnsAcknowledgedReplicaCount(t *t
 	response := HandleWait(&RedisCommand{
 		Type: CmdWAIT,
 		Args: []string{"2", "50"},
-	})
+	}).String()
 
 	if response != ":1\r\n" {
 		t.Fatalf("expected WAIT response %q, got %q", ":1\r\n", response)
@@ -1,10 +1,8 @@
 package main
 
-import "fmt"
-
-func HandleXadd(command *RedisCommand) string {
+func HandleXadd(command *RedisCommand) Reply {
 	if len(command.Args) < 4 || len(command.Args)%2 != 0 {
-		return "-ERR wrong number of arguments for 'xadd' command\r\n"
+		return WrongNumberOfArgumentsReply("xadd")
 	}
 
 	streamKey := command.Args[0]
@@ -34,5 +32,5 @@ func HandleXadd(command *RedisCommand) string {
 		StreamKey: streamKey,
 	})
 
-	return fmt.Sprintf("$%d\r\n%s\r\n", len(entryID), entryID)
+	return BulkStringReply(entryID)
 }
@@ -60,7 +60,7 @@ func TestHandleXadd(t *testing.T) {
 				Type: CmdXADD,
 				Args: []string{"stream_key", "1-2", "baz", "foo"},
 			},
-			expected: errXaddIDEqualOrSmallerThanTop,
+			expected: errXaddIDEqualOrSmallerThanTop.String(),
 		},
 		{
 			name: "xadd rejects id with lower milliseconds than last entry",
@@ -72,7 +72,7 @@ func TestHandleXadd(t *testing.T) {
 				Type: CmdXADD,
 				Args: []string{"stream_key", "0-3", "baz", "foo"},
 			},
-			expected: errXaddIDEqualOrSmallerThanTop,
+			expected: errXaddIDEqualOrSmallerThanTop.String(),
 		},
 		{
 			name: "xadd rejects 0-0 on empty stream",
@@ -82,7 +82,7 @@ func TestHandleXadd(t *testing.T) {
 				Type: CmdXADD,
 				Args: []string{"stream_key", "0-0", "baz", "foo"},
 			},
-			expected: errXaddIDMustBeGreaterThanZeroZero,
+			expected: errXaddIDMustBeGreaterThanZeroZero.String(),
 		},
 		{
 			name: "xadd rejects 0-0 when stream has entries",
@@ -93,7 +93,7 @@ func TestHandleXadd(t *testing.T) {
 				Type: CmdXADD,
 				Args: []string{"stream_key", "0-0", "baz", "foo"},
 			},
-			expected: errXaddIDMustBeGreaterThanZeroZero,
+			expected: errXaddIDMustBeGreaterThanZeroZero.String(),
 		},
 		{
 			name: "xadd rejects duplicate id on single entry stream",
@@ -104,7 +104,7 @@ func TestHandleXadd(t *testing.T) {
 				Type: CmdXADD,
 				Args: []string{"stream_key", "1-1", "bar", "baz"},
 			},
-			expected: errXaddIDEqualOrSmallerThanTop,
+			expected: errXaddIDEqualOrSmallerThanTop.String(),
 		},
 		{
 			name: "xadd auto generates sequence for 0-* on empty stream",
@@ -178,7 +178,7 @@ func TestHandleXadd(t *testing.T) {
 				tt.setup()
 			}
 
-			result := HandleXadd(tt.cmd)
+			result := HandleXadd(tt.cmd).String()
 			if result != tt.expected {
 				t.Errorf("HandleXadd() = %q, expected %q", result, tt.expected)
 			}
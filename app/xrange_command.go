@@ -1,16 +1,11 @@
 package main
 
-import (
-	"fmt"
-	"strings"
-)
-
-func parseXrangeCommandArguments(command *RedisCommand) (streamKey string, startID string, endID string, errorResponse string) {
+func parseXrangeCommandArguments(command *RedisCommand) (streamKey string, startID string, endID string, errorResponse Reply) {
 	if len(command.Args) != 3 {
-		return "", "", "", "-ERR wrong number of arguments for 'xrange' command\r\n"
+		return "", "", "", WrongNumberOfArgumentsReply("xrange")
 	}
 
-	return command.Args[0], command.Args[1], command.Args[2], ""
+	return command.Args[0], command.Args[1], command.Args[2], Reply{}
 }
 
 func buildXrangeResponse(stream *Stream, startBoundID string, endBoundID string) XrangeResponse {
@@ -33,38 +28,36 @@ func buildXrangeResponse(stream *Stream, startBoundID string, endBoundID string)
 	return xrangeResponse
 }
 
-func encodeXrangeResponse(xrangeResponse XrangeResponse) string {
-	var builder strings.Builder
+// encodeStreamEntry encodes one stream entry as [id, [field, value, ...]].
+func encodeStreamEntry(entryID string, fieldValues []string) Reply {
+	return ArrayReply(BulkStringReply(entryID), BulkStringArrayReply(fieldValues))
+}
 
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(xrangeResponse.Entries)))
+func encodeXrangeResponse(xrangeResponse XrangeResponse) Reply {
+	entries := make([]Reply, 0, len(xrangeResponse.Entries))
 	for _, entry := range xrangeResponse.Entries {
-		builder.WriteString("*2\r\n")
-		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(entry.EntryID), entry.EntryID))
-		builder.WriteString(fmt.Sprintf("*%d\r\n", len(entry.FieldValues)))
-		for _, fieldValue := range entry.FieldValues {
-			builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(fieldValue), fieldValue))
-		}
+		entries = append(entries, encodeStreamEntry(entry.EntryID, entry.FieldValues))
 	}
 
-	return builder.String()
+	return ArrayReply(entries...)
 }
 
-func HandleXrange(command *RedisCommand) string {
+func HandleXrange(command *RedisCommand) Reply {
 	streamKey, startID, endID, errorResponse := parseXrangeCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	startBoundID, err := normalizeRangeBoundID(startID, startSequenceNumberDefault)
 	if err != nil {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
-	stream := GetInstance().GetStream(streamKey)
+	stream := GetInstance().ReadStream(streamKey)
 
 	endBoundID, err := resolveXrangeEndBoundID(stream, endID)
 	if err != nil {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	xrangeResponse := buildXrangeResponse(stream, startBoundID, endBoundID)
@@ -74,8 +74,8 @@ func TestParseXrangeCommandArguments(t *testing.T) {
 
 			streamKey, startID, endID, errorResponse := parseXrangeCommandArguments(command)
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseXrangeCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseXrangeCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if streamKey != testCase.expectedStreamKey {
@@ -111,7 +111,7 @@ func TestHandleXrangeArgumentParsing(t *testing.T) {
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-			result := HandleXrange(testCase.cmd)
+			result := HandleXrange(testCase.cmd).String()
 			if result != testCase.expected {
 				t.Errorf("HandleXrange() = %q, expected %q", result, testCase.expected)
 			}
@@ -291,7 +291,7 @@ func TestHandleXrange(t *testing.T) {
 				testCase.setup()
 			}
 
-			result := HandleXrange(testCase.cmd)
+			result := HandleXrange(testCase.cmd).String()
 			if result != testCase.expected {
 				t.Errorf("HandleXrange() = %q, expected %q", result, testCase.expected)
 			}
@@ -17,8 +17,8 @@ func TestParseBlockingXreadCommand(t *testing.T) {
 		Args: []string{"BLOCK", "1000", "streams", "stream_key", "0-1"},
 	})
 
-	if errorResponse != "" {
-		t.Fatalf("parseXreadCommand() error = %q", errorResponse)
+	if errorResponse.String() != "" {
+		t.Fatalf("parseXreadCommand() error = %q", errorResponse.String())
 	}
 
 	if blockMilliseconds != 1000 {
@@ -45,7 +45,7 @@ func TestHandleBlockingXreadReturnsImmediatelyWhenEntriesExist(t *testing.T) {
 	result := HandleXread(&RedisCommand{
 		Type: CmdXREAD,
 		Args: []string{"BLOCK", "1000", "STREAMS", "stream_key", "0-0"},
-	})
+	}).String()
 
 	expected := formatExpectedXreadResponse(
 		formatExpectedXreadStreamResponse(
@@ -71,7 +71,7 @@ func TestHandleBlockingXreadWaitsForStreamChange(t *testing.T) {
 		resultChannel <- HandleXread(&RedisCommand{
 			Type: CmdXREAD,
 			Args: []string{"BLOCK", "1000", "STREAMS", "stream_key", "0-1"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -110,7 +110,7 @@ func TestHandleBlockingXreadWithZeroBlockWaitsIndefinitely(t *testing.T) {
 		resultChannel <- HandleXread(&RedisCommand{
 			Type: CmdXREAD,
 			Args: []string{"BLOCK", "0", "STREAMS", "stream_key", "0-1"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -142,8 +142,8 @@ func TestParseBlockingXreadCommandWithDollarStartID(t *testing.T) {
 		Args: []string{"BLOCK", "1000", "streams", "stream_key", "$"},
 	})
 
-	if errorResponse != "" {
-		t.Fatalf("parseXreadCommand() error = %q", errorResponse)
+	if errorResponse.String() != "" {
+		t.Fatalf("parseXreadCommand() error = %q", errorResponse.String())
 	}
 
 	if blockMilliseconds != 1000 {
@@ -172,7 +172,7 @@ func TestHandleBlockingXreadWithDollarStartIDWaitsForNewEntry(t *testing.T) {
 		resultChannel <- HandleXread(&RedisCommand{
 			Type: CmdXREAD,
 			Args: []string{"BLOCK", "0", "streams", "stream_key", "$"},
-		})
+		}).String()
 	}()
 
 	time.Sleep(50 * time.Millisecond)
@@ -210,11 +210,11 @@ func TestHandleBlockingXreadWithDollarStartIDReturnsNullArrayOnTimeout(t *testin
 	result := HandleXread(&RedisCommand{
 		Type: CmdXREAD,
 		Args: []string{"BLOCK", "100", "streams", "stream_key", "$"},
-	})
+	}).String()
 	elapsed := time.Since(startTime)
 
-	if result != blockingXreadTimeoutResponse {
-		t.Errorf("HandleXread() = %q, expected %q", result, blockingXreadTimeoutResponse)
+	if result != blockingXreadTimeoutResponse.String() {
+		t.Errorf("HandleXread() = %q, expected %q", result, blockingXreadTimeoutResponse.String())
 	}
 
 	if elapsed < 90*time.Millisecond {
@@ -234,11 +234,11 @@ func TestHandleBlockingXreadReturnsNullArrayOnTimeout(t *testing.T) {
 	result := HandleXread(&RedisCommand{
 		Type: CmdXREAD,
 		Args: []string{"block", "100", "streams", "stream_key", "0-1"},
-	})
+	}).String()
 	elapsed := time.Since(startTime)
 
-	if result != blockingXreadTimeoutResponse {
-		t.Errorf("HandleXread() = %q, expected %q", result, blockingXreadTimeoutResponse)
+	if result != blockingXreadTimeoutResponse.String() {
+		t.Errorf("HandleXread() = %q, expected %q", result, blockingXreadTimeoutResponse.String())
 	}
 
 	if elapsed < 90*time.Millisecond {
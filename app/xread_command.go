@@ -1,27 +1,26 @@
 package main
 
 import (
-	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const blockingXreadTimeoutResponse = "*-1\r\n"
+var blockingXreadTimeoutResponse = NullArrayReply()
 
-func parseXreadStreamsArguments(args []string) (streamKeys []string, startIDs []string, errorResponse string) {
+func parseXreadStreamsArguments(args []string) (streamKeys []string, startIDs []string, errorResponse Reply) {
 	if len(args) < 3 {
-		return nil, nil, "-ERR wrong number of arguments for 'xread' command\r\n"
+		return nil, nil, WrongNumberOfArgumentsReply("xread")
 	}
 
 	if !strings.EqualFold(args[0], "STREAMS") {
-		return nil, nil, "-ERR wrong number of arguments for 'xread' command\r\n"
+		return nil, nil, WrongNumberOfArgumentsReply("xread")
 	}
 
 	streamKeysAndIds := args[1:]
 	if len(streamKeysAndIds)%2 != 0 {
-		return nil, nil, "-ERR wrong number of arguments for 'xread' command\r\n"
+		return nil, nil, WrongNumberOfArgumentsReply("xread")
 	}
 
 	streamCount := len(streamKeysAndIds) / 2
@@ -32,17 +31,17 @@ func parseXreadStreamsArguments(args []string) (streamKeys []string, startIDs []
 		startIDs[index] = streamKeysAndIds[streamCount+index]
 	}
 
-	return streamKeys, startIDs, ""
+	return streamKeys, startIDs, Reply{}
 }
 
-func parseXreadCommand(command *RedisCommand) (streamKeys []string, startIDs []string, blockMilliseconds int, errorResponse string) {
+func parseXreadCommand(command *RedisCommand) (streamKeys []string, startIDs []string, blockMilliseconds int, errorResponse Reply) {
 	blockMilliseconds = -1
 	args := command.Args
 
 	if len(args) >= 2 && strings.EqualFold(args[0], "BLOCK") {
 		parsedBlockMilliseconds, parseError := strconv.Atoi(args[1])
 		if parseError != nil {
-			return nil, nil, -1, "-ERR wrong number of arguments for 'xread' command\r\n"
+			return nil, nil, -1, WrongNumberOfArgumentsReply("xread")
 		}
 
 		blockMilliseconds = parsedBlockMilliseconds
@@ -53,38 +52,38 @@ func parseXreadCommand(command *RedisCommand) (streamKeys []string, startIDs []s
 	return streamKeys, startIDs, blockMilliseconds, errorResponse
 }
 
-func parseXreadCommandArguments(command *RedisCommand) (streamKey string, startID string, errorResponse string) {
+func parseXreadCommandArguments(command *RedisCommand) (streamKey string, startID string, errorResponse Reply) {
 	streamKeys, startIDs, blockMilliseconds, errorResponse := parseXreadCommand(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return "", "", errorResponse
 	}
 
 	if blockMilliseconds >= 0 {
-		return "", "", "-ERR wrong number of arguments for 'xread' command\r\n"
+		return "", "", WrongNumberOfArgumentsReply("xread")
 	}
 
 	if len(streamKeys) != 1 {
-		return "", "", "-ERR wrong number of arguments for 'xread' command\r\n"
+		return "", "", WrongNumberOfArgumentsReply("xread")
 	}
 
-	return streamKeys[0], startIDs[0], ""
+	return streamKeys[0], startIDs[0], Reply{}
 }
 
-func parseManyXReadCommandArguments(command *RedisCommand) (streamKeys []string, startIds []string, errorResponse string) {
+func parseManyXReadCommandArguments(command *RedisCommand) (streamKeys []string, startIds []string, errorResponse Reply) {
 	streamKeys, startIds, blockMilliseconds, errorResponse := parseXreadCommand(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return nil, nil, errorResponse
 	}
 
 	if blockMilliseconds >= 0 {
-		return nil, nil, "-ERR wrong number of arguments for 'xread' command\r\n"
+		return nil, nil, WrongNumberOfArgumentsReply("xread")
 	}
 
 	if len(streamKeys) <= 1 {
-		return nil, nil, "-ERR wrong number of arguments for 'xread' command\r\n"
+		return nil, nil, WrongNumberOfArgumentsReply("xread")
 	}
 
-	return streamKeys, startIds, ""
+	return streamKeys, startIds, Reply{}
 }
 
 func resolveXreadStartIDs(streamKeys []string, startIDs []string) []string {
@@ -108,7 +107,7 @@ func buildXreadResponse(streamKey string, startID string) XreadResponse {
 		return XreadResponse{Streams: []XreadStreamResponse{}}
 	}
 
-	stream := GetInstance().GetStream(streamKey)
+	stream := GetInstance().ReadStream(streamKey)
 	entries := []XreadEntryResponse{}
 	if stream != nil {
 		for _, entry := range stream.Entries {
@@ -147,32 +146,21 @@ func buildMultiXreadResponse(streamKeys []string, startIDs []string) XreadRespon
 	return xreadResponse
 }
 
-func encodeXreadResponse(xreadResponse XreadResponse) string {
-	if len(xreadResponse.Streams) == 0 {
-		return "*0\r\n"
-	}
-
-	var builder strings.Builder
-
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(xreadResponse.Streams)))
+func encodeXreadResponse(xreadResponse XreadResponse) Reply {
+	streams := make([]Reply, 0, len(xreadResponse.Streams))
 	for _, stream := range xreadResponse.Streams {
-		builder.WriteString("*2\r\n")
-		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(stream.StreamKey), stream.StreamKey))
-		builder.WriteString(fmt.Sprintf("*%d\r\n", len(stream.Entries)))
+		entries := make([]Reply, 0, len(stream.Entries))
 		for _, entry := range stream.Entries {
-			builder.WriteString("*2\r\n")
-			builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(entry.EntryID), entry.EntryID))
-			builder.WriteString(fmt.Sprintf("*%d\r\n", len(entry.FieldValues)))
-			for _, fieldValue := range entry.FieldValues {
-				builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(fieldValue), fieldValue))
-			}
+			entries = append(entries, encodeStreamEntry(entry.EntryID, entry.FieldValues))
 		}
+
+		streams = append(streams, ArrayReply(BulkStringReply(stream.StreamKey), ArrayReply(entries...)))
 	}
 
-	return builder.String()
+	return ArrayReply(streams...)
 }
 
-func waitForBlockingXreadResponse(streamKeys []string, startIDs []string, blockMilliseconds int) string {
+func waitForBlockingXreadResponse(streamKeys []string, startIDs []string, blockMilliseconds int) Reply {
 	xreadResponse := buildMultiXreadResponse(streamKeys, startIDs)
 	if len(xreadResponse.Streams) > 0 {
 		return encodeXreadResponse(xreadResponse)
@@ -227,9 +215,9 @@ func waitForBlockingXreadResponse(streamKeys []string, startIDs []string, blockM
 	}
 }
 
-func HandleXread(command *RedisCommand) string {
+func HandleXread(command *RedisCommand) Reply {
 	streamKeys, startIDs, blockMilliseconds, errorResponse := parseXreadCommand(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
@@ -62,8 +62,8 @@ func TestParseXreadCommandArguments(t *testing.T) {
 
 			streamKey, startID, errorResponse := parseXreadCommandArguments(command)
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseXreadCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseXreadCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if streamKey != testCase.expectedStreamKey {
@@ -95,7 +95,7 @@ func TestHandleXreadArgumentParsing(t *testing.T) {
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-			result := HandleXread(testCase.cmd)
+			result := HandleXread(testCase.cmd).String()
 			if result != testCase.expected {
 				t.Errorf("HandleXread() = %q, expected %q", result, testCase.expected)
 			}
@@ -220,7 +220,7 @@ func TestHandleXread(t *testing.T) {
 				testCase.setup()
 			}
 
-			result := HandleXread(testCase.cmd)
+			result := HandleXread(testCase.cmd).String()
 			if result != testCase.expected {
 				t.Errorf("HandleXread() = %q, expected %q", result, testCase.expected)
 			}
@@ -319,7 +319,7 @@ func TestHandleXreadMultipleStreams(t *testing.T) {
 				testCase.setup()
 			}
 
-			result := HandleXread(testCase.cmd)
+			result := HandleXread(testCase.cmd).String()
 			if result != testCase.expected {
 				t.Errorf("HandleXread() = %q, expected %q", result, testCase.expected)
 			}
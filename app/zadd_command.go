@@ -1,29 +1,26 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-)
+import "strconv"
 
-func parseZaddCommandArguments(command *RedisCommand) (key string, score float64, member string, errorResponse string) {
+func parseZaddCommandArguments(command *RedisCommand) (key string, score float64, member string, errorResponse Reply) {
 	if len(command.Args) != 3 {
-		return "", 0, "", "-ERR wrong number of arguments for 'zadd' command\r\n"
+		return "", 0, "", WrongNumberOfArgumentsReply("zadd")
 	}
 
 	parsedScore, parseError := strconv.ParseFloat(command.Args[1], 64)
 	if parseError != nil {
-		return "", 0, "", "-ERR value is not a valid float\r\n"
+		return "", 0, "", errNotFloatReply
 	}
 
-	return command.Args[0], parsedScore, command.Args[2], ""
+	return command.Args[0], parsedScore, command.Args[2], Reply{}
 }
 
-func HandleZadd(command *RedisCommand) string {
+func HandleZadd(command *RedisCommand) Reply {
 	key, score, member, errorResponse := parseZaddCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	newMembersAdded := GetInstance().Zadd(key, score, member)
-	return fmt.Sprintf(":%d\r\n", newMembersAdded)
+	return IntegerReply(int64(newMembersAdded))
 }
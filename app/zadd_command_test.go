@@ -73,8 +73,8 @@ func TestParseZaddCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseZaddCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseZaddCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if key != testCase.expectedKey {
@@ -98,7 +98,7 @@ func TestHandleZaddCodecraftersStageExample(t *testing.T) {
 	result := HandleZadd(&RedisCommand{
 		Type: CmdZADD,
 		Args: []string{"zset_key", "10.0", "zset_member"},
-	})
+	}).String()
 
 	if result != ":1\r\n" {
 		t.Errorf("HandleZadd() = %q, expected %q", result, ":1\r\n")
@@ -111,7 +111,7 @@ func TestHandleZaddCreatesSortedSetWithMemberAndScore(t *testing.T) {
 	result := HandleZadd(&RedisCommand{
 		Type: CmdZADD,
 		Args: []string{"zset_key", "10.0", "zset_member"},
-	})
+	}).String()
 	if result != ":1\r\n" {
 		t.Fatalf("HandleZadd() = %q, expected %q", result, ":1\r\n")
 	}
@@ -141,7 +141,7 @@ func TestHandleZaddArgumentParsing(t *testing.T) {
 	result := HandleZadd(&RedisCommand{
 		Type: CmdZADD,
 		Args: []string{"zset_key", "10.0"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'zadd' command\r\n" {
 		t.Errorf("HandleZadd() = %q, expected %q", result, "-ERR wrong number of arguments for 'zadd' command\r\n")
@@ -1,21 +1,19 @@
 package main
 
-import "fmt"
-
-func parseZcardCommandArguments(command *RedisCommand) (key string, errorResponse string) {
+func parseZcardCommandArguments(command *RedisCommand) (key string, errorResponse Reply) {
 	if len(command.Args) != 1 {
-		return "", "-ERR wrong number of arguments for 'zcard' command\r\n"
+		return "", WrongNumberOfArgumentsReply("zcard")
 	}
 
-	return command.Args[0], ""
+	return command.Args[0], Reply{}
 }
 
-func HandleZcard(command *RedisCommand) string {
+func HandleZcard(command *RedisCommand) Reply {
 	key, errorResponse := parseZcardCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	memberCount := GetInstance().Zcard(key)
-	return fmt.Sprintf(":%d\r\n", memberCount)
+	return IntegerReply(int64(memberCount))
 }
@@ -40,8 +40,8 @@ func TestParseZcardCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseZcardCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseZcardCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if key != testCase.expectedKey {
@@ -64,7 +64,7 @@ func TestHandleZcardCodecraftersStageExample(t *testing.T) {
 	result := HandleZcard(&RedisCommand{
 		Type: CmdZCARD,
 		Args: []string{"zset_key"},
-	})
+	}).String()
 	if result != ":4\r\n" {
 		t.Errorf("HandleZcard() = %q, expected %q", result, ":4\r\n")
 	}
@@ -72,7 +72,7 @@ func TestHandleZcardCodecraftersStageExample(t *testing.T) {
 	updateResult := HandleZadd(&RedisCommand{
 		Type: CmdZADD,
 		Args: []string{"zset_key", "100.0", "zset_member1"},
-	})
+	}).String()
 	if updateResult != ":0\r\n" {
 		t.Errorf("HandleZadd() = %q, expected %q", updateResult, ":0\r\n")
 	}
@@ -80,7 +80,7 @@ func TestHandleZcardCodecraftersStageExample(t *testing.T) {
 	resultAfterUpdate := HandleZcard(&RedisCommand{
 		Type: CmdZCARD,
 		Args: []string{"zset_key"},
-	})
+	}).String()
 	if resultAfterUpdate != ":4\r\n" {
 		t.Errorf("HandleZcard() after score update = %q, expected %q", resultAfterUpdate, ":4\r\n")
 	}
@@ -92,7 +92,7 @@ func TestHandleZcardMissingKeyReturnsZero(t *testing.T) {
 	result := HandleZcard(&RedisCommand{
 		Type: CmdZCARD,
 		Args: []string{"missing_key"},
-	})
+	}).String()
 	if result != ":0\r\n" {
 		t.Errorf("HandleZcard() = %q, expected %q", result, ":0\r\n")
 	}
@@ -104,7 +104,7 @@ func TestHandleZcardArgumentParsing(t *testing.T) {
 	result := HandleZcard(&RedisCommand{
 		Type: CmdZCARD,
 		Args: []string{},
-	})
+	}).String()
 	if result != "-ERR wrong number of arguments for 'zcard' command\r\n" {
 		t.Errorf("HandleZcard() = %q, expected %q", result, "-ERR wrong number of arguments for 'zcard' command\r\n")
 	}
@@ -1,59 +1,45 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-	"strings"
-)
+import "strconv"
 
-func parseZrangeCommandArguments(command *RedisCommand) (key string, startIndex string, stopIndex string, errorResponse string) {
+func parseZrangeCommandArguments(command *RedisCommand) (key string, startIndex string, stopIndex string, errorResponse Reply) {
 	if len(command.Args) != 3 {
-		return "", "", "", "-ERR wrong number of arguments for 'zrange' command\r\n"
+		return "", "", "", WrongNumberOfArgumentsReply("zrange")
 	}
 
-	return command.Args[0], command.Args[1], command.Args[2], ""
+	return command.Args[0], command.Args[1], command.Args[2], Reply{}
 }
 
-func encodeZrangeResponse(members []string) string {
-	if len(members) == 0 {
-		return "*0\r\n"
-	}
-
-	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("*%d\r\n", len(members)))
-	for _, member := range members {
-		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(member), member))
-	}
-
-	return builder.String()
+func encodeZrangeResponse(members []string) Reply {
+	return BulkStringArrayReply(members)
 }
 
-func HandleZrange(command *RedisCommand) string {
+func HandleZrange(command *RedisCommand) Reply {
 	key, startIndexString, stopIndexString, errorResponse := parseZrangeCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
 	startIndex, parseStartError := strconv.Atoi(startIndexString)
 	if parseStartError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	stopIndex, parseStopError := strconv.Atoi(stopIndexString)
 	if parseStopError != nil {
-		return "-ERR value is not an integer or out of range\r\n"
+		return errNotIntegerReply
 	}
 
 	sortedSet := GetInstance().GetSortedSet(key)
 	if sortedSet == nil {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	sortedSetLength := sortedSet.orderedIndex.Length()
 
 	startIndex = normalizeLrangeIndex(startIndex, sortedSetLength)
 	if startIndex >= sortedSetLength {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	stopIndex = normalizeLrangeIndex(stopIndex, sortedSetLength)
@@ -62,7 +48,7 @@ func HandleZrange(command *RedisCommand) string {
 	}
 
 	if startIndex > stopIndex {
-		return "*0\r\n"
+		return ArrayReply()
 	}
 
 	members := GetInstance().Zrange(key, startIndex, stopIndex)
@@ -75,8 +75,8 @@ func TestParseZrangeCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseZrangeCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseZrangeCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if key != testCase.expectedKey {
@@ -100,7 +100,7 @@ func TestHandleZrangeCodecraftersStageExample(t *testing.T) {
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "2", "4"},
-	})
+	}).String()
 
 	expected := formatExpectedZrangeResponse("paz", "bar", "foo")
 	if result != expected {
@@ -121,7 +121,7 @@ func TestHandleZrangeStageDocumentationExample(t *testing.T) {
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"racer_scores", "0", "2"},
-	})
+	}).String()
 
 	expected := formatExpectedZrangeResponse("Ford", "Sam-Bodden", "Royce")
 	if result != expected {
@@ -135,7 +135,7 @@ func TestHandleZrangeMissingKeyReturnsEmptyArray(t *testing.T) {
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"missing_key", "0", "2"},
-	})
+	}).String()
 
 	if result != "*0\r\n" {
 		t.Errorf("HandleZrange() = %q, expected %q", result, "*0\r\n")
@@ -148,7 +148,7 @@ func TestHandleZrangeStartIndexGreaterThanStopIndexReturnsEmptyArray(t *testing.
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "4", "2"},
-	})
+	}).String()
 
 	if result != "*0\r\n" {
 		t.Errorf("HandleZrange() = %q, expected %q", result, "*0\r\n")
@@ -161,7 +161,7 @@ func TestHandleZrangeStartIndexGreaterThanCardinalityReturnsEmptyArray(t *testin
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "5", "6"},
-	})
+	}).String()
 
 	if result != "*0\r\n" {
 		t.Errorf("HandleZrange() = %q, expected %q", result, "*0\r\n")
@@ -174,7 +174,7 @@ func TestHandleZrangeStopIndexGreaterThanCardinalityClampsToLastElement(t *testi
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "3", "99"},
-	})
+	}).String()
 
 	expected := formatExpectedZrangeResponse("bar", "foo")
 	if result != expected {
@@ -201,7 +201,7 @@ func TestHandleZrangeCodecraftersNegativeIndexStageExample(t *testing.T) {
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "2", "-1"},
-	})
+	}).String()
 
 	expected := formatExpectedZrangeResponse("paz", "bar", "foo")
 	if result != expected {
@@ -255,7 +255,7 @@ func TestHandleZrangeNegativeIndexes(t *testing.T) {
 			result := HandleZrange(&RedisCommand{
 				Type: CmdZRANGE,
 				Args: testCase.args,
-			})
+			}).String()
 
 			if result != testCase.expected {
 				t.Errorf("HandleZrange() = %q, expected %q", result, testCase.expected)
@@ -270,7 +270,7 @@ func TestHandleZrangeArgumentParsing(t *testing.T) {
 	result := HandleZrange(&RedisCommand{
 		Type: CmdZRANGE,
 		Args: []string{"zset_key", "0"},
-	})
+	}).String()
 
 	if result != "-ERR wrong number of arguments for 'zrange' command\r\n" {
 		t.Errorf("HandleZrange() = %q, expected %q", result, "-ERR wrong number of arguments for 'zrange' command\r\n")
@@ -1,26 +1,24 @@
 package main
 
-import "fmt"
-
-func parseZrankCommandArguments(command *RedisCommand) (key string, member string, errorResponse string) {
+func parseZrankCommandArguments(command *RedisCommand) (key string, member string, errorResponse Reply) {
 	if len(command.Args) != 2 {
-		return "", "", "-ERR wrong number of arguments for 'zrank' command\r\n"
+		return "", "", WrongNumberOfArgumentsReply("zrank")
 	}
 
-	return command.Args[0], command.Args[1], ""
+	return command.Args[0], command.Args[1], Reply{}
 }
 
-func encodeZrankResponse(rank int) string {
-	return fmt.Sprintf(":%d\r\n", rank)
+func encodeZrankResponse(rank int) Reply {
+	return IntegerReply(int64(rank))
 }
 
-func encodeZrankNullResponse() string {
-	return "$-1\r\n"
+func encodeZrankNullResponse() Reply {
+	return NullReply()
 }
 
-func HandleZrank(command *RedisCommand) string {
+func HandleZrank(command *RedisCommand) Reply {
 	key, member, errorResponse := parseZrankCommandArguments(command)
-	if errorResponse != "" {
+	if !errorResponse.IsEmpty() {
 		return errorResponse
 	}
 
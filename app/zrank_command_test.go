@@ -17,7 +17,7 @@ func addSortedSetMembers(t *testing.T, key string, members ...SortedSetMember) {
 		result := HandleZadd(&RedisCommand{
 			Type: CmdZADD,
 			Args: []string{key, formatFloatScore(member.Score), member.Member},
-		})
+		}).String()
 		if result != ":1\r\n" {
 			t.Fatalf("HandleZadd(%q, %v) = %q, expected %q", member.Member, member.Score, result, ":1\r\n")
 		}
@@ -73,8 +73,8 @@ func TestParseZrankCommandArguments(t *testing.T) {
 				Args: testCase.args,
 			})
 
-			if errorResponse != testCase.expectedError {
-				t.Errorf("parseZrankCommandArguments() error = %q, expected %q", errorResponse, testCase.expectedError)
+			if errorResponse.String() != testCase.expectedError {
+				t.Errorf("parseZrankCommandArguments() error = %q, expected %q", errorResponse.String(), testCase.expectedError)
 			}
 
 			if key != testCase.expectedKey {
@@ -110,7 +110,7 @@ func TestHandleZrankStageDocumentationExample(t *testing.T) {
 		result := HandleZrank(&RedisCommand{
 			Type: CmdZRANK,
 			Args: []string{"zset_key", testCase.member},
-		})
+		}).String()
 		if result != testCase.expectedResp {
 			t.Errorf("HandleZrank(%q) = %q, expected %q", testCase.member, result, testCase.expectedResp)
 		}
@@ -142,7 +142,7 @@ func TestHandleZrankCodecraftersStageExample(t *testing.T) {
 		result := HandleZrank(&RedisCommand{
 			Type: CmdZRANK,
 			Args: []string{"zset_key", testCase.member},
-		})
+		}).String()
 		if result != testCase.expectedResp {
 			t.Errorf("HandleZrank(%q) = %q, expected %q", testCase.member, result, testCase.expectedResp)
 		}
@@ -157,7 +157,7 @@ func TestHandleZrankMissingMemberReturnsNullBulkString(t *testing.T) {
 	result := HandleZrank(&RedisCommand{
 		Type: CmdZRANK,
 		Args: []string{"zset_key", "missing_member"},
-	})
+	}).String()
 	if result != "$-1\r\n" {
 		t.Errorf("HandleZrank() = %q, expected %q", result, "$-1\r\n")
 	}
@@ -169,7 +169,7 @@ func TestHandleZrankMissingKeyReturnsNullBulkString(t *testing.T) {
 	result := HandleZrank(&RedisCommand{
 		Type: CmdZRANK,
 		Args: []string{"missing_key", "member"},
-	})
+	}).String()
 	if result != "$-1\r\n" {
 		t.Errorf("HandleZrank() = %q, expected %q", result, "$-1\r\n")
 	}
@@ -181,7 +181,7 @@ func TestHandleZrankArgumentParsing(t *testing.T) {
 	result := HandleZrank(&RedisCommand{
 		Type: CmdZRANK,
 		Args: []string{"zset_key"},
-	})
+	}).String()
 	if result != "-ERR wrong number of arguments for 'zrank' command\r\n" {
 		t.Errorf("HandleZrank() = %q, expected %q", result, "-ERR wrong number of arguments for 'zrank' command\r\n")
 	}